@@ -0,0 +1,285 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// ErrNotRunning is returned by Trigger when the reconciliation loop is not running, e.g. because this replica does not
+// hold the leader election lease
+var ErrNotRunning = errors.New("the reconciliation loop is not running")
+
+var controllerPaused = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "paused",
+		Help:      "Whether the reconciliation loop is paused (0: running, 1: paused).",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(controllerPaused)
+}
+
+// ZoneStatus is the sync status of a DNS zone
+type ZoneStatus struct {
+	LastSyncTime  *time.Time `json:"lastSyncTime,omitempty"`
+	LastError     string     `json:"lastError,omitempty"`
+	LastErrorTime *time.Time `json:"lastErrorTime,omitempty"`
+}
+
+// Status is the state of the reconciliation loop
+type Status struct {
+	Paused              bool                  `json:"paused"`
+	CircuitBreaker      string                `json:"circuitBreaker"`
+	ConsecutiveFailures int                   `json:"consecutiveFailures"`
+	LastError           string                `json:"lastError,omitempty"`
+	NextRunAt           *time.Time            `json:"nextRunAt,omitempty"`
+	Zones               map[string]ZoneStatus `json:"zones,omitempty"`
+}
+
+// Endpoints are the desired and registry endpoints of the last plan
+type Endpoints struct {
+	Desired  []*endpoint.Endpoint `json:"desired"`
+	Registry []*endpoint.Endpoint `json:"registry"`
+}
+
+// Pause stops the reconciliation loop until Resume is called. Manually triggered runs are still executed.
+func (c *Controller) Pause() {
+	c.nextRunAtMux.Lock()
+	defer c.nextRunAtMux.Unlock()
+	c.paused = true
+	controllerPaused.Set(1)
+	log.Info("Reconciliation paused")
+}
+
+// Resume restarts a paused reconciliation loop.
+func (c *Controller) Resume() {
+	c.nextRunAtMux.Lock()
+	defer c.nextRunAtMux.Unlock()
+	c.paused = false
+	controllerPaused.Set(0)
+	log.Info("Reconciliation resumed")
+}
+
+// Trigger asks the reconciliation loop started by Run for an immediate run, regardless of the interval,
+// a pending retry or a pause, and waits for its result. It returns ErrNotRunning if the loop is not running.
+func (c *Controller) Trigger(ctx context.Context) error {
+	c.nextRunAtMux.Lock()
+	running := c.running
+	c.nextRunAtMux.Unlock()
+	if running == nil {
+		return ErrNotRunning
+	}
+
+	result := make(chan error, 1)
+	select {
+	case c.triggerQueue() <- result:
+	case <-running:
+		return ErrNotRunning
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// triggerQueue returns the channel manually triggered runs are passed on.
+func (c *Controller) triggerQueue() chan chan error {
+	c.nextRunAtMux.Lock()
+	defer c.nextRunAtMux.Unlock()
+	if c.triggers == nil {
+		c.triggers = make(chan chan error)
+	}
+	return c.triggers
+}
+
+// startLoop marks the reconciliation loop as running, until stopLoop is called.
+func (c *Controller) startLoop() {
+	c.nextRunAtMux.Lock()
+	defer c.nextRunAtMux.Unlock()
+	c.running = make(chan struct{})
+}
+
+// stopLoop marks the reconciliation loop as stopped, the pending triggers fail with ErrNotRunning.
+func (c *Controller) stopLoop() {
+	c.nextRunAtMux.Lock()
+	defer c.nextRunAtMux.Unlock()
+	close(c.running)
+	c.running = nil
+}
+
+// Status returns the state of the reconciliation loop.
+func (c *Controller) Status() Status {
+	c.nextRunAtMux.Lock()
+	status := Status{
+		Paused:              c.paused,
+		CircuitBreaker:      c.retry.breaker.String(),
+		ConsecutiveFailures: c.retry.failures,
+	}
+	if c.retry.lastError != nil {
+		status.LastError = c.retry.lastError.Error()
+	}
+	if !c.nextRunAt.IsZero() {
+		nextRunAt := c.nextRunAt
+		status.NextRunAt = &nextRunAt
+	}
+	c.nextRunAtMux.Unlock()
+
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+	if len(c.zones) > 0 {
+		status.Zones = make(map[string]ZoneStatus, len(c.zones))
+		for zoneName, zone := range c.zones {
+			status.Zones[zoneName] = *zone
+		}
+	}
+	return status
+}
+
+// setZoneStatus records the result of applying changes to a DNS zone.
+func (c *Controller) setZoneStatus(zoneName string, now time.Time, err error) {
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+	if c.zones == nil {
+		c.zones = map[string]*ZoneStatus{}
+	}
+	zone, ok := c.zones[zoneName]
+	if !ok {
+		zone = &ZoneStatus{}
+		c.zones[zoneName] = zone
+	}
+	if err != nil {
+		zone.LastError = err.Error()
+		zone.LastErrorTime = &now
+		return
+	}
+	zone.LastSyncTime = &now
+}
+
+// LastPlan returns the last calculated plan, nil if there is none yet.
+func (c *Controller) LastPlan() *plan.Plan {
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+	return c.lastPlan
+}
+
+// AdminHandler serves the admin API:
+//   - POST /admin/sync runs a synchronization immediately, or fails with 503 if the reconciliation loop is not running
+//   - POST /admin/pause and POST /admin/resume pause and resume the reconciliation loop
+//   - GET /admin/status returns the state of the reconciliation loop and the last error per zone
+//   - GET /admin/plan returns the changes of the last plan, as a human-readable diff with ?format=text
+//   - GET /admin/endpoints returns the desired and registry endpoints of the last plan
+//
+// Every request must present the given token as a bearer token in its Authorization header.
+func (c *Controller) AdminHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/sync", allowMethod(http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+		err := c.Trigger(r.Context())
+		switch {
+		case errors.Is(err, ErrNotRunning):
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, c.Status())
+	}))
+	mux.HandleFunc("/admin/pause", allowMethod(http.MethodPost, func(w http.ResponseWriter, _ *http.Request) {
+		c.Pause()
+		writeJSON(w, c.Status())
+	}))
+	mux.HandleFunc("/admin/resume", allowMethod(http.MethodPost, func(w http.ResponseWriter, _ *http.Request) {
+		c.Resume()
+		writeJSON(w, c.Status())
+	}))
+	mux.HandleFunc("/admin/status", allowMethod(http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, c.Status())
+	}))
+	mux.HandleFunc("/admin/plan", allowMethod(http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+		var changes *plan.Changes
+		if p := c.LastPlan(); p != nil {
+			changes = p.Changes
+		}
+		if r.URL.Query().Get("format") == string(plan.DiffFormatText) {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			w.Write([]byte(changes.Diff().String()))
+			return
+		}
+		writeJSON(w, changes.Diff())
+	}))
+	mux.HandleFunc("/admin/endpoints", allowMethod(http.MethodGet, func(w http.ResponseWriter, _ *http.Request) {
+		endpoints := Endpoints{Desired: []*endpoint.Endpoint{}, Registry: []*endpoint.Endpoint{}}
+		if p := c.LastPlan(); p != nil {
+			endpoints.Desired = p.Desired
+			endpoints.Registry = p.Current
+		}
+		writeJSON(w, endpoints)
+	}))
+	return requireToken(token, mux)
+}
+
+// requireToken rejects requests which do not present the given bearer token. All requests are rejected if
+// the token is empty.
+func requireToken(token string, handler http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// allowMethod rejects requests with another method than the given one.
+func allowMethod(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func newAdminTestController(t *testing.T) (*Controller, *filteredMockProvider) {
+	t.Helper()
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "2.2.2.2"),
+	}, nil)
+	p := &filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		},
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+	return &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		Interval:           time.Hour,
+	}, p
+}
+
+const testAdminToken = "secret"
+
+// waitForLoop waits until the reconciliation loop started by Run accepts triggers
+func waitForLoop(t *testing.T, ctrl *Controller) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		ctrl.nextRunAtMux.Lock()
+		defer ctrl.nextRunAtMux.Unlock()
+		return ctrl.running != nil
+	}, time.Second, time.Millisecond)
+}
+
+func serveAdmin(t *testing.T, ctrl *Controller, method, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	ctrl.AdminHandler(testAdminToken).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestPauseResume(t *testing.T) {
+	ctrl, _ := newAdminTestController(t)
+	now := time.Now()
+
+	ctrl.Pause()
+	assert.False(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.Status().Paused)
+
+	ctrl.Resume()
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.False(t, ctrl.Status().Paused)
+}
+
+func TestAdminSync(t *testing.T) {
+	ctrl, p := newAdminTestController(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctrl.Pause()
+	// the regular run is due far in the future, only the trigger can run the loop
+	ctrl.nextRunAt = time.Now().Add(time.Hour)
+	go ctrl.Run(ctx)
+	waitForLoop(t, ctrl)
+
+	rec := serveAdmin(t, ctrl, http.MethodPost, "/admin/sync")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Len(t, p.ApplyChangesCalls, 1)
+
+	status := Status{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.True(t, status.Paused)
+	assert.Equal(t, "closed", status.CircuitBreaker)
+}
+
+func TestAdminSyncNotRunning(t *testing.T) {
+	ctrl, p := newAdminTestController(t)
+
+	// without a running loop, e.g. on a standby replica, the trigger fails immediately
+	assert.ErrorIs(t, ctrl.Trigger(context.Background()), ErrNotRunning)
+	rec := serveAdmin(t, ctrl, http.MethodPost, "/admin/sync")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, rec.Body.String())
+
+	// the trigger fails again once the loop stops, e.g. when the leader election lease is lost
+	ctx, cancel := context.WithCancel(context.Background())
+	ctrl.nextRunAt = time.Now().Add(time.Hour)
+	stopped := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(stopped)
+	}()
+	waitForLoop(t, ctrl)
+	require.NoError(t, ctrl.Trigger(context.Background()))
+	cancel()
+	<-stopped
+	assert.ErrorIs(t, ctrl.Trigger(context.Background()), ErrNotRunning)
+	assert.Len(t, p.ApplyChangesCalls, 1)
+}
+
+func TestAdminSyncCanceled(t *testing.T) {
+	ctrl, _ := newAdminTestController(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// a running loop which cannot pick up the trigger in time
+	ctrl.startLoop()
+	defer ctrl.stopLoop()
+	assert.ErrorIs(t, ctrl.Trigger(ctx), context.Canceled)
+}
+
+func TestAdminPlanAndEndpoints(t *testing.T) {
+	ctrl, _ := newAdminTestController(t)
+
+	rec := serveAdmin(t, ctrl, http.MethodGet, "/admin/plan")
+	require.Equal(t, http.StatusOK, rec.Code)
+	diff := &plan.Diff{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), diff))
+	assert.Empty(t, diff.Records)
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	rec = serveAdmin(t, ctrl, http.MethodGet, "/admin/plan")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), diff))
+	assert.Equal(t, plan.DiffSummary{Update: 1}, diff.Summary)
+
+	rec = serveAdmin(t, ctrl, http.MethodGet, "/admin/plan?format=text")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "~ update a.example.org A [targets]"))
+
+	rec = serveAdmin(t, ctrl, http.MethodGet, "/admin/endpoints")
+	require.Equal(t, http.StatusOK, rec.Code)
+	endpoints := Endpoints{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &endpoints))
+	require.Len(t, endpoints.Desired, 1)
+	require.Len(t, endpoints.Registry, 1)
+	assert.Equal(t, endpoint.Targets{"2.2.2.2"}, endpoints.Desired[0].Targets)
+	assert.Equal(t, endpoint.Targets{"1.1.1.1"}, endpoints.Registry[0].Targets)
+}
+
+func TestAdminMethodNotAllowed(t *testing.T) {
+	ctrl, _ := newAdminTestController(t)
+
+	rec := serveAdmin(t, ctrl, http.MethodGet, "/admin/pause")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+	assert.False(t, ctrl.Status().Paused)
+
+	rec = serveAdmin(t, ctrl, http.MethodPost, "/admin/pause")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, ctrl.Status().Paused)
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	ctrl, _ := newAdminTestController(t)
+
+	for _, tc := range []struct {
+		name          string
+		token         string
+		authorization string
+	}{
+		{name: "missing token", token: testAdminToken},
+		{name: "wrong token", token: testAdminToken, authorization: "Bearer other"},
+		{name: "no token configured", authorization: "Bearer "},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/admin/pause", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			ctrl.AdminHandler(tc.token).ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.False(t, ctrl.Status().Paused)
+		})
+	}
+}
+
+func TestAdminZoneStatus(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "2.2.2.2"),
+	}, nil)
+	p := &zoneMockProvider{
+		zones:    provider.ZoneIDName{"z1": "example.org", "z2": "example.com"},
+		failZone: "example.com",
+		failErr:  errors.New("invalid credentials"),
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+	ctrl := &Controller{
+		Source:                  source,
+		Registry:                r,
+		Policy:                  &plan.SyncPolicy{},
+		ManagedRecordTypes:      []string{endpoint.RecordTypeA},
+		ZoneProvider:            p,
+		ZoneConcurrency:         1,
+		CircuitBreakerThreshold: 3,
+	}
+
+	err = ctrl.RunOnce(context.Background())
+	require.Error(t, err)
+	require.True(t, ctrl.handleRunOnceResult(time.Now(), err))
+
+	rec := serveAdmin(t, ctrl, http.MethodGet, "/admin/status")
+	require.Equal(t, http.StatusOK, rec.Code)
+	status := Status{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+	assert.Equal(t, 1, status.ConsecutiveFailures)
+	assert.Contains(t, status.LastError, "invalid credentials")
+	require.Len(t, status.Zones, 2)
+	assert.Equal(t, "invalid credentials", status.Zones["example.com"].LastError)
+	assert.NotNil(t, status.Zones["example.com"].LastErrorTime)
+	assert.Nil(t, status.Zones["example.com"].LastSyncTime)
+	assert.Empty(t, status.Zones["example.org"].LastError)
+	assert.NotNil(t, status.Zones["example.org"].LastSyncTime)
+}
@@ -18,7 +18,6 @@ package controller
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -82,6 +81,30 @@ var (
 			Help:      "Timestamp of last attempted sync with the DNS provider",
 		},
 	)
+	consecutiveFailures = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "consecutive_failures",
+			Help:      "Number of consecutive failed syncs with the DNS provider",
+		},
+	)
+	nextRetryTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "next_retry_timestamp_seconds",
+			Help:      "Timestamp of the next retry after a failed sync, 0 if the last sync succeeded",
+		},
+	)
+	circuitBreakerState = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "circuit_breaker_state",
+			Help:      "State of the reconcile circuit breaker (0: closed, 1: open, 2: half-open)",
+		},
+	)
 	controllerNoChangesTotal = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Namespace: "external_dns",
@@ -161,6 +184,9 @@ func init() {
 	prometheus.MustRegister(registryEndpointsTotal)
 	prometheus.MustRegister(lastSyncTimestamp)
 	prometheus.MustRegister(lastReconcileTimestamp)
+	prometheus.MustRegister(consecutiveFailures)
+	prometheus.MustRegister(nextRetryTimestamp)
+	prometheus.MustRegister(circuitBreakerState)
 	prometheus.MustRegister(deprecatedRegistryErrors)
 	prometheus.MustRegister(deprecatedSourceErrors)
 	prometheus.MustRegister(controllerNoChangesTotal)
@@ -183,6 +209,8 @@ type Controller struct {
 	Registry registry.Registry
 	// The policy that defines which changes to DNS records are allowed
 	Policy plan.Policy
+	// The ConflictResolver that decides which resource acquires a DNS name claimed by several resources
+	ConflictResolver plan.ConflictResolver
 	// The interval between individual synchronizations
 	Interval time.Duration
 	// The DomainFilter defines which DNS records to keep or exclude
@@ -197,6 +225,65 @@ type Controller struct {
 	ExcludeRecordTypes []string
 	// MinEventSyncInterval is used as window for batching events
 	MinEventSyncInterval time.Duration
+	// ZoneProvider is used to split the changes by DNS zone, when set
+	ZoneProvider provider.ZoneProvider
+	// ZoneConcurrency is the maximum number of zones changes are applied to in parallel
+	ZoneConcurrency int
+	// BackoffInitialInterval is the delay before retrying a failed synchronization, doubled after every
+	// consecutive failure. If zero, failed synchronizations are retried on the regular Interval.
+	BackoffInitialInterval time.Duration
+	// BackoffMaxInterval caps the delay between retries of failed synchronizations
+	BackoffMaxInterval time.Duration
+	// CircuitBreakerThreshold is the number of consecutive non-soft errors after which the circuit breaker
+	// opens instead of terminating the process. If zero, the first non-soft error is fatal.
+	CircuitBreakerThreshold int
+	// CircuitBreakerCooldown is the time the circuit breaker stays open before a trial synchronization
+	CircuitBreakerCooldown time.Duration
+	// MaxDeletes is the maximum number of records deleted by a synchronization, zero for no limit
+	MaxDeletes int
+	// MaxDeletesPercent is the maximum number of records deleted by a synchronization, in percent of the owned records
+	MaxDeletesPercent int
+	// MaxChanges is the maximum number of records changed by a synchronization, zero for no limit
+	MaxChanges int
+	// DeletionGracePeriod is the time a record has to stay undesired before it gets deleted
+	DeletionGracePeriod time.Duration
+	// TruncateChanges applies the changes up to the exceeded limit instead of skipping all of them
+	TruncateChanges bool
+	// PlanOutput is the path the planned changes of every synchronization are written to, if set
+	PlanOutput string
+	// AllowApexCNAME allows CNAME records at the apex of the zones listed by the ZoneProvider
+	AllowApexCNAME bool
+	// apexWarning logs once that CNAME records at the zone apex cannot be refused, as the zones are not known
+	apexWarning sync.Once
+	// AliasFlattener publishes the ALIAS endpoints the provider does not support as address records, when set
+	AliasFlattener *provider.AliasFlattener
+	// EventRecorder records events on the objects records originate from, if set
+	EventRecorder EventRecorder
+	// events is the last event emitted for every record of a source object, guarded by statusMux
+	events map[eventKey]string
+	// eventsQueue passes the events to the single worker recording them in the background
+	eventsQueue chan event
+	// eventsOnce starts the worker recording the events
+	eventsOnce sync.Once
+	// eventsWG tracks the events being recorded in the background
+	eventsWG sync.WaitGroup
+	// StatusRecorders record the state of the records of the source objects they manage, e.g. DNSEndpoints
+	StatusRecorders []StatusRecorder
+	// lastPlan is the last calculated plan, guarded by statusMux
+	lastPlan *plan.Plan
+	// zones is the sync status of every DNS zone changes were applied to, guarded by statusMux
+	zones map[string]*ZoneStatus
+	// statusMux guards the status reported by the admin API
+	statusMux sync.Mutex
+	// paused stops the reconciliation loop, guarded by nextRunAtMux
+	paused bool
+	// triggers passes manually triggered runs to the reconciliation loop
+	triggers chan chan error
+	// running is closed when the reconciliation loop started by Run stops, nil while it is not running, guarded by
+	// nextRunAtMux
+	running chan struct{}
+	// retry tracks consecutive failures, guarded by nextRunAtMux
+	retry retryState
 }
 
 // RunOnce runs a single iteration of a reconciliation loop.
@@ -229,35 +316,80 @@ func (c *Controller) RunOnce(ctx context.Context) error {
 	vARecords, vAAAARecords := countMatchingAddressRecords(endpoints, records)
 	verifiedARecords.Set(float64(vARecords))
 	verifiedAAAARecords.Set(float64(vAAAARecords))
+	endpoints, rejected := validateEndpoints(endpoints)
+	if c.AliasFlattener != nil && plan.IsManagedRecord(endpoint.RecordTypeALIAS, c.ManagedRecordTypes, c.ExcludeRecordTypes) {
+		endpoints = c.AliasFlattener.Flatten(ctx, endpoints, records)
+	}
 	endpoints, err = c.Registry.AdjustEndpoints(endpoints)
 	if err != nil {
 		return fmt.Errorf("adjusting endpoints: %w", err)
 	}
 	registryFilter := c.Registry.GetDomainFilter()
 
+	policies := []plan.Policy{c.Policy}
+	limits := c.changeLimitPolicy(records, c.Registry.OwnerID())
+	if limits != nil {
+		policies = append(policies, limits)
+	}
+
+	zones := c.listZones(ctx)
+	if zones == nil && !c.AllowApexCNAME {
+		c.apexWarning.Do(func() {
+			log.Warn("The DNS zones are not known, CNAME records at the zone apex are not refused")
+		})
+	}
+	var ownershipRecordNames func(*endpoint.Endpoint) []string
+	if namer, ok := c.Registry.(registry.OwnershipRecordNamer); ok {
+		ownershipRecordNames = namer.OwnershipRecordNames
+	}
+
 	plan := &plan.Plan{
-		Policies:       []plan.Policy{c.Policy},
-		Current:        records,
-		Desired:        endpoints,
-		DomainFilter:   endpoint.MatchAllDomainFilters{&c.DomainFilter, &registryFilter},
-		ManagedRecords: c.ManagedRecordTypes,
-		ExcludeRecords: c.ExcludeRecordTypes,
-		OwnerID:        c.Registry.OwnerID(),
+		Policies:             policies,
+		Current:              records,
+		Desired:              endpoints,
+		DomainFilter:         endpoint.MatchAllDomainFilters{&c.DomainFilter, &registryFilter},
+		ManagedRecords:       c.ManagedRecordTypes,
+		ExcludeRecords:       c.ExcludeRecordTypes,
+		OwnerID:              c.Registry.OwnerID(),
+		ConflictResolver:     c.ConflictResolver,
+		DeletionGracePeriod:  c.DeletionGracePeriod,
+		ZoneNames:            zoneNames(zones),
+		AllowApexCNAME:       c.AllowApexCNAME,
+		OwnershipRecordNames: ownershipRecordNames,
+		Rejected:             rejected,
 	}
 
 	plan = plan.Calculate()
 
-	if plan.Changes.HasChanges() {
-		err = c.Registry.ApplyChanges(ctx, plan.Changes)
-		if err != nil {
-			registryErrorsTotal.Inc()
-			deprecatedRegistryErrors.Inc()
+	limitExceeded := ""
+	if limits != nil && limits.Exceeded != "" {
+		changeLimitExceededTotal.WithLabelValues(limits.Exceeded).Inc()
+		limitExceeded = limits.ExceededMessage
+	}
+
+	c.setLastPlan(plan)
+
+	if c.PlanOutput != "" {
+		if err := writePlanOutput(c.PlanOutput, plan.Changes); err != nil {
 			return err
 		}
-	} else {
+	}
+
+	var failed map[endpoint.EndpointKey]error
+	switch {
+	case plan.Changes.HasChanges():
+		failed, err = c.applyChanges(ctx, plan.Changes)
+	case limitExceeded != "":
+		log.Warnf("The synchronization was aborted, as the %s", limitExceeded)
+	default:
 		controllerNoChangesTotal.Inc()
 		log.Info("All records are already up to date")
 	}
+	c.emitEvents(plan.Decisions, failed, limitExceeded)
+	c.recordStatus(ctx, plan.Decisions, failed, zones)
+	if err != nil {
+		return err
+	}
 
 	lastSyncTimestamp.SetToCurrentTime()
 
@@ -308,6 +440,10 @@ func countAddressRecords(endpoints []*endpoint.Endpoint) (int, int) {
 func (c *Controller) ScheduleRunOnce(now time.Time) {
 	c.nextRunAtMux.Lock()
 	defer c.nextRunAtMux.Unlock()
+	// a retry after a failure is already planned, events must not shorten the backoff
+	if c.retry.retryAt.After(now) {
+		return
+	}
 	// schedule only if a reconciliation is not already planned
 	// to happen in the following c.MinEventSyncInterval
 	if !c.nextRunAt.Before(now.Add(c.MinEventSyncInterval)) {
@@ -318,29 +454,52 @@ func (c *Controller) ScheduleRunOnce(now time.Time) {
 func (c *Controller) ShouldRunOnce(now time.Time) bool {
 	c.nextRunAtMux.Lock()
 	defer c.nextRunAtMux.Unlock()
-	if now.Before(c.nextRunAt) {
+	if c.paused || now.Before(c.nextRunAt) {
 		return false
 	}
-	c.nextRunAt = now.Add(c.Interval)
+	c.startRun(now)
 	return true
 }
 
+// startRun plans the next regular run after the one starting now. It must be called with nextRunAtMux held.
+func (c *Controller) startRun(now time.Time) {
+	c.nextRunAt = now.Add(c.Interval)
+	if c.retry.breaker == circuitBreakerOpen {
+		log.Info("Circuit breaker half-open, attempting a trial synchronization")
+		c.retry.setBreaker(circuitBreakerHalfOpen)
+	}
+}
+
+// runOnceAndHandle runs RunOnce and plans the next run depending on its result, exiting on fatal errors.
+func (c *Controller) runOnceAndHandle(ctx context.Context) error {
+	err := c.RunOnce(ctx)
+	if !c.handleRunOnceResult(time.Now(), err) {
+		log.Fatalf("Failed to do run once: %v", err)
+	}
+	if err != nil {
+		log.Errorf("Failed to do run once: %v", err)
+	}
+	return err
+}
+
 // Run runs RunOnce in a loop with a delay until context is canceled
 func (c *Controller) Run(ctx context.Context) {
 	ticker := time.NewTicker(time.Second)
 	defer ticker.Stop()
+	triggers := c.triggerQueue()
+	c.startLoop()
+	defer c.stopLoop()
 	for {
 		if c.ShouldRunOnce(time.Now()) {
-			if err := c.RunOnce(ctx); err != nil {
-				if errors.Is(err, provider.SoftError) {
-					log.Errorf("Failed to do run once: %v", err)
-				} else {
-					log.Fatalf("Failed to do run once: %v", err)
-				}
-			}
+			c.runOnceAndHandle(ctx)
 		}
 		select {
 		case <-ticker.C:
+		case result := <-triggers:
+			c.nextRunAtMux.Lock()
+			c.startRun(time.Now())
+			c.nextRunAtMux.Unlock()
+			result <- c.runOnceAndHandle(ctx)
 		case <-ctx.Done():
 			log.Info("Terminating main controller loop")
 			return
@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"math"
+	"net"
 	"reflect"
 	"sort"
 	"testing"
@@ -430,6 +431,13 @@ func TestWhenMultipleControllerConsidersAllFilteredComain(t *testing.T) {
 						},
 					},
 				},
+				TargetDeltas: map[endpoint.EndpointKey]plan.TargetDelta{
+					{DNSName: "some-record.used.tld", RecordType: endpoint.RecordTypeA}: {
+						Added:   endpoint.Targets{"1.1.1.1"},
+						Removed: endpoint.Targets{"8.8.8.8"},
+						Kept:    endpoint.Targets{},
+					},
+				},
 			},
 		},
 	)
@@ -684,3 +692,42 @@ func TestAAAARecords(t *testing.T) {
 	assert.Equal(t, math.Float64bits(2), valueFromMetric(sourceAAAARecords))
 	assert.Equal(t, math.Float64bits(1), valueFromMetric(registryAAAARecords))
 }
+
+type staticResolver map[string]string
+
+func (r staticResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	if address, ok := r[host]; ok {
+		return []net.IPAddr{{IP: net.ParseIP(address)}}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestRunOnceFlattensAliases(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("used.tld", endpoint.RecordTypeALIAS, "lb.example.com"),
+	}, nil)
+
+	p := &filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("used.tld", endpoint.RecordTypeA, "192.0.2.1"),
+		},
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+		AliasFlattener:     provider.NewAliasFlattener(p, staticResolver{"lb.example.com": "192.0.2.2"}),
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	require.Len(t, p.ApplyChangesCalls, 1)
+	changes := p.ApplyChangesCalls[0]
+	require.Len(t, changes.UpdateNew, 1)
+	assert.Equal(t, endpoint.RecordTypeA, changes.UpdateNew[0].RecordType)
+	assert.Equal(t, endpoint.Targets{"192.0.2.2"}, changes.UpdateNew[0].Targets)
+}
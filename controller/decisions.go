@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/external-dns/plan"
+)
+
+var planDecisions = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "plan_decisions",
+		Help:      "Number of records per planner decision reason in the last reconcile loop.",
+	},
+	[]string{"reason"},
+)
+
+func init() {
+	prometheus.MustRegister(planDecisions)
+}
+
+// setLastPlan stores the last calculated plan and updates the decision metrics.
+func (c *Controller) setLastPlan(p *plan.Plan) {
+	counts := map[plan.DecisionReason]int{}
+	for _, d := range p.Decisions {
+		counts[d.Reason]++
+	}
+	planDecisions.Reset()
+	for reason, count := range counts {
+		planDecisions.WithLabelValues(string(reason)).Set(float64(count))
+	}
+
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+	c.lastPlan = p
+}
+
+// Decisions returns the decisions of the last plan, restricted to the given DNS name if not empty.
+func (c *Controller) Decisions(dnsName string) []plan.Decision {
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+
+	dnsName = strings.TrimSuffix(strings.ToLower(dnsName), ".")
+	decisions := []plan.Decision{}
+	if c.lastPlan == nil {
+		return decisions
+	}
+	for _, d := range c.lastPlan.Decisions {
+		if dnsName == "" || strings.TrimSuffix(strings.ToLower(d.DNSName), ".") == dnsName {
+			decisions = append(decisions, d)
+		}
+	}
+	return decisions
+}
+
+// DecisionsHandler serves the decisions of the last plan as JSON. The dnsName query parameter restricts the
+// decisions to a single DNS name.
+func (c *Controller) DecisionsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, c.Decisions(r.URL.Query().Get("dnsName")))
+	})
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func TestRunOnceDecisions(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "2.2.2.2"),
+		endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeTXT, "text"),
+	}, nil)
+	r, err := registry.NewNoopRegistry(&filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		},
+	})
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+	}
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(planDecisions.WithLabelValues(string(plan.DecisionCreated))))
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(planDecisions.WithLabelValues(string(plan.DecisionUnchanged))))
+	assert.Equal(t, math.Float64bits(1), valueFromMetric(planDecisions.WithLabelValues(string(plan.DecisionUnmanagedRecordType))))
+	assert.Len(t, ctrl.Decisions(""), 3)
+
+	rec := httptest.NewRecorder()
+	ctrl.DecisionsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/decisions?dnsName=B.example.org.", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var decisions []plan.Decision
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &decisions))
+	require.Len(t, decisions, 1)
+	assert.Equal(t, "b.example.org", decisions[0].DNSName)
+	assert.Equal(t, plan.DecisionCreated, decisions[0].Reason)
+}
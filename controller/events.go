@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+const (
+	// EventTypeNormal is the type of events reporting a record which was handled as expected
+	EventTypeNormal = "Normal"
+	// EventTypeWarning is the type of events reporting a record which could not be handled
+	EventTypeWarning = "Warning"
+)
+
+// Event reasons reported on the objects records originate from
+const (
+	EventReasonRecordCreated  = "RecordCreated"
+	EventReasonRecordUpdated  = "RecordUpdated"
+	EventReasonConflictLost   = "ConflictLost"
+	EventReasonCNAMEConflict  = "CNAMEConflict"
+	EventReasonOwnedByOther   = "OwnedByOther"
+	EventReasonRecordFiltered = "RecordFiltered"
+	EventReasonInvalidTarget  = "InvalidTarget"
+	EventReasonInvalidName    = "InvalidName"
+	EventReasonInvalidTTL     = "InvalidTTL"
+	EventReasonBadProperty    = "InvalidProviderSpecific"
+	EventReasonRecordSkipped  = "RecordSkipped"
+	EventReasonChangeLimit    = "ChangeLimitExceeded"
+	EventReasonProviderError  = "ProviderError"
+)
+
+// eventsQueueSize is the number of events waiting to be recorded, further events are dropped
+const eventsQueueSize = 1000
+
+// EventRecorder records events on the object a record originates from. The object is identified by the
+// resource label of the record, e.g. "service/default/nginx".
+type EventRecorder interface {
+	Event(resource, eventType, reason, message string)
+}
+
+// eventKey identifies a record of an object events have been emitted for
+type eventKey struct {
+	resource string
+	record   endpoint.EndpointKey
+}
+
+// event is an event to be recorded on an object
+type event struct {
+	key                                  eventKey
+	resource, eventType, reason, message string
+}
+
+// emitEvents records an event for every decision of the plan which concerns a record of a source object.
+// Events are only emitted when the outcome of a record changed since the previous synchronization, so that
+// an unchanged failure does not produce a new event on every interval. limitExceeded explains why the changes
+// skipped by the policies exceeded a change limit, if they did.
+//
+// The events are recorded in the background by a single worker, so that looking up the objects they are recorded
+// on does not delay the synchronization. Events which do not fit in its queue are dropped, and emitted again by
+// the next synchronization.
+func (c *Controller) emitEvents(decisions []plan.Decision, failed map[endpoint.EndpointKey]error, limitExceeded string) {
+	if c.EventRecorder == nil {
+		return
+	}
+
+	pending := c.pendingEvents(decisions, failed, limitExceeded)
+	if len(pending) == 0 {
+		return
+	}
+
+	c.eventsOnce.Do(func() {
+		c.eventsQueue = make(chan event, eventsQueueSize)
+		go c.recordEvents()
+	})
+	var dropped []event
+	for _, e := range pending {
+		c.eventsWG.Add(1)
+		select {
+		case c.eventsQueue <- e:
+		default:
+			c.eventsWG.Done()
+			dropped = append(dropped, e)
+		}
+	}
+	if len(dropped) > 0 {
+		log.Warnf("Dropped %d events, as %d events are already waiting to be recorded", len(dropped), eventsQueueSize)
+		c.forgetEvents(dropped)
+	}
+}
+
+// recordEvents records the queued events one after the other
+func (c *Controller) recordEvents() {
+	for e := range c.eventsQueue {
+		c.EventRecorder.Event(e.resource, e.eventType, e.reason, e.message)
+		c.eventsWG.Done()
+	}
+}
+
+// forgetEvents forgets that the events were emitted, so that the next synchronization emits them again
+func (c *Controller) forgetEvents(events []event) {
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+	for _, e := range events {
+		delete(c.events, e.key)
+	}
+}
+
+// pendingEvents returns the events of the decisions whose outcome changed since the previous synchronization
+func (c *Controller) pendingEvents(decisions []plan.Decision, failed map[endpoint.EndpointKey]error, limitExceeded string) []event {
+	c.statusMux.Lock()
+	defer c.statusMux.Unlock()
+
+	var pending []event
+	emitted := map[eventKey]string{}
+	for _, d := range decisions {
+		if d.Resource == "" {
+			continue
+		}
+		record := endpoint.EndpointKey{DNSName: d.DNSName, RecordType: d.RecordType, SetIdentifier: d.SetIdentifier}
+		eventType, reason, message := eventForDecision(d, failed[record], limitExceeded)
+		if reason == "" {
+			continue
+		}
+		key := eventKey{resource: d.Resource, record: record}
+		emitted[key] = reason + ": " + message
+		if c.events[key] == emitted[key] {
+			continue
+		}
+		pending = append(pending, event{key: key, resource: d.Resource, eventType: eventType, reason: reason, message: message})
+	}
+	c.events = emitted
+	return pending
+}
+
+// eventForDecision returns the event reporting the decision, or an empty reason if the decision is not
+// reported. err is the error the provider returned for the record, if any.
+func eventForDecision(d plan.Decision, err error, limitExceeded string) (eventType, reason, message string) {
+	record := d.DNSName + " " + d.RecordType
+	if d.SetIdentifier != "" {
+		record += " (set-identifier: " + d.SetIdentifier + ")"
+	}
+
+	switch d.Reason {
+	case plan.DecisionCreated, plan.DecisionUpdated:
+		if err != nil {
+			return EventTypeWarning, EventReasonProviderError, fmt.Sprintf("Failed to apply record %s: %v", record, err)
+		}
+		if d.Reason == plan.DecisionCreated {
+			return EventTypeNormal, EventReasonRecordCreated, fmt.Sprintf("Created record %s", record)
+		}
+		return EventTypeNormal, EventReasonRecordUpdated, fmt.Sprintf("Updated record %s: %s", record, d.Message)
+	case plan.DecisionConflictLost:
+		return EventTypeWarning, EventReasonConflictLost, fmt.Sprintf("Record %s not applied: %s", record, d.Message)
+	case plan.DecisionCNAMEConflict:
+		return EventTypeWarning, EventReasonCNAMEConflict, fmt.Sprintf("Record %s not applied: %s", record, d.Message)
+	case plan.DecisionOwnedByOther:
+		return EventTypeWarning, EventReasonOwnedByOther, fmt.Sprintf("Record %s not applied: %s", record, d.Message)
+	case plan.DecisionFilteredByDomain, plan.DecisionUnmanagedRecordType:
+		return EventTypeWarning, EventReasonRecordFiltered, fmt.Sprintf("Record %s filtered out: %s", record, d.Message)
+	case plan.DecisionInvalidTarget:
+		return EventTypeWarning, EventReasonInvalidTarget, fmt.Sprintf("Record %s rejected: %s", record, d.Message)
+	case plan.DecisionInvalidName:
+		return EventTypeWarning, EventReasonInvalidName, fmt.Sprintf("Record %s rejected: %s", record, d.Message)
+	case plan.DecisionInvalidTTL:
+		return EventTypeWarning, EventReasonInvalidTTL, fmt.Sprintf("Record %s rejected: %s", record, d.Message)
+	case plan.DecisionInvalidProperty:
+		return EventTypeWarning, EventReasonBadProperty, fmt.Sprintf("Record %s rejected: %s", record, d.Message)
+	case plan.DecisionSkippedByPolicy:
+		if limitExceeded != "" {
+			return EventTypeWarning, EventReasonChangeLimit, fmt.Sprintf("Record %s not applied: %s", record, limitExceeded)
+		}
+		return EventTypeNormal, EventReasonRecordSkipped, fmt.Sprintf("Record %s not applied: %s", record, d.Message)
+	}
+	return "", "", ""
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+type fakeEvent struct {
+	resource, eventType, reason string
+}
+
+type fakeEventRecorder struct {
+	events []fakeEvent
+}
+
+func (r *fakeEventRecorder) Event(resource, eventType, reason, message string) {
+	r.events = append(r.events, fakeEvent{resource: resource, eventType: eventType, reason: reason})
+}
+
+func newEventEndpoint(dnsName, recordType, resource string, targets ...string) *endpoint.Endpoint {
+	ep := endpoint.NewEndpoint(dnsName, recordType, targets...)
+	ep.Labels[endpoint.ResourceLabelKey] = resource
+	return ep
+}
+
+func TestRunOnceEmitsEvents(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		newEventEndpoint("a.example.org", endpoint.RecordTypeA, "service/default/a", "1.1.1.1"),
+		newEventEndpoint("a.example.com", endpoint.RecordTypeA, "service/default/b", "2.2.2.2"),
+		newEventEndpoint("a.example.net", endpoint.RecordTypeA, "service/default/c", "3.3.3.3"),
+		newEventEndpoint("b.example.org", endpoint.RecordTypeTXT, "ingress/default/d", "text"),
+		endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeA, "4.4.4.4"),
+	}, nil)
+
+	p := &zoneMockProvider{
+		zones:    provider.ZoneIDName{"z1": "example.org", "z2": "example.com"},
+		failZone: "example.com",
+		failErr:  provider.NewSoftError(errors.New("rate limited")),
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	recorder := &fakeEventRecorder{}
+	domainFilter := endpoint.NewDomainFilter([]string{"example.org", "example.com"})
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		DomainFilter:       domainFilter,
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		ZoneProvider:       p,
+		ZoneConcurrency:    1,
+		EventRecorder:      recorder,
+	}
+
+	require.Error(t, ctrl.RunOnce(context.Background()))
+	ctrl.eventsWG.Wait()
+	assert.ElementsMatch(t, []fakeEvent{
+		{resource: "service/default/a", eventType: EventTypeNormal, reason: EventReasonRecordCreated},
+		{resource: "service/default/b", eventType: EventTypeWarning, reason: EventReasonProviderError},
+		{resource: "service/default/c", eventType: EventTypeWarning, reason: EventReasonRecordFiltered},
+		{resource: "ingress/default/d", eventType: EventTypeWarning, reason: EventReasonRecordFiltered},
+	}, recorder.events)
+
+	// the same outcome does not emit the events again
+	recorder.events = nil
+	require.Error(t, ctrl.RunOnce(context.Background()))
+	ctrl.eventsWG.Wait()
+	assert.Empty(t, recorder.events)
+}
+
+type blockingEventRecorder struct {
+	release chan struct{}
+}
+
+func (r *blockingEventRecorder) Event(_, _, _, _ string) {
+	<-r.release
+}
+
+func TestRunOnceDoesNotWaitForEvents(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		newEventEndpoint("a.example.org", endpoint.RecordTypeA, "service/default/a", "1.1.1.1"),
+	}, nil)
+	r, err := registry.NewNoopRegistry(&filteredMockProvider{})
+	require.NoError(t, err)
+
+	recorder := &blockingEventRecorder{release: make(chan struct{})}
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		EventRecorder:      recorder,
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	close(recorder.release)
+	ctrl.eventsWG.Wait()
+}
+
+// countingEventRecorder counts the events it records once released
+type countingEventRecorder struct {
+	release chan struct{}
+	mux     sync.Mutex
+	count   int
+}
+
+func (r *countingEventRecorder) Event(_, _, _, _ string) {
+	<-r.release
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.count++
+}
+
+func TestEmitEventsDropsEventsWhenQueueIsFull(t *testing.T) {
+	var decisions []plan.Decision
+	for i := 0; i < eventsQueueSize+10; i++ {
+		decisions = append(decisions, plan.Decision{
+			DNSName:    fmt.Sprintf("a%d.example.org", i),
+			RecordType: endpoint.RecordTypeA,
+			Resource:   fmt.Sprintf("service/default/a%d", i),
+			Reason:     plan.DecisionCreated,
+		})
+	}
+	recorder := &countingEventRecorder{release: make(chan struct{})}
+	ctrl := &Controller{EventRecorder: recorder}
+
+	ctrl.emitEvents(decisions, nil, "")
+	// the worker may already hold one event besides the full queue, the dropped events are forgotten
+	kept := len(ctrl.events)
+	assert.GreaterOrEqual(t, kept, eventsQueueSize)
+	assert.LessOrEqual(t, kept, eventsQueueSize+1)
+
+	close(recorder.release)
+	ctrl.eventsWG.Wait()
+	assert.Equal(t, kept, recorder.count)
+
+	// the next synchronization emits the dropped events again
+	ctrl.emitEvents(decisions, nil, "")
+	ctrl.eventsWG.Wait()
+	assert.Equal(t, len(decisions), recorder.count)
+	assert.Len(t, ctrl.events, len(decisions))
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+var changeLimitExceededTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "change_limit_exceeded_total",
+		Help:      "Number of reconcile loops whose planned changes exceeded a change limit.",
+	},
+	[]string{"limit"},
+)
+
+func init() {
+	prometheus.MustRegister(changeLimitExceededTotal)
+}
+
+// changeLimitPolicy returns the policy enforcing the configured change limits, or nil if there are none.
+func (c *Controller) changeLimitPolicy(records []*endpoint.Endpoint, ownerID string) *plan.ChangeLimitPolicy {
+	if c.MaxDeletes <= 0 && c.MaxDeletesPercent <= 0 && c.MaxChanges <= 0 {
+		return nil
+	}
+	owned := 0
+	for _, r := range records {
+		if (ownerID == "" || r.IsOwnedBy(ownerID)) && plan.IsManagedRecord(r.RecordType, c.ManagedRecordTypes, c.ExcludeRecordTypes) {
+			owned++
+		}
+	}
+	return &plan.ChangeLimitPolicy{
+		MaxDeletes:        c.MaxDeletes,
+		MaxDeletesPercent: c.MaxDeletesPercent,
+		OwnedRecords:      owned,
+		MaxChanges:        c.MaxChanges,
+		Truncate:          c.TruncateChanges,
+	}
+}
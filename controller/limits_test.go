@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func TestRunOnceChangeLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name            string
+		maxDeletes      int
+		truncate        bool
+		expectedDeletes []int
+		expectedAborted bool
+	}{
+		{
+			name:            "no limit",
+			expectedDeletes: []int{4},
+		},
+		{
+			name:            "abort",
+			maxDeletes:      2,
+			expectedAborted: true,
+		},
+		{
+			name:            "truncate",
+			maxDeletes:      2,
+			truncate:        true,
+			expectedDeletes: []int{2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			source := new(testutils.MockSource)
+			source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+
+			p := &filteredMockProvider{
+				RecordsStore: []*endpoint.Endpoint{
+					endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+					endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+					endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+					endpoint.NewEndpoint("d.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+				},
+			}
+			r, err := registry.NewNoopRegistry(p)
+			require.NoError(t, err)
+
+			ctrl := &Controller{
+				Source:             source,
+				Registry:           r,
+				Policy:             &plan.SyncPolicy{},
+				ManagedRecordTypes: []string{endpoint.RecordTypeA},
+				MaxDeletes:         tc.maxDeletes,
+				TruncateChanges:    tc.truncate,
+			}
+
+			hook := logtest.NewGlobal()
+			defer log.StandardLogger().ReplaceHooks(log.LevelHooks{})
+			noChanges := testutil.ToFloat64(controllerNoChangesTotal)
+
+			require.NoError(t, ctrl.RunOnce(context.Background()))
+			var deletes []int
+			for _, changes := range p.ApplyChangesCalls {
+				deletes = append(deletes, len(changes.Delete))
+			}
+			assert.Equal(t, tc.expectedDeletes, deletes)
+
+			// an aborted synchronization is not reported as up to date
+			assert.Equal(t, noChanges, testutil.ToFloat64(controllerNoChangesTotal))
+			aborted := false
+			for _, entry := range hook.AllEntries() {
+				assert.NotEqual(t, "All records are already up to date", entry.Message)
+				if entry.Level == log.WarnLevel && entry.Message == "The synchronization was aborted, as the planned changes exceed the max-deletes limit (4 > 2)" {
+					aborted = true
+				}
+			}
+			assert.Equal(t, tc.expectedAborted, aborted)
+		})
+	}
+}
+
+func TestRunOnceChangeLimitEmitsEvents(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+
+	p := &filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{
+			newEventEndpoint("a.example.org", endpoint.RecordTypeA, "service/default/a", "1.1.1.1"),
+			newEventEndpoint("b.example.org", endpoint.RecordTypeA, "service/default/b", "1.1.1.1"),
+		},
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	recorder := &fakeEventRecorder{}
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		MaxDeletes:         1,
+		EventRecorder:      recorder,
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	ctrl.eventsWG.Wait()
+	assert.ElementsMatch(t, []fakeEvent{
+		{resource: "service/default/a", eventType: EventTypeWarning, reason: EventReasonChangeLimit},
+		{resource: "service/default/b", eventType: EventTypeWarning, reason: EventReasonChangeLimit},
+	}, recorder.events)
+}
+
+func TestChangeLimitPolicyCountsOwnedRecords(t *testing.T) {
+	ctrl := &Controller{
+		ManagedRecordTypes: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		MaxDeletesPercent:  10,
+	}
+	records := []*endpoint.Endpoint{
+		{DNSName: "a.example.org", RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "owner"}},
+		{DNSName: "b.example.org", RecordType: endpoint.RecordTypeCNAME, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "owner"}},
+		{DNSName: "c.example.org", RecordType: endpoint.RecordTypeA, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "other"}},
+		{DNSName: "d.example.org", RecordType: endpoint.RecordTypeMX, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "owner"}},
+	}
+
+	limits := ctrl.changeLimitPolicy(records, "owner")
+	require.NotNil(t, limits)
+	assert.Equal(t, 2, limits.OwnedRecords)
+
+	assert.Nil(t, (&Controller{}).changeLimitPolicy(records, "owner"))
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// writePlanOutput writes the diff of the planned changes to the given path. The format is chosen by the
+// extension of the path: JSON for .json, YAML for .yaml or .yml and a human-readable diff otherwise.
+func writePlanOutput(path string, changes *plan.Changes) error {
+	data, err := changes.Diff().Encode(plan.DiffFormatForPath(path))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing plan output: %w", err)
+	}
+	log.Debugf("Wrote planned changes to %s", path)
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func TestRunOncePlanOutput(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "2.2.2.2"),
+		endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeA, "3.3.3.3"),
+	}, nil)
+
+	p := &filteredMockProvider{
+		RecordsStore: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+			endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		},
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "plan.json")
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		PlanOutput:         path,
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	diff := &plan.Diff{}
+	require.NoError(t, json.Unmarshal(data, diff))
+	assert.Equal(t, plan.DiffSummary{Create: 1, Update: 1, Delete: 1}, diff.Summary)
+	require.Len(t, diff.Records, 3)
+	assert.Equal(t, plan.DiffActionUpdate, diff.Records[0].Action)
+	assert.Equal(t, []plan.DiffReason{plan.DiffReasonTargets}, diff.Records[0].Reasons)
+}
+
+func TestRunOncePlanOutputError(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{}, nil)
+	r, err := registry.NewNoopRegistry(&filteredMockProvider{})
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:     source,
+		Registry:   r,
+		Policy:     &plan.SyncPolicy{},
+		PlanOutput: filepath.Join(t.TempDir(), "missing", "plan.txt"),
+	}
+
+	assert.Error(t, ctrl.RunOnce(context.Background()))
+}
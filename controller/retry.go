@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"math/rand"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/provider"
+)
+
+type circuitBreaker int
+
+const (
+	circuitBreakerClosed circuitBreaker = iota
+	circuitBreakerOpen
+	circuitBreakerHalfOpen
+)
+
+func (b circuitBreaker) String() string {
+	switch b {
+	case circuitBreakerOpen:
+		return "open"
+	case circuitBreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// retryState keeps track of consecutive failures of the reconciliation loop.
+type retryState struct {
+	// failures is the number of consecutive failed runs, soft or not
+	failures int
+	// hardFailures is the number of consecutive runs which failed with a non-soft error
+	hardFailures int
+	// breaker is the current state of the circuit breaker
+	breaker circuitBreaker
+	// retryAt is the time of the next planned retry, zero if the last run succeeded
+	retryAt time.Time
+	// lastError is the error of the last run, nil if it succeeded
+	lastError error
+}
+
+func (r *retryState) setBreaker(state circuitBreaker) {
+	r.breaker = state
+	circuitBreakerState.Set(float64(state))
+}
+
+// handleRunOnceResult updates the retry state with the result of a run and plans the next
+// one accordingly. It returns false if the error is fatal and the controller must stop.
+func (c *Controller) handleRunOnceResult(now time.Time, err error) bool {
+	c.nextRunAtMux.Lock()
+	defer c.nextRunAtMux.Unlock()
+
+	if err == nil {
+		if c.retry.breaker != circuitBreakerClosed {
+			log.Info("Circuit breaker closed")
+		}
+		c.retry = retryState{}
+		c.retry.setBreaker(circuitBreakerClosed)
+		consecutiveFailures.Set(0)
+		nextRetryTimestamp.Set(0)
+		return true
+	}
+
+	c.retry.lastError = err
+	soft := errors.Is(err, provider.SoftError)
+	if !soft && c.CircuitBreakerThreshold <= 0 {
+		return false
+	}
+
+	c.retry.failures++
+	consecutiveFailures.Set(float64(c.retry.failures))
+	if !soft {
+		c.retry.hardFailures++
+	}
+
+	if c.BackoffInitialInterval > 0 {
+		c.nextRunAt = now.Add(c.backoff())
+		c.retry.retryAt = c.nextRunAt
+	}
+
+	if !soft && (c.retry.breaker == circuitBreakerHalfOpen || c.retry.hardFailures >= c.CircuitBreakerThreshold) {
+		c.retry.setBreaker(circuitBreakerOpen)
+		c.nextRunAt = now.Add(c.CircuitBreakerCooldown)
+		c.retry.retryAt = c.nextRunAt
+		log.Errorf("Circuit breaker open after %d consecutive failures, next attempt at %s", c.retry.hardFailures, c.nextRunAt.Format(time.RFC3339))
+	} else if c.retry.breaker == circuitBreakerHalfOpen {
+		// the trial run only failed softly, keep retrying without opening the breaker again
+		c.retry.setBreaker(circuitBreakerClosed)
+	}
+
+	nextRetryTimestamp.Set(float64(c.nextRunAt.Unix()))
+	return true
+}
+
+// backoff returns the delay before the next retry: BackoffInitialInterval doubled for every
+// consecutive failure, capped at BackoffMaxInterval, with a random jitter of up to half of it.
+func (c *Controller) backoff() time.Duration {
+	delay := c.BackoffInitialInterval
+	for i := 1; i < c.retry.failures; i++ {
+		if c.BackoffMaxInterval > 0 && delay >= c.BackoffMaxInterval {
+			break
+		}
+		// stop doubling before the duration overflows
+		if delay > time.Duration(1<<62) {
+			break
+		}
+		delay *= 2
+	}
+	if c.BackoffMaxInterval > 0 && delay > c.BackoffMaxInterval {
+		delay = c.BackoffMaxInterval
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
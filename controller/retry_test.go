@@ -0,0 +1,117 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/provider"
+)
+
+func TestHandleRunOnceResultWithoutCircuitBreaker(t *testing.T) {
+	ctrl := &Controller{Interval: time.Minute}
+	now := time.Now()
+	assert.True(t, ctrl.ShouldRunOnce(now))
+
+	// soft errors are retried on the regular interval
+	assert.True(t, ctrl.handleRunOnceResult(now, provider.NewSoftError(errors.New("throttled"))))
+	assert.False(t, ctrl.ShouldRunOnce(now.Add(time.Minute-time.Second)))
+	assert.True(t, ctrl.ShouldRunOnce(now.Add(time.Minute)))
+
+	// any other error is fatal
+	assert.False(t, ctrl.handleRunOnceResult(now, errors.New("broken")))
+}
+
+func TestHandleRunOnceResultBackoff(t *testing.T) {
+	ctrl := &Controller{
+		Interval:               time.Hour,
+		BackoffInitialInterval: 10 * time.Second,
+		BackoffMaxInterval:     time.Minute,
+	}
+	now := time.Now()
+	softErr := provider.NewSoftError(errors.New("throttled"))
+
+	for _, maxDelay := range []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute} {
+		assert.True(t, ctrl.ShouldRunOnce(now))
+		assert.True(t, ctrl.handleRunOnceResult(now, softErr))
+
+		delay := ctrl.retry.retryAt.Sub(now)
+		assert.GreaterOrEqual(t, delay, maxDelay/2)
+		assert.LessOrEqual(t, delay, maxDelay)
+
+		// events do not shorten the backoff
+		ctrl.ScheduleRunOnce(now)
+		assert.False(t, ctrl.ShouldRunOnce(now.Add(maxDelay/2-time.Second)))
+		now = now.Add(maxDelay)
+	}
+	assert.Equal(t, math.Float64bits(5), valueFromMetric(consecutiveFailures))
+
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.handleRunOnceResult(now, nil))
+	assert.Equal(t, 0, ctrl.retry.failures)
+	assert.True(t, ctrl.retry.retryAt.IsZero())
+	assert.Equal(t, math.Float64bits(0), valueFromMetric(nextRetryTimestamp))
+	assert.False(t, ctrl.ShouldRunOnce(now.Add(time.Hour-time.Second)))
+}
+
+func TestHandleRunOnceResultCircuitBreaker(t *testing.T) {
+	ctrl := &Controller{
+		Interval:                time.Minute,
+		CircuitBreakerThreshold: 2,
+		CircuitBreakerCooldown:  10 * time.Minute,
+	}
+	now := time.Now()
+	hardErr := errors.New("invalid credentials")
+
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.handleRunOnceResult(now, hardErr))
+	assert.Equal(t, circuitBreakerClosed, ctrl.retry.breaker)
+
+	now = now.Add(time.Minute)
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.handleRunOnceResult(now, hardErr))
+	assert.Equal(t, circuitBreakerOpen, ctrl.retry.breaker)
+	assert.Equal(t, math.Float64bits(float64(circuitBreakerOpen)), valueFromMetric(circuitBreakerState))
+
+	// the breaker stays open until the cooldown expired
+	assert.False(t, ctrl.ShouldRunOnce(now.Add(10*time.Minute-time.Second)))
+	now = now.Add(10 * time.Minute)
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.Equal(t, circuitBreakerHalfOpen, ctrl.retry.breaker)
+
+	// a failed trial opens the breaker again
+	assert.True(t, ctrl.handleRunOnceResult(now, hardErr))
+	assert.Equal(t, circuitBreakerOpen, ctrl.retry.breaker)
+
+	now = now.Add(10 * time.Minute)
+	assert.True(t, ctrl.ShouldRunOnce(now))
+	assert.True(t, ctrl.handleRunOnceResult(now, nil))
+	assert.Equal(t, circuitBreakerClosed, ctrl.retry.breaker)
+	assert.Equal(t, 0, ctrl.retry.hardFailures)
+}
+
+func TestBackoffDoesNotOverflow(t *testing.T) {
+	ctrl := &Controller{BackoffInitialInterval: time.Second}
+	ctrl.retry.failures = 100
+
+	assert.Greater(t, ctrl.backoff(), time.Duration(0))
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// statusReasonUpToDate is the reason of a programmed record which did not need any change
+const statusReasonUpToDate = "RecordUpToDate"
+
+// StatusRecorder records the state of the records of the source objects it manages, e.g. in their status.
+// statuses are keyed by the resource label of the records.
+type StatusRecorder interface {
+	RecordStatus(ctx context.Context, statuses map[string][]endpoint.EndpointStatus) error
+}
+
+// recordStatus reports the state of every planned record to the StatusRecorders, once the changes are applied.
+// failed are the records the provider failed to apply, zones are used to report the zone of every record.
+func (c *Controller) recordStatus(ctx context.Context, decisions []plan.Decision, failed map[endpoint.EndpointKey]error, zones provider.ZoneIDName) {
+	if len(c.StatusRecorders) == 0 {
+		return
+	}
+
+	now := metav1.NewTime(time.Now())
+	statuses := map[string][]endpoint.EndpointStatus{}
+	for _, d := range decisions {
+		if d.Resource == "" {
+			continue
+		}
+		record := endpoint.EndpointKey{DNSName: d.DNSName, RecordType: d.RecordType, SetIdentifier: d.SetIdentifier}
+		status, ok := endpointStatus(d, failed[record], now)
+		if !ok {
+			continue
+		}
+		if status.Owner == "" && status.Condition != endpoint.EndpointConflicted {
+			status.Owner = c.Registry.OwnerID()
+		}
+		if zones != nil {
+			_, status.Zone = zones.FindZone(d.DNSName)
+		}
+		statuses[d.Resource] = append(statuses[d.Resource], status)
+	}
+
+	for _, recorder := range c.StatusRecorders {
+		if err := recorder.RecordStatus(ctx, statuses); err != nil {
+			log.Warnf("Could not record the status of the records: %v", err)
+		}
+	}
+}
+
+// endpointStatus returns the state of the record the decision is about, or false if the decision is not about a
+// desired record. err is the error the provider returned for the record, if any.
+func endpointStatus(d plan.Decision, err error, now metav1.Time) (endpoint.EndpointStatus, bool) {
+	status := endpoint.EndpointStatus{
+		DNSName:       d.DNSName,
+		RecordType:    d.RecordType,
+		SetIdentifier: d.SetIdentifier,
+		Owner:         d.Owner,
+		Message:       d.Message,
+	}
+
+	switch d.Reason {
+	case plan.DecisionCreated, plan.DecisionUpdated:
+		if err != nil {
+			status.Condition = endpoint.EndpointPending
+			status.Reason = EventReasonProviderError
+			status.Message = "the DNS provider failed to apply the record"
+			status.LastError = err.Error()
+			break
+		}
+		status.Condition = endpoint.EndpointProgrammed
+		status.Reason = EventReasonRecordCreated
+		if d.Reason == plan.DecisionUpdated {
+			status.Reason = EventReasonRecordUpdated
+		}
+		status.LastAppliedTime = &now
+	case plan.DecisionUnchanged:
+		status.Condition = endpoint.EndpointProgrammed
+		status.Reason = statusReasonUpToDate
+	case plan.DecisionSkippedByPolicy:
+		status.Condition = endpoint.EndpointPending
+		status.Reason = EventReasonRecordSkipped
+	case plan.DecisionConflictLost:
+		status.Condition = endpoint.EndpointConflicted
+		status.Reason = EventReasonConflictLost
+	case plan.DecisionCNAMEConflict:
+		status.Condition = endpoint.EndpointConflicted
+		status.Reason = EventReasonCNAMEConflict
+	case plan.DecisionOwnedByOther:
+		status.Condition = endpoint.EndpointConflicted
+		status.Reason = EventReasonOwnedByOther
+	case plan.DecisionFilteredByDomain, plan.DecisionUnmanagedRecordType:
+		status.Condition = endpoint.EndpointRejected
+		status.Reason = EventReasonRecordFiltered
+	case plan.DecisionInvalidTarget:
+		status.Condition = endpoint.EndpointRejected
+		status.Reason = EventReasonInvalidTarget
+	case plan.DecisionInvalidName:
+		status.Condition = endpoint.EndpointRejected
+		status.Reason = EventReasonInvalidName
+	case plan.DecisionInvalidTTL:
+		status.Condition = endpoint.EndpointRejected
+		status.Reason = EventReasonInvalidTTL
+	case plan.DecisionInvalidProperty:
+		status.Condition = endpoint.EndpointRejected
+		status.Reason = EventReasonBadProperty
+	default:
+		return endpoint.EndpointStatus{}, false
+	}
+	return status, true
+}
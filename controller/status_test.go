@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+type fakeStatusRecorder struct {
+	statuses map[string][]endpoint.EndpointStatus
+}
+
+func (r *fakeStatusRecorder) RecordStatus(ctx context.Context, statuses map[string][]endpoint.EndpointStatus) error {
+	r.statuses = statuses
+	return nil
+}
+
+func TestRunOnceRecordsStatus(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		newEventEndpoint("a.example.org", endpoint.RecordTypeA, "crd/default/records", "1.1.1.1"),
+		newEventEndpoint("a.example.com", endpoint.RecordTypeA, "crd/default/records", "2.2.2.2"),
+		newEventEndpoint("b.example.org", endpoint.RecordTypeTXT, "crd/default/records", "text"),
+		endpoint.NewEndpoint("c.example.org", endpoint.RecordTypeA, "4.4.4.4"),
+	}, nil)
+
+	p := &zoneMockProvider{
+		zones:    provider.ZoneIDName{"z1": "example.org", "z2": "example.com"},
+		failZone: "example.com",
+		failErr:  errors.New("zone is broken"),
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	recorder := &fakeStatusRecorder{}
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		ZoneProvider:       p,
+		ZoneConcurrency:    1,
+		StatusRecorders:    []StatusRecorder{recorder},
+	}
+
+	require.Error(t, ctrl.RunOnce(context.Background()))
+	require.Len(t, recorder.statuses, 1)
+	statuses := map[string]endpoint.EndpointStatus{}
+	for _, s := range recorder.statuses["crd/default/records"] {
+		statuses[s.DNSName] = s
+	}
+	require.Len(t, statuses, 3)
+
+	assert.Equal(t, endpoint.EndpointProgrammed, statuses["a.example.org"].Condition)
+	assert.Equal(t, "example.org", statuses["a.example.org"].Zone)
+	assert.NotNil(t, statuses["a.example.org"].LastAppliedTime)
+
+	assert.Equal(t, endpoint.EndpointPending, statuses["a.example.com"].Condition)
+	assert.Equal(t, EventReasonProviderError, statuses["a.example.com"].Reason)
+	assert.Equal(t, "zone is broken", statuses["a.example.com"].LastError)
+	assert.Nil(t, statuses["a.example.com"].LastAppliedTime)
+
+	assert.Equal(t, endpoint.EndpointRejected, statuses["b.example.org"].Condition)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+var rejectedEndpointsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "rejected_endpoints_total",
+		Help:      "Number of endpoints from the sources which were rejected as invalid, by reason.",
+	},
+	[]string{"reason"},
+)
+
+func init() {
+	prometheus.MustRegister(rejectedEndpointsTotal)
+}
+
+// validateEndpoints returns the endpoints of the sources which pass endpoint.Validate and the errors of the others,
+// which are counted and logged. Nil endpoints are dropped.
+func validateEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, []*endpoint.ValidationError) {
+	valid := make([]*endpoint.Endpoint, 0, len(endpoints))
+	var rejected []*endpoint.ValidationError
+	for _, ep := range endpoints {
+		if ep == nil {
+			continue
+		}
+		var validationErr *endpoint.ValidationError
+		if err := endpoint.Validate(ep); errors.As(err, &validationErr) {
+			log.WithFields(log.Fields{
+				"record":   ep.DNSName,
+				"type":     ep.RecordType,
+				"resource": ep.Labels[endpoint.ResourceLabelKey],
+				"reason":   validationErr.Reason,
+			}).Warnf("Rejecting invalid endpoint: %v", validationErr.Err)
+			rejectedEndpointsTotal.WithLabelValues(string(validationErr.Reason)).Inc()
+			rejected = append(rejected, validationErr)
+			continue
+		}
+		valid = append(valid, ep)
+	}
+	return valid, rejected
+}
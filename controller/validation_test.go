@@ -0,0 +1,76 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+func TestValidateEndpoints(t *testing.T) {
+	valid := endpoint.NewEndpoint("valid.tld", endpoint.RecordTypeA, "1.2.3.4")
+	invalid := endpoint.NewEndpointWithTTL("invalid.tld", endpoint.RecordTypeA, -1, "1.2.3.4")
+
+	endpoints, rejected := validateEndpoints([]*endpoint.Endpoint{valid, nil, invalid})
+	assert.Equal(t, []*endpoint.Endpoint{valid}, endpoints)
+	require.Len(t, rejected, 1)
+	assert.Same(t, invalid, rejected[0].Endpoint)
+	assert.Equal(t, endpoint.ValidationReasonInvalidTTL, rejected[0].Reason)
+}
+
+func TestRunOnceRejectsInvalidEndpoints(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("valid.tld", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpoint("address.tld", endpoint.RecordTypeA, "lb.example.com"),
+		endpoint.NewEndpoint("weight.tld", endpoint.RecordTypeA, "1.2.3.4").WithProviderSpecific("aws/weight", "heavy"),
+	}, nil)
+
+	p := &filteredMockProvider{}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	require.Len(t, p.ApplyChangesCalls, 1)
+	require.Len(t, p.ApplyChangesCalls[0].Create, 1)
+	assert.Equal(t, "valid.tld", p.ApplyChangesCalls[0].Create[0].DNSName)
+
+	var decisions []string
+	for _, d := range ctrl.Decisions("") {
+		decisions = append(decisions, d.String())
+	}
+	assert.ElementsMatch(t, []string{
+		"valid.tld A: created",
+		`address.tld A: invalid-target, target "lb.example.com" is not an IPv4 address`,
+		`weight.tld A: invalid-provider-specific, provider specific property aws/weight: "heavy" is not a number between 0 and 255`,
+	}, decisions)
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+var (
+	zoneErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "zone_errors_total",
+			Help:      "Number of errors while applying changes to a DNS zone.",
+		},
+		[]string{"zone"},
+	)
+	zoneLastSyncTimestamp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "zone_last_sync_timestamp_seconds",
+			Help:      "Timestamp of last successful sync of a DNS zone with the DNS provider",
+		},
+		[]string{"zone"},
+	)
+	zoneChanges = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "external_dns",
+			Subsystem: "controller",
+			Name:      "zone_changes",
+			Help:      "Number of changes applied to a DNS zone during the last reconcile loop.",
+		},
+		[]string{"zone"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(zoneErrorsTotal)
+	prometheus.MustRegister(zoneLastSyncTimestamp)
+	prometheus.MustRegister(zoneChanges)
+}
+
+// listZones returns the zones of the ZoneProvider, or nil if there is none or listing the zones failed
+func (c *Controller) listZones(ctx context.Context) provider.ZoneIDName {
+	if c.ZoneProvider == nil {
+		return nil
+	}
+	zones, err := c.ZoneProvider.ZoneIDNames(ctx)
+	if err != nil {
+		log.Debugf("Could not list zones: %v", err)
+		return nil
+	}
+	return zones
+}
+
+// zoneNames returns the names of the zones, sorted
+func zoneNames(zones provider.ZoneIDName) []string {
+	names := make([]string, 0, len(zones))
+	for _, name := range zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyChanges hands the changes over to the registry. When a ZoneProvider is configured
+// the changes are split by zone and every zone is applied on its own, so that a failure
+// in one zone does not prevent the others from being updated. It returns the error of every
+// created or updated record which could not be applied.
+func (c *Controller) applyChanges(ctx context.Context, changes *plan.Changes) (map[endpoint.EndpointKey]error, error) {
+	if c.ZoneProvider == nil || c.ZoneConcurrency <= 0 {
+		if err := c.Registry.ApplyChanges(ctx, changes); err != nil {
+			registryErrorsTotal.Inc()
+			deprecatedRegistryErrors.Inc()
+			return failedRecords(changes, err, nil), err
+		}
+		return nil, nil
+	}
+
+	zones, err := c.ZoneProvider.ZoneIDNames(ctx)
+	if err != nil {
+		registryErrorsTotal.Inc()
+		deprecatedRegistryErrors.Inc()
+		err = fmt.Errorf("listing zones: %w", err)
+		return failedRecords(changes, err, nil), err
+	}
+
+	return c.applyChangesByZone(ctx, splitChangesByZone(zones, changes))
+}
+
+// failedRecords adds the created and updated records of the changes to failed with the given error.
+func failedRecords(changes *plan.Changes, err error, failed map[endpoint.EndpointKey]error) map[endpoint.EndpointKey]error {
+	if failed == nil {
+		failed = map[endpoint.EndpointKey]error{}
+	}
+	for _, list := range [][]*endpoint.Endpoint{changes.Create, changes.UpdateNew} {
+		for _, ep := range list {
+			failed[ep.Key()] = err
+		}
+	}
+	return failed
+}
+
+// applyChangesByZone applies the changes of every zone using at most ZoneConcurrency workers.
+// All zones are attempted, errors are collected and returned once every zone has been processed.
+func (c *Controller) applyChangesByZone(ctx context.Context, changesByZone map[string]*plan.Changes) (map[endpoint.EndpointKey]error, error) {
+	zoneNames := make([]string, 0, len(changesByZone))
+	for zoneName := range changesByZone {
+		zoneNames = append(zoneNames, zoneName)
+	}
+	sort.Strings(zoneNames)
+
+	queue := make(chan string)
+	errs := make(map[string]error)
+	var errsMux sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < min(c.ZoneConcurrency, len(zoneNames)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for zoneName := range queue {
+				changes := changesByZone[zoneName]
+				zoneChanges.WithLabelValues(zoneName).Set(float64(countChanges(changes)))
+				if err := c.Registry.ApplyChanges(ctx, changes); err != nil {
+					registryErrorsTotal.Inc()
+					deprecatedRegistryErrors.Inc()
+					zoneErrorsTotal.WithLabelValues(zoneName).Inc()
+					c.setZoneStatus(zoneName, time.Now(), err)
+					errsMux.Lock()
+					errs[zoneName] = err
+					errsMux.Unlock()
+					continue
+				}
+				zoneLastSyncTimestamp.WithLabelValues(zoneName).SetToCurrentTime()
+				c.setZoneStatus(zoneName, time.Now(), nil)
+			}
+		}()
+	}
+
+	for _, zoneName := range zoneNames {
+		queue <- zoneName
+	}
+	close(queue)
+	wg.Wait()
+
+	var hardErrs, softErrs []error
+	var failed map[endpoint.EndpointKey]error
+	for _, zoneName := range zoneNames {
+		err, ok := errs[zoneName]
+		if !ok {
+			continue
+		}
+		failed = failedRecords(changesByZone[zoneName], err, failed)
+		err = fmt.Errorf("zone %q: %w", zoneName, err)
+		if errors.Is(err, provider.SoftError) {
+			softErrs = append(softErrs, err)
+		} else {
+			hardErrs = append(hardErrs, err)
+		}
+	}
+
+	// a single hard error must not be masked by soft errors of other zones
+	if len(hardErrs) > 0 {
+		for _, err := range softErrs {
+			log.Error(err)
+		}
+		return failed, errors.Join(hardErrs...)
+	}
+	return failed, errors.Join(softErrs...)
+}
+
+// splitChangesByZone groups the changes by the zone the records belong to. Records which
+// cannot be matched to any zone are grouped under the empty zone name and passed on as is.
+// UpdateOld and UpdateNew are split pairwise, so that their order stays aligned.
+func splitChangesByZone(zones provider.ZoneIDName, changes *plan.Changes) map[string]*plan.Changes {
+	// zone names may or may not be returned with a trailing dot depending on the provider
+	zoneNames := provider.ZoneIDName{}
+	for zoneID, zoneName := range zones {
+		zoneNames.Add(zoneID, strings.TrimSuffix(zoneName, "."))
+	}
+
+	changesByZone := map[string]*plan.Changes{}
+	changesFor := func(ep *endpoint.Endpoint) *plan.Changes {
+		_, zoneName := zoneNames.FindZone(strings.TrimSuffix(ep.DNSName, "."))
+		if zoneName == "" {
+			log.Debugf("No zone found for record %s", ep.DNSName)
+		}
+		if _, ok := changesByZone[zoneName]; !ok {
+			changesByZone[zoneName] = &plan.Changes{TargetDeltas: changes.TargetDeltas}
+		}
+		return changesByZone[zoneName]
+	}
+
+	for _, ep := range changes.Create {
+		zc := changesFor(ep)
+		zc.Create = append(zc.Create, ep)
+	}
+	for i, ep := range changes.UpdateNew {
+		zc := changesFor(ep)
+		zc.UpdateNew = append(zc.UpdateNew, ep)
+		if i < len(changes.UpdateOld) {
+			zc.UpdateOld = append(zc.UpdateOld, changes.UpdateOld[i])
+		}
+	}
+	for _, ep := range changes.Delete {
+		zc := changesFor(ep)
+		zc.Delete = append(zc.Delete, ep)
+	}
+
+	return changesByZone
+}
+
+func countChanges(changes *plan.Changes) int {
+	return len(changes.Create) + len(changes.UpdateNew) + len(changes.Delete)
+}
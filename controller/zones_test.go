@@ -0,0 +1,249 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/internal/testutils"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/registry"
+)
+
+// zoneMockProvider records the changes applied per call and fails for records in failZone.
+type zoneMockProvider struct {
+	provider.BaseProvider
+	zones             provider.ZoneIDName
+	failZone          string
+	failErr           error
+	applyChangesMux   sync.Mutex
+	ApplyChangesCalls []*plan.Changes
+}
+
+func (p *zoneMockProvider) ZoneIDNames(ctx context.Context) (provider.ZoneIDName, error) {
+	return p.zones, nil
+}
+
+func (p *zoneMockProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	return []*endpoint.Endpoint{}, nil
+}
+
+func (p *zoneMockProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	p.applyChangesMux.Lock()
+	p.ApplyChangesCalls = append(p.ApplyChangesCalls, changes)
+	p.applyChangesMux.Unlock()
+	for _, ep := range changes.Create {
+		if _, zoneName := p.zones.FindZone(ep.DNSName); zoneName == p.failZone {
+			return p.failErr
+		}
+	}
+	return nil
+}
+
+func TestSplitChangesByZone(t *testing.T) {
+	zones := provider.ZoneIDName{
+		"z1": "example.org.",
+		"z2": "sub.example.org",
+		"z3": "example.com",
+	}
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+			endpoint.NewEndpoint("a.sub.example.org", endpoint.RecordTypeA, "2.2.2.2"),
+			endpoint.NewEndpoint("a.example.net", endpoint.RecordTypeA, "3.3.3.3"),
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "4.4.4.4"),
+			endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "5.5.5.5"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("b.example.com", endpoint.RecordTypeA, "4.4.4.5"),
+			endpoint.NewEndpoint("b.example.org", endpoint.RecordTypeA, "5.5.5.6"),
+		},
+		Delete: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("c.sub.example.org.", endpoint.RecordTypeA, "6.6.6.6"),
+		},
+	}
+
+	changesByZone := splitChangesByZone(zones, changes)
+
+	require.Len(t, changesByZone, 4)
+	assert.Equal(t, &plan.Changes{
+		Create:    changes.Create[:1],
+		UpdateOld: changes.UpdateOld[1:],
+		UpdateNew: changes.UpdateNew[1:],
+	}, changesByZone["example.org"])
+	assert.Equal(t, &plan.Changes{
+		Create: changes.Create[1:2],
+		Delete: changes.Delete,
+	}, changesByZone["sub.example.org"])
+	assert.Equal(t, &plan.Changes{
+		UpdateOld: changes.UpdateOld[:1],
+		UpdateNew: changes.UpdateNew[:1],
+	}, changesByZone["example.com"])
+	assert.Equal(t, &plan.Changes{
+		Create: changes.Create[2:],
+	}, changesByZone[""])
+}
+
+func testRunOncePerZone(t *testing.T, failErr error) (*zoneMockProvider, error) {
+	t.Helper()
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "2.2.2.2"),
+		endpoint.NewEndpoint("a.example.net", endpoint.RecordTypeA, "3.3.3.3"),
+	}, nil)
+
+	p := &zoneMockProvider{
+		zones: provider.ZoneIDName{
+			"z1": "example.org",
+			"z2": "example.com",
+			"z3": "example.net",
+		},
+		failZone: "example.com",
+		failErr:  failErr,
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		ZoneProvider:       p,
+		ZoneConcurrency:    2,
+	}
+
+	return p, ctrl.RunOnce(context.Background())
+}
+
+func TestRunOncePerZoneIsolatesErrors(t *testing.T) {
+	p, err := testRunOncePerZone(t, errors.New("zone is broken"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `zone "example.com"`)
+	assert.False(t, errors.Is(err, provider.SoftError))
+	// every zone has been attempted despite the failure
+	assert.Len(t, p.ApplyChangesCalls, 3)
+}
+
+func TestRunOncePerZoneSoftError(t *testing.T) {
+	p, err := testRunOncePerZone(t, provider.NewSoftError(errors.New("rate limited")))
+
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, provider.SoftError))
+	assert.Len(t, p.ApplyChangesCalls, 3)
+}
+
+func TestRunOncePerZoneDisabled(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+		endpoint.NewEndpoint("a.example.com", endpoint.RecordTypeA, "2.2.2.2"),
+	}, nil)
+
+	p := &zoneMockProvider{
+		zones: provider.ZoneIDName{"z1": "example.org", "z2": "example.com"},
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeA},
+		ZoneProvider:       p,
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	assert.Len(t, p.ApplyChangesCalls, 1)
+}
+
+func TestRunOnceRefusesCNAMEAtZoneApex(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("example.org", endpoint.RecordTypeCNAME, "lb.example.com"),
+		endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "lb.example.com"),
+	}, nil)
+
+	p := &zoneMockProvider{
+		zones: provider.ZoneIDName{"z1": "example.org"},
+	}
+	r, err := registry.NewNoopRegistry(p)
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeCNAME},
+		ZoneProvider:       p,
+	}
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	require.Len(t, p.ApplyChangesCalls, 1)
+	require.Len(t, p.ApplyChangesCalls[0].Create, 1)
+	assert.Equal(t, "www.example.org", p.ApplyChangesCalls[0].Create[0].DNSName)
+
+	decisions := ctrl.Decisions("example.org")
+	require.Len(t, decisions, 1)
+	assert.Equal(t, plan.DecisionCNAMEConflict, decisions[0].Reason)
+}
+
+func TestRunOnceWarnsOnceWithoutZones(t *testing.T) {
+	source := new(testutils.MockSource)
+	source.On("Endpoints").Return([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("example.org", endpoint.RecordTypeCNAME, "lb.example.com"),
+	}, nil)
+
+	r, err := registry.NewNoopRegistry(&zoneMockProvider{})
+	require.NoError(t, err)
+
+	ctrl := &Controller{
+		Source:             source,
+		Registry:           r,
+		Policy:             &plan.SyncPolicy{},
+		ManagedRecordTypes: []string{endpoint.RecordTypeCNAME},
+	}
+
+	hook := logtest.NewGlobal()
+	defer log.StandardLogger().ReplaceHooks(log.LevelHooks{})
+
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+	require.NoError(t, ctrl.RunOnce(context.Background()))
+
+	warnings := 0
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == log.WarnLevel && entry.Message == "The DNS zones are not known, CNAME records at the zone apex are not refused" {
+			warnings++
+		}
+	}
+	assert.Equal(t, 1, warnings)
+}
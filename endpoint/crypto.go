@@ -21,15 +21,111 @@ import (
 	"compress/gzip"
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
-const standardGcmNonceSize = 12
+const (
+	standardGcmNonceSize = 12
+	// signatureSize is the number of bytes the HMAC-SHA256 signatures are truncated to, for keeping TXT records short
+	signatureSize = 16
+	// keyIDSize is the number of bytes of the SHA-256 hash of an AES key its ID is made of
+	keyIDSize = 4
+	// keyIDSeparator separates the key ID from the encrypted text, it is not part of the base64 alphabet
+	keyIDSeparator = ":"
+	// legacyKeyRefPrefix starts the references of the keys of texts encrypted without key ID
+	legacyKeyRefPrefix = "legacy-"
+)
+
+// AESKeyring holds the AES keys of encrypted texts. The active key encrypts the texts, and the retired keys can
+// still decrypt the texts they encrypted, so that the active key can be rotated. Encrypted texts start with the ID of
+// their key, texts encrypted without key ID are decrypted with any of the keys.
+type AESKeyring struct {
+	ids  []string
+	keys map[string][]byte
+}
+
+// NewAESKeyring returns the keyring of the active AES key followed by the retired keys, or nil without keys
+func NewAESKeyring(activeKey []byte, retiredKeys ...[]byte) (*AESKeyring, error) {
+	if len(activeKey) == 0 {
+		if len(retiredKeys) > 0 {
+			return nil, errors.New("the retired AES keys require an active AES key")
+		}
+		return nil, nil
+	}
+	keyring := &AESKeyring{keys: map[string][]byte{}}
+	for _, key := range append([][]byte{activeKey}, retiredKeys...) {
+		if len(key) != 32 {
+			return nil, errors.New("the AES Encryption key must have a length of 32 bytes")
+		}
+		hash := sha256.Sum256(key)
+		id := hex.EncodeToString(hash[:keyIDSize])
+		if _, ok := keyring.keys[id]; ok {
+			continue
+		}
+		keyring.ids = append(keyring.ids, id)
+		keyring.keys[id] = key
+	}
+	return keyring, nil
+}
+
+// ActiveKeyID returns the ID of the key new texts are encrypted with
+func (k *AESKeyring) ActiveKeyID() string {
+	return k.ids[0]
+}
+
+// Retired returns true if the text of the key reference is not encrypted with the active key, or without key ID
+func (k *AESKeyring) Retired(keyRef string) bool {
+	return keyRef != k.ActiveKeyID()
+}
+
+// Encrypt encrypts the text the same way as the text of the key reference returned by Decrypt, or with the active key
+// if there is no such key in the keyring, and returns the encrypted text and its key reference
+func (k *AESKeyring) Encrypt(text string, keyRef string, nonceEncoded []byte) (string, string, error) {
+	keyID, legacy := strings.CutPrefix(keyRef, legacyKeyRefPrefix)
+	key, ok := k.keys[keyID]
+	if !ok {
+		keyID, legacy = k.ActiveKeyID(), false
+		key = k.keys[keyID]
+	}
+	encrypted, err := EncryptText(text, key, nonceEncoded)
+	if err != nil {
+		return "", "", err
+	}
+	if legacy {
+		return encrypted, legacyKeyRefPrefix + keyID, nil
+	}
+	return keyID + keyIDSeparator + encrypted, keyID, nil
+}
+
+// Decrypt decrypts the text, and returns the decrypted text, its nonce and the reference of the key it was decrypted
+// with, which is the ID of the key unless the text was encrypted without key ID
+func (k *AESKeyring) Decrypt(text string) (decryptResult string, encryptNonce string, keyRef string, err error) {
+	if id, encrypted, found := strings.Cut(text, keyIDSeparator); found {
+		key, ok := k.keys[id]
+		if !ok {
+			return "", "", "", fmt.Errorf("the text is encrypted with the unknown AES key %q", id)
+		}
+		decryptResult, encryptNonce, err = DecryptText(encrypted, key)
+		return decryptResult, encryptNonce, id, err
+	}
+	for _, id := range k.ids {
+		decryptResult, encryptNonce, err = DecryptText(text, k.keys[id])
+		if err == nil {
+			return decryptResult, encryptNonce, legacyKeyRefPrefix + id, nil
+		}
+	}
+	return "", "", "", err
+}
 
 // GenerateNonce creates a random nonce of a fixed size
 func GenerateNonce() ([]byte, error) {
@@ -99,6 +195,23 @@ func DecryptText(text string, aesKey []byte) (decryptResult string, encryptNonce
 	return string(plaindata), base64.StdEncoding.EncodeToString(nonce), nil
 }
 
+// signText returns the HMAC-SHA256 signature of the text with the key, hex encoded
+func signText(text string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(text))
+	return hex.EncodeToString(mac.Sum(nil)[:signatureSize])
+}
+
+// verifyText returns true if the signature is the signature of the text with one of the keys
+func verifyText(text, signature string, keys [][]byte) bool {
+	for _, key := range keys {
+		if hmac.Equal([]byte(signText(text, key)), []byte(signature)) {
+			return true
+		}
+	}
+	return false
+}
+
 // decompressData gzip compressed data
 func decompressData(data []byte) (resData []byte, err error) {
 	gz, err := gzip.NewReader(bytes.NewBuffer(data))
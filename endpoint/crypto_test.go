@@ -17,6 +17,7 @@ limitations under the License.
 package endpoint
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -56,3 +57,64 @@ func TestEncrypt(t *testing.T) {
 		t.Error("Decryption of text didn't result in expected plaintext result.")
 	}
 }
+
+func TestAESKeyring(t *testing.T) {
+	activeKey := []byte("s%zF`.*'5`9.AhI2!B,.~hmbs^.*TL?;")
+	retiredKey := []byte("s'J!jD`].LC?g&Oa11AgTub,j48ts/96")
+	plaintext := "heritage=external-dns,external-dns/owner=owner"
+
+	keyring, err := NewAESKeyring(nil)
+	require.NoError(t, err)
+	require.Nil(t, keyring)
+	_, err = NewAESKeyring(nil, retiredKey)
+	require.Error(t, err)
+	_, err = NewAESKeyring(activeKey, []byte("too-short"))
+	require.Error(t, err)
+
+	keyring, err = NewAESKeyring(activeKey, retiredKey)
+	require.NoError(t, err)
+	retired, err := NewAESKeyring(retiredKey)
+	require.NoError(t, err)
+	require.NotEqual(t, keyring.ActiveKeyID(), retired.ActiveKeyID())
+
+	// texts are encrypted with the active key unless they were encrypted with another key of the keyring
+	nonce, err := GenerateNonce()
+	require.NoError(t, err)
+	encrypted, keyID, err := keyring.Encrypt(plaintext, "", nonce)
+	require.NoError(t, err)
+	require.Equal(t, keyring.ActiveKeyID(), keyID)
+	require.True(t, strings.HasPrefix(encrypted, keyID+":"))
+
+	encryptedRetired, keyID, err := keyring.Encrypt(plaintext, retired.ActiveKeyID(), nonce)
+	require.NoError(t, err)
+	require.Equal(t, retired.ActiveKeyID(), keyID)
+
+	for _, text := range []string{encrypted, encryptedRetired} {
+		decrypted, decryptedNonce, decryptedKeyID, err := keyring.Decrypt(text)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, decrypted)
+		require.Equal(t, string(nonce), decryptedNonce)
+		require.True(t, strings.HasPrefix(text, decryptedKeyID+":"))
+	}
+	require.False(t, keyring.Retired(keyring.ActiveKeyID()))
+	require.True(t, keyring.Retired(retired.ActiveKeyID()))
+
+	// texts encrypted without key ID are decrypted with any key
+	legacy, err := EncryptText(plaintext, retiredKey, nonce)
+	require.NoError(t, err)
+	decrypted, _, keyRef, err := keyring.Decrypt(legacy)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decrypted)
+	require.Equal(t, "legacy-"+retired.ActiveKeyID(), keyRef)
+	require.True(t, keyring.Retired(keyRef))
+
+	// texts encrypted without key ID are encrypted again without key ID, to be reproduced identically
+	reencrypted, reencryptedKeyRef, err := keyring.Encrypt(plaintext, keyRef, nonce)
+	require.NoError(t, err)
+	require.Equal(t, legacy, reencrypted)
+	require.Equal(t, keyRef, reencryptedKeyRef)
+
+	// texts encrypted with a key that is not in the keyring are not decrypted
+	_, _, _, err = retired.Decrypt(encrypted)
+	require.Error(t, err)
+}
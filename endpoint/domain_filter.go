@@ -64,6 +64,12 @@ func prepareFilters(filters []string) []string {
 	var fs []string
 	for _, filter := range filters {
 		if domain := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(filter), ".")); domain != "" {
+			// internationalized filters match the punycode of the names, a leading dot restricts the filter to subdomains
+			if strings.HasPrefix(domain, ".") {
+				domain = "." + asciiName(domain[1:])
+			} else {
+				domain = asciiName(domain)
+			}
 			fs = append(fs, domain)
 		}
 	}
@@ -486,6 +486,10 @@ func TestPrepareFiltersStripsWhitespaceAndDotSuffix(t *testing.T) {
 			[]string{"foo.bar", "  foo.bar.  ", " foo.bar.baz ", " foo.bar.baz.  "},
 			[]string{"foo.bar", "foo.bar", "foo.bar.baz", "foo.bar.baz"},
 		},
+		{
+			[]string{"Bücher.de.", ".bücher.de"},
+			[]string{"xn--bcher-kva.de", ".xn--bcher-kva.de"},
+		},
 	} {
 		t.Run("test string", func(t *testing.T) {
 			assert.Equal(t, tt.output, prepareFilters(tt.input))
@@ -46,6 +46,19 @@ const (
 	RecordTypeMX = "MX"
 	// RecordTypeNAPTR is a RecordType enum value
 	RecordTypeNAPTR = "NAPTR"
+	// RecordTypeCAA is a RecordType enum value
+	RecordTypeCAA = "CAA"
+	// RecordTypeTLSA is a RecordType enum value
+	RecordTypeTLSA = "TLSA"
+	// RecordTypeSSHFP is a RecordType enum value
+	RecordTypeSSHFP = "SSHFP"
+	// RecordTypeSVCB is a RecordType enum value
+	RecordTypeSVCB = "SVCB"
+	// RecordTypeHTTPS is a RecordType enum value
+	RecordTypeHTTPS = "HTTPS"
+	// RecordTypeALIAS is a RecordType enum value, for a hostname target published as the address records it
+	// resolves to, which is allowed at the zone apex
+	RecordTypeALIAS = "ALIAS"
 )
 
 // TTL is a structure defining the TTL of a DNS record
@@ -196,22 +209,16 @@ func NewEndpoint(dnsName, recordType string, targets ...string) *Endpoint {
 	return NewEndpointWithTTL(dnsName, recordType, TTL(0), targets...)
 }
 
-// NewEndpointWithTTL initialization method to be used to create an endpoint with a TTL struct
+// NewEndpointWithTTL initialization method to be used to create an endpoint with a TTL struct.
+// The endpoint is not validated, invalid endpoints are refused by Validate.
 func NewEndpointWithTTL(dnsName, recordType string, ttl TTL, targets ...string) *Endpoint {
 	cleanTargets := make([]string, len(targets))
 	for idx, target := range targets {
-		cleanTargets[idx] = strings.TrimSuffix(target, ".")
-	}
-
-	for _, label := range strings.Split(dnsName, ".") {
-		if len(label) > 63 {
-			log.Errorf("label %s in %s is longer than 63 characters. Cannot create endpoint", label, dnsName)
-			return nil
-		}
+		cleanTargets[idx] = asciiTarget(recordType, strings.TrimSuffix(target, "."))
 	}
 
 	return &Endpoint{
-		DNSName:    strings.TrimSuffix(dnsName, "."),
+		DNSName:    strings.TrimSuffix(asciiName(dnsName), "."),
 		Targets:    cleanTargets,
 		RecordType: recordType,
 		Labels:     NewLabels(),
@@ -219,6 +226,18 @@ func NewEndpointWithTTL(dnsName, recordType string, ttl TTL, targets ...string)
 	}
 }
 
+// asciiTarget converts the host names of an internationalized target to punycode, see ToASCIIName
+func asciiTarget(recordType, target string) string {
+	if isASCII(target) {
+		return target
+	}
+	switch recordType {
+	case RecordTypeCNAME, RecordTypeNS, RecordTypePTR, RecordTypeALIAS:
+		return asciiName(target)
+	}
+	return NormalizeTarget(recordType, target)
+}
+
 // WithSetIdentifier applies the given set identifier to the endpoint.
 func (e *Endpoint) WithSetIdentifier(setIdentifier string) *Endpoint {
 	e.SetIdentifier = setIdentifier
@@ -279,12 +298,29 @@ func (e *Endpoint) Key() EndpointKey {
 	}
 }
 
-// IsOwnedBy returns true if the endpoint owner label matches the given ownerID, false otherwise
+// IsOwnedBy returns true if the endpoint owner label matches the given ownerID
 func (e *Endpoint) IsOwnedBy(ownerID string) bool {
 	endpointOwner, ok := e.Labels[OwnerLabelKey]
 	return ok && endpointOwner == ownerID
 }
 
+// IsSharedWith returns true if the given ownerID contributes targets to the endpoint.
+// Shared ownership is only honoured by the multi-owner conflict resolver.
+func (e *Endpoint) IsSharedWith(ownerID string) bool {
+	_, ok := e.Labels[OwnerTargetsLabelPrefix+ownerID]
+	return ok
+}
+
+// IsShared returns true if the endpoint is shared by several owners, each of them contributing targets
+func (e *Endpoint) IsShared() bool {
+	for key := range e.Labels {
+		if strings.HasPrefix(key, OwnerTargetsLabelPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Endpoint) String() string {
 	return fmt.Sprintf("%s %d IN %s %s %s %s", e.DNSName, e.RecordTTL, e.RecordType, e.SetIdentifier, e.Targets, e.ProviderSpecific)
 }
@@ -294,8 +330,8 @@ func (e *Endpoint) String() string {
 func FilterEndpointsByOwnerID(ownerID string, eps []*Endpoint) []*Endpoint {
 	filtered := []*Endpoint{}
 	for _, ep := range eps {
-		if endpointOwner, ok := ep.Labels[OwnerLabelKey]; !ok || endpointOwner != ownerID {
-			log.Debugf(`Skipping endpoint %v because owner id does not match, found: "%s", required: "%s"`, ep, endpointOwner, ownerID)
+		if !ep.IsOwnedBy(ownerID) {
+			log.Debugf(`Skipping endpoint %v because owner id does not match, found: "%s", required: "%s"`, ep, ep.Labels[OwnerLabelKey], ownerID)
 		} else {
 			filtered = append(filtered, ep)
 		}
@@ -304,16 +340,129 @@ func FilterEndpointsByOwnerID(ownerID string, eps []*Endpoint) []*Endpoint {
 	return filtered
 }
 
+// FilterUpdatesByOwnerID filters the pairs of old and new endpoints of updates and returns the ones that
+// match. An update matches if the old endpoint is owned by ownerID.
+func FilterUpdatesByOwnerID(ownerID string, oldEps, newEps []*Endpoint) ([]*Endpoint, []*Endpoint) {
+	return filterUpdates(ownerID, oldEps, newEps, func(oldEp, _ *Endpoint) bool {
+		return oldEp.IsOwnedBy(ownerID)
+	})
+}
+
+// FilterSharedEndpointsByOwnerID returns the endpoints which are owned by ownerID or shared with it.
+// It must only be used when the multi-owner conflict resolver is enabled.
+func FilterSharedEndpointsByOwnerID(ownerID string, eps []*Endpoint) []*Endpoint {
+	filtered := []*Endpoint{}
+	for _, ep := range eps {
+		if !ep.IsOwnedBy(ownerID) && !ep.IsSharedWith(ownerID) {
+			log.Debugf(`Skipping endpoint %v because owner id does not match, found: "%s", required: "%s"`, ep, ep.Labels[OwnerLabelKey], ownerID)
+		} else {
+			filtered = append(filtered, ep)
+		}
+	}
+
+	return filtered
+}
+
+// FilterSharedUpdatesByOwnerID is like FilterUpdatesByOwnerID, but an update also matches if the old endpoint is
+// shared with ownerID, or if ownerID joins the owners of a shared endpoint.
+// It must only be used when the multi-owner conflict resolver is enabled.
+func FilterSharedUpdatesByOwnerID(ownerID string, oldEps, newEps []*Endpoint) ([]*Endpoint, []*Endpoint) {
+	return filterUpdates(ownerID, oldEps, newEps, func(oldEp, newEp *Endpoint) bool {
+		return oldEp.IsOwnedBy(ownerID) || oldEp.IsSharedWith(ownerID) || (oldEp.IsShared() && newEp.IsSharedWith(ownerID))
+	})
+}
+
+func filterUpdates(ownerID string, oldEps, newEps []*Endpoint, owned func(oldEp, newEp *Endpoint) bool) ([]*Endpoint, []*Endpoint) {
+	filteredOld, filteredNew := []*Endpoint{}, []*Endpoint{}
+	for i := range oldEps {
+		if i >= len(newEps) {
+			break
+		}
+		if !owned(oldEps[i], newEps[i]) {
+			log.Debugf(`Skipping update of endpoint %v because owner id does not match, found: "%s", required: "%s"`, oldEps[i], oldEps[i].Labels[OwnerLabelKey], ownerID)
+			continue
+		}
+		filteredOld = append(filteredOld, oldEps[i])
+		filteredNew = append(filteredNew, newEps[i])
+	}
+
+	return filteredOld, filteredNew
+}
+
 // DNSEndpointSpec defines the desired state of DNSEndpoint
 type DNSEndpointSpec struct {
 	Endpoints []*Endpoint `json:"endpoints,omitempty"`
 }
 
+// EndpointConditionType is the state of a record of a DNSEndpoint
+type EndpointConditionType string
+
+const (
+	// EndpointProgrammed is the condition of a record which is live at the DNS provider
+	EndpointProgrammed EndpointConditionType = "Programmed"
+	// EndpointPending is the condition of a record which has not been applied yet, e.g. because the DNS
+	// provider failed or a policy prevented the change
+	EndpointPending EndpointConditionType = "Pending"
+	// EndpointConflicted is the condition of a record which is held by another resource or owner
+	EndpointConflicted EndpointConditionType = "Conflicted"
+	// EndpointRejected is the condition of a record which is invalid or not managed by external-dns
+	EndpointRejected EndpointConditionType = "Rejected"
+)
+
+// DNSEndpointProgrammed is the type of the DNSEndpoint condition which is true when all its records are programmed
+const DNSEndpointProgrammed = "Programmed"
+
+// EndpointStatus is the observed state of a record of a DNSEndpoint
+type EndpointStatus struct {
+	// The hostname of the DNS record
+	DNSName string `json:"dnsName"`
+	// RecordType type of record, e.g. CNAME, A, SRV, TXT etc
+	RecordType string `json:"recordType"`
+	// Identifier to distinguish multiple records with the same name and type
+	// +optional
+	SetIdentifier string `json:"setIdentifier,omitempty"`
+	// The state of the record: Programmed, Pending, Conflicted or Rejected
+	Condition EndpointConditionType `json:"condition"`
+	// A machine-readable reason for the condition
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// A human-readable description of the condition
+	// +optional
+	Message string `json:"message,omitempty"`
+	// The DNS zone the record is applied to
+	// +optional
+	Zone string `json:"zone,omitempty"`
+	// The owner ID of the external-dns instance holding the record
+	// +optional
+	Owner string `json:"owner,omitempty"`
+	// The last time the record was applied to the DNS provider
+	// +optional
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+	// The last error returned by the DNS provider for the record
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Key returns the key of the record the status refers to
+func (s *EndpointStatus) Key() EndpointKey {
+	return EndpointKey{
+		DNSName:       s.DNSName,
+		RecordType:    s.RecordType,
+		SetIdentifier: s.SetIdentifier,
+	}
+}
+
 // DNSEndpointStatus defines the observed state of DNSEndpoint
 type DNSEndpointStatus struct {
 	// The generation observed by the external-dns controller.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The state of every record of the DNSEndpoint
+	// +optional
+	Endpoints []EndpointStatus `json:"endpoints,omitempty"`
+	// The conditions of the DNSEndpoint, a Programmed condition is true when all its records are live
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +genclient
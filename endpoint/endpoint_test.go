@@ -204,6 +204,12 @@ func TestIsOwnedBy(t *testing.T) {
 			args:   args{ownerID: "foo"},
 			want:   true,
 		},
+		{
+			name:   "owner targets label does not grant ownership",
+			fields: fields{Labels: Labels{OwnerLabelKey: "bar", OwnerTargetsLabelPrefix + "foo": "1.2.3.4"}},
+			args:   args{ownerID: "foo"},
+			want:   false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -216,3 +222,43 @@ func TestIsOwnedBy(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterUpdatesByOwnerID(t *testing.T) {
+	fooOld := &Endpoint{DNSName: "foo.com", RecordType: RecordTypeA, Labels: Labels{OwnerLabelKey: "foo"}}
+	fooNew := &Endpoint{DNSName: "foo.com", RecordType: RecordTypeA, Labels: Labels{OwnerLabelKey: "foo"}}
+	barOld := &Endpoint{DNSName: "bar.com", RecordType: RecordTypeA, Labels: Labels{OwnerLabelKey: "bar"}}
+	barNew := &Endpoint{DNSName: "bar.com", RecordType: RecordTypeA, Labels: Labels{OwnerLabelKey: "bar", OwnerTargetsLabelPrefix + "foo": "1.2.3.4"}}
+	sharedOld := &Endpoint{DNSName: "shared.com", RecordType: RecordTypeA, Labels: Labels{OwnerLabelKey: "bar", OwnerTargetsLabelPrefix + "bar": "1.1.1.1"}}
+	sharedNew := &Endpoint{DNSName: "shared.com", RecordType: RecordTypeA, Labels: Labels{OwnerLabelKey: "bar", OwnerTargetsLabelPrefix + "bar": "1.1.1.1", OwnerTargetsLabelPrefix + "foo": "1.2.3.4"}}
+
+	gotOld, gotNew := FilterUpdatesByOwnerID("foo", []*Endpoint{fooOld, barOld, sharedOld}, []*Endpoint{fooNew, barNew, sharedNew})
+	if !reflect.DeepEqual(gotOld, []*Endpoint{fooOld}) || !reflect.DeepEqual(gotNew, []*Endpoint{fooNew}) {
+		t.Errorf("FilterUpdatesByOwnerID() = %v, %v", gotOld, gotNew)
+	}
+
+	gotOld, gotNew = FilterSharedUpdatesByOwnerID("foo", []*Endpoint{fooOld, barOld, sharedOld}, []*Endpoint{fooNew, barNew, sharedNew})
+	if !reflect.DeepEqual(gotOld, []*Endpoint{fooOld, sharedOld}) || !reflect.DeepEqual(gotNew, []*Endpoint{fooNew, sharedNew}) {
+		t.Errorf("FilterSharedUpdatesByOwnerID() = %v, %v", gotOld, gotNew)
+	}
+}
+
+func TestFilterEndpointsByOwnerIDIgnoresOwnerTargets(t *testing.T) {
+	foo := &Endpoint{DNSName: "foo.com", RecordType: RecordTypeA, Labels: Labels{OwnerLabelKey: "foo"}}
+	shared := &Endpoint{DNSName: "shared.com", RecordType: RecordTypeA, Labels: Labels{OwnerLabelKey: "bar", OwnerTargetsLabelPrefix + "foo": "1.2.3.4"}}
+
+	if got := FilterEndpointsByOwnerID("foo", []*Endpoint{foo, shared}); !reflect.DeepEqual(got, []*Endpoint{foo}) {
+		t.Errorf("FilterEndpointsByOwnerID() = %v", got)
+	}
+	if got := FilterSharedEndpointsByOwnerID("foo", []*Endpoint{foo, shared}); !reflect.DeepEqual(got, []*Endpoint{foo, shared}) {
+		t.Errorf("FilterSharedEndpointsByOwnerID() = %v", got)
+	}
+}
+
+func TestIsShared(t *testing.T) {
+	if (&Endpoint{Labels: Labels{OwnerLabelKey: "foo"}}).IsShared() {
+		t.Error("endpoint without owner targets should not be shared")
+	}
+	if !(&Endpoint{Labels: Labels{OwnerTargetsLabelPrefix + "foo": "1.2.3.4"}}).IsShared() {
+		t.Error("endpoint with owner targets should be shared")
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// idnaProfile maps internationalized domain names as RFC 5891 requires for lookups. Underscores and asterisks are
+// allowed, as they are used in the names of service records and wildcards.
+var idnaProfile = idna.New(
+	idna.MapForLookup(),
+	idna.BidiRule(),
+	idna.Transitional(false),
+	idna.StrictDomainName(false),
+)
+
+// ToASCIIName validates a DNS name and returns it in its ASCII form, in lower case and without trailing dot.
+// Internationalized labels are converted to punycode, e.g. "bücher.example.org" to "xn--bcher-kva.example.org".
+// The name must not be longer than 253 octets, and its labels not longer than 63 octets. Labels consist of letters,
+// digits, hyphens and underscores and do not start or end with a hyphen; the first label may also be "*".
+func ToASCIIName(name string) (string, error) {
+	trimmed := strings.TrimSuffix(strings.TrimSpace(name), ".")
+	if trimmed == "" {
+		return "", fmt.Errorf("DNS name %q is empty", name)
+	}
+	ascii := strings.ToLower(trimmed)
+	if !isASCII(ascii) || strings.Contains(ascii, "xn--") {
+		var err error
+		if ascii, err = idnaProfile.ToASCII(ascii); err != nil {
+			return "", fmt.Errorf("DNS name %q is not a valid internationalized domain name: %w", name, err)
+		}
+	}
+	if len(ascii) > 253 {
+		return "", fmt.Errorf("DNS name %q is longer than 253 octets", name)
+	}
+	for i, label := range strings.Split(ascii, ".") {
+		if err := validateLabel(label, i == 0); err != nil {
+			return "", fmt.Errorf("DNS name %q is invalid: %w", name, err)
+		}
+	}
+	return ascii, nil
+}
+
+// ToUnicodeName returns a DNS name with its punycode labels converted to Unicode, e.g. for display
+func ToUnicodeName(name string) (string, error) {
+	return idnaProfile.ToUnicode(strings.ToLower(strings.TrimSuffix(name, ".")))
+}
+
+// asciiName returns the ASCII form of an internationalized DNS name. Other names, and names which cannot be converted,
+// are returned as they are, to be refused where they are validated.
+func asciiName(name string) string {
+	if isASCII(name) {
+		return name
+	}
+	ascii, err := ToASCIIName(name)
+	if err != nil {
+		return name
+	}
+	return ascii
+}
+
+func validateLabel(label string, first bool) error {
+	if label == "" {
+		return fmt.Errorf("empty label")
+	}
+	if len(label) > 63 {
+		return fmt.Errorf("label %q is longer than 63 octets", label)
+	}
+	if label == "*" && first {
+		return nil
+	}
+	if label[0] == '-' || label[len(label)-1] == '-' {
+		return fmt.Errorf("label %q starts or ends with a hyphen", label)
+	}
+	for _, c := range label {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return fmt.Errorf("label %q contains the invalid character %q", label, c)
+		}
+	}
+	return nil
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToASCIIName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Bücher.Example.org.":         "xn--bcher-kva.example.org",
+		"xn--bcher-kva.example.org":   "xn--bcher-kva.example.org",
+		"faß.example.org":             "xn--fa-hia.example.org",
+		"*.bücher.example.org":        "*.xn--bcher-kva.example.org",
+		"_sip._tcp.example.org":       "_sip._tcp.example.org",
+		"WWW.example.org":             "www.example.org",
+		strings.Repeat("a", 63) + ".": strings.Repeat("a", 63),
+	} {
+		ascii, err := ToASCIIName(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, expected, ascii, name)
+	}
+
+	for _, name := range []string{
+		"",
+		".",
+		"www..example.org",
+		"-www.example.org",
+		"www-.example.org",
+		"www.*.example.org",
+		"w w w.example.org",
+		"www@example.org",
+		"xn--zz.example.org",
+		strings.Repeat("a", 64) + ".example.org",
+		strings.Repeat(strings.Repeat("a", 63)+".", 4),
+		strings.Repeat("ü", 60) + ".example.org",
+	} {
+		_, err := ToASCIIName(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestToUnicodeName(t *testing.T) {
+	name, err := ToUnicodeName("xn--bcher-kva.example.org.")
+	require.NoError(t, err)
+	assert.Equal(t, "bücher.example.org", name)
+}
+
+func TestNewEndpointIDN(t *testing.T) {
+	ep := NewEndpoint("bücher.example.org", RecordTypeCNAME, "shop.bücher.example.org.")
+	assert.Equal(t, "xn--bcher-kva.example.org", ep.DNSName)
+	assert.Equal(t, Targets{"shop.xn--bcher-kva.example.org"}, ep.Targets)
+
+	ep = NewEndpoint("bücher.example.org", RecordTypeMX, "10 mail.bücher.example.org")
+	assert.Equal(t, Targets{"10 mail.xn--bcher-kva.example.org"}, ep.Targets)
+
+	ep = NewEndpoint("Foo.example.org", RecordTypeA, "1.2.3.4")
+	assert.Equal(t, "Foo.example.org", ep.DNSName)
+}
@@ -28,6 +28,12 @@ import (
 // ErrInvalidHeritage is returned when heritage was not found, or different heritage is found
 var ErrInvalidHeritage = errors.New("heritage is unknown or not found")
 
+// ErrInvalidSignature is returned when labels are not signed with one of the verification keys
+var ErrInvalidSignature = errors.New("signature is invalid")
+
+// ErrMissingSignature is returned when labels are not signed although verification keys are provided
+var ErrMissingSignature = errors.New("signature is missing")
+
 const (
 	heritage = "external-dns"
 	// OwnerLabelKey is the name of the label that defines the owner of an Endpoint.
@@ -44,8 +50,28 @@ const (
 	// DualstackLabelKey is the name of the label that identifies dualstack endpoints
 	DualstackLabelKey = "dualstack"
 
+	// CreationTimestampLabelKey is the name of the label that holds the creation time (RFC 3339) of the k8s resource
+	// which wants to acquire the DNS name
+	CreationTimestampLabelKey = "resource-creation-timestamp"
+	// PriorityLabelKey is the name of the label that holds the priority of the k8s resource which wants to acquire the DNS name
+	PriorityLabelKey = "priority"
+
+	// PendingDeleteLabelKey is the name of the label that holds the time (RFC 3339) since which a record is no longer desired,
+	// when it is kept for a deletion grace period
+	PendingDeleteLabelKey = "pending-delete"
+
+	// OwnerTargetsLabelPrefix is the prefix of the labels that hold the targets contributed by each owner of a record shared
+	// by several instances of ExternalDNS, e.g. "owner-targets/cluster-a" = "10.0.0.1;10.0.0.2"
+	OwnerTargetsLabelPrefix = "owner-targets/"
+	// ownerTargetsSeparator separates the targets in the value of an owner targets label
+	ownerTargetsSeparator = ";"
+
 	// txtEncryptionNonce label for keep same nonce for same txt records, for prevent different result of encryption for same txt record, it can cause issues for some providers
 	txtEncryptionNonce = "txt-encryption-nonce"
+	// txtEncryptionKey label for keep the same AES key for same txt records, for the same reason as the nonce
+	txtEncryptionKey = "txt-encryption-key"
+	// txtSignature label for the HMAC signature of the other labels, when TXT records are signed
+	txtSignature = "txt-signature"
 )
 
 // Labels store metadata related to the endpoint
@@ -90,15 +116,38 @@ func NewLabelsFromStringPlain(labelText string) (Labels, error) {
 	return endpointLabels, nil
 }
 
-func NewLabelsFromString(labelText string, aesKey []byte) (Labels, error) {
-	if len(aesKey) != 0 {
-		decryptedText, encryptionNonce, err := DecryptText(strings.Trim(labelText, "\""), aesKey)
+// NewLabelsFromString constructs endpoints labels from a provided format string, which is decrypted if it was encrypted
+// with one of the AES keys of the keyring. If verification keys are provided, labels which are not signed with one of
+// them are rejected with ErrInvalidSignature, labels which are not signed at all with ErrMissingSignature.
+func NewLabelsFromString(labelText string, keyring *AESKeyring, verificationKeys [][]byte) (Labels, error) {
+	labels, err := newLabelsFromString(labelText, keyring)
+	if err != nil {
+		return nil, err
+	}
+	signature := labels[txtSignature]
+	delete(labels, txtSignature)
+	if len(verificationKeys) == 0 {
+		return labels, nil
+	}
+	if signature == "" {
+		return nil, ErrMissingSignature
+	}
+	if !verifyText(labels.SerializePlain(false), signature, verificationKeys) {
+		return nil, ErrInvalidSignature
+	}
+	return labels, nil
+}
+
+func newLabelsFromString(labelText string, keyring *AESKeyring) (Labels, error) {
+	if keyring != nil {
+		decryptedText, encryptionNonce, keyRef, err := keyring.Decrypt(strings.Trim(labelText, "\""))
 		//in case if we have decryption error, just try process original text
 		//decryption errors should be ignored here, because we can already have plain-text labels in registry
 		if err == nil {
 			labels, err := NewLabelsFromStringPlain(decryptedText)
 			if err == nil {
 				labels[txtEncryptionNonce] = encryptionNonce
+				labels[txtEncryptionKey] = keyRef
 			}
 
 			return labels, err
@@ -107,6 +156,34 @@ func NewLabelsFromString(labelText string, aesKey []byte) (Labels, error) {
 	return NewLabelsFromStringPlain(labelText)
 }
 
+// EncryptionKey returns the reference of the AES key the labels were decrypted with, see AESKeyring.Decrypt, or an empty
+// string if they were not encrypted
+func (l Labels) EncryptionKey() string {
+	return l[txtEncryptionKey]
+}
+
+// OwnerTargets returns the targets contributed by each owner of a shared record, keyed by owner ID
+func (l Labels) OwnerTargets() map[string]Targets {
+	result := map[string]Targets{}
+	for key, value := range l {
+		if ownerID, ok := strings.CutPrefix(key, OwnerTargetsLabelPrefix); ok {
+			result[ownerID] = NewTargets(strings.Split(value, ownerTargetsSeparator)...)
+		}
+	}
+	return result
+}
+
+// SetOwnerTargets records the targets contributed by the given owner, or removes its contribution if there are none
+func (l Labels) SetOwnerTargets(ownerID string, targets Targets) {
+	if len(targets) == 0 {
+		delete(l, OwnerTargetsLabelPrefix+ownerID)
+		return
+	}
+	sorted := append(Targets(nil), targets...)
+	sort.Sort(sorted)
+	l[OwnerTargetsLabelPrefix+ownerID] = strings.Join(sorted, ownerTargetsSeparator)
+}
+
 // SerializePlain transforms endpoints labels into a external-dns recognizable format string
 // withQuotes adds additional quotes
 func (l Labels) SerializePlain(withQuotes bool) string {
@@ -119,7 +196,7 @@ func (l Labels) SerializePlain(withQuotes bool) string {
 	sort.Strings(keys) // sort for consistency
 
 	for _, key := range keys {
-		if key == txtEncryptionNonce {
+		if key == txtEncryptionNonce || key == txtEncryptionKey {
 			continue
 		}
 		tokens = append(tokens, fmt.Sprintf("%s/%s=%s", heritage, key, l[key]))
@@ -130,10 +207,26 @@ func (l Labels) SerializePlain(withQuotes bool) string {
 	return strings.Join(tokens, ",")
 }
 
-// Serialize same to SerializePlain, but encrypt data, if encryption enabled
-func (l Labels) Serialize(withQuotes bool, txtEncryptEnabled bool, aesKey []byte) string {
+// signed returns a copy of the labels with their signature with the key, or the labels if there is no key
+func (l Labels) signed(signingKey []byte) Labels {
+	if len(signingKey) == 0 {
+		return l
+	}
+	signed := make(Labels, len(l)+1)
+	for key, value := range l {
+		if key != txtSignature {
+			signed[key] = value
+		}
+	}
+	signed[txtSignature] = signText(signed.SerializePlain(false), signingKey)
+	return signed
+}
+
+// Serialize same to SerializePlain, but signs data, if a signing key is provided, and encrypt data, if encryption enabled.
+// Labels are encrypted with the AES key they were decrypted with if it is in the keyring, or with its active key.
+func (l Labels) Serialize(withQuotes bool, txtEncryptEnabled bool, keyring *AESKeyring, signingKey []byte) string {
 	if !txtEncryptEnabled {
-		return l.SerializePlain(withQuotes)
+		return l.signed(signingKey).SerializePlain(withQuotes)
 	}
 
 	var encryptionNonce []byte
@@ -148,14 +241,17 @@ func (l Labels) Serialize(withQuotes bool, txtEncryptEnabled bool, aesKey []byte
 		l[txtEncryptionNonce] = string(encryptionNonce)
 	}
 
-	text := l.SerializePlain(false)
+	text := l.signed(signingKey).SerializePlain(false)
 	log.Debugf("Encrypt the serialized text %#v before returning it.", text)
-	var err error
-	text, err = EncryptText(text, aesKey, encryptionNonce)
-
+	if keyring == nil {
+		log.Fatalf("Failed to encrypt the text %#v without encryption key.", text)
+	}
+	encrypted, keyRef, err := keyring.Encrypt(text, l[txtEncryptionKey], encryptionNonce)
 	if err != nil {
-		log.Fatalf("Failed to encrypt the text %#v using the encryption key %#v. Got error %#v.", text, aesKey, err)
+		log.Fatalf("Failed to encrypt the text %#v using the encryption key %q. Got error %#v.", text, l[txtEncryptionKey], err)
 	}
+	l[txtEncryptionKey] = keyRef
+	text = encrypted
 
 	if withQuotes {
 		text = fmt.Sprintf("\"%s\"", text)
@@ -18,6 +18,7 @@ package endpoint
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/suite"
@@ -26,6 +27,7 @@ import (
 type LabelsSuite struct {
 	suite.Suite
 	aesKey                       []byte
+	keyring                      *AESKeyring
 	foo                          Labels
 	fooAsText                    string
 	fooAsTextWithQuotes          string
@@ -45,6 +47,7 @@ func (suite *LabelsSuite) SetupTest() {
 		"resource": "foo-resource",
 	}
 	suite.aesKey = []byte(")K_Fy|?Z.64#UuHm`}[d!GC%WJM_fs{_")
+	suite.keyring, _ = NewAESKeyring(suite.aesKey)
 	suite.fooAsText = "heritage=external-dns,external-dns/owner=foo-owner,external-dns/resource=foo-resource"
 	suite.fooAsTextWithQuotes = fmt.Sprintf(`"%s"`, suite.fooAsText)
 	suite.fooAsTextEncrypted = `+lvP8q9KHJ6BS6O81i2Q6DLNdf2JSKy8j/gbZKviTZlGYj7q+yDoYMgkQ1hPn6urtGllM5bfFMcaaHto52otQtiOYrX8990J3kQqg4s47m3bH3Ejl8RSxSSuWJM3HJtPghQzYg0/LSOsdQ0=`
@@ -64,21 +67,46 @@ func (suite *LabelsSuite) SetupTest() {
 func (suite *LabelsSuite) TestSerialize() {
 	suite.Equal(suite.fooAsText, suite.foo.SerializePlain(false), "should serializeLabel")
 	suite.Equal(suite.fooAsTextWithQuotes, suite.foo.SerializePlain(true), "should serializeLabel")
-	suite.Equal(suite.fooAsText, suite.foo.Serialize(false, false, nil), "should serializeLabel")
-	suite.Equal(suite.fooAsTextWithQuotes, suite.foo.Serialize(true, false, nil), "should serializeLabel")
-	suite.Equal(suite.fooAsText, suite.foo.Serialize(false, false, suite.aesKey), "should serializeLabel")
-	suite.Equal(suite.fooAsTextWithQuotes, suite.foo.Serialize(true, false, suite.aesKey), "should serializeLabel")
-	suite.NotEqual(suite.fooAsText, suite.foo.Serialize(false, true, suite.aesKey), "should serializeLabel and encrypt")
-	suite.NotEqual(suite.fooAsTextWithQuotes, suite.foo.Serialize(true, true, suite.aesKey), "should serializeLabel and encrypt")
+	suite.Equal(suite.fooAsText, suite.foo.Serialize(false, false, nil, nil), "should serializeLabel")
+	suite.Equal(suite.fooAsTextWithQuotes, suite.foo.Serialize(true, false, nil, nil), "should serializeLabel")
+	suite.Equal(suite.fooAsText, suite.foo.Serialize(false, false, suite.keyring, nil), "should serializeLabel")
+	suite.Equal(suite.fooAsTextWithQuotes, suite.foo.Serialize(true, false, suite.keyring, nil), "should serializeLabel")
+	suite.NotEqual(suite.fooAsText, suite.foo.Serialize(false, true, suite.keyring, nil), "should serializeLabel and encrypt")
+	suite.NotEqual(suite.fooAsTextWithQuotes, suite.foo.Serialize(true, true, suite.keyring, nil), "should serializeLabel and encrypt")
 }
 
 func (suite *LabelsSuite) TestEncryptionNonceReUsage() {
-	foo, err := NewLabelsFromString(suite.fooAsTextEncrypted, suite.aesKey)
+	foo, err := NewLabelsFromString(suite.fooAsTextEncrypted, suite.keyring, nil)
 	suite.NoError(err, "should succeed for valid label text")
-	serialized := foo.Serialize(false, true, suite.aesKey)
+	serialized := foo.Serialize(false, true, suite.keyring, nil)
 	suite.Equal(serialized, suite.fooAsTextEncrypted, "serialized result should be equal")
 }
 
+func (suite *LabelsSuite) TestEncryptionKeyRotation() {
+	activeKey := []byte("s%zF`.*'5`9.AhI2!B,.~hmbs^.*TL?;")
+	keyring, err := NewAESKeyring(activeKey, suite.aesKey)
+	suite.NoError(err)
+
+	foo, err := NewLabelsFromString(suite.fooAsTextEncrypted, keyring, nil)
+	suite.NoError(err, "should decrypt with a retired key")
+	suite.Equal("legacy-"+suite.keyring.ActiveKeyID(), foo.EncryptionKey(), "should keep the reference of the retired key")
+	suite.Equal(suite.fooAsText, foo.SerializePlain(false), "should not serialize the key reference")
+
+	reencrypted := foo.Serialize(false, true, keyring, nil)
+	_, _, err = DecryptText(reencrypted, suite.aesKey)
+	suite.NoError(err, "should encrypt the current labels with the same key and without key ID")
+
+	fresh := Labels{"owner": "foo-owner", "resource": "foo-resource"}
+	encrypted := fresh.Serialize(false, true, keyring, nil)
+	suite.True(strings.HasPrefix(encrypted, keyring.ActiveKeyID()+":"), "should encrypt new labels with the active key")
+	suite.Equal(keyring.ActiveKeyID(), fresh.EncryptionKey())
+
+	decrypted, err := NewLabelsFromString(encrypted, keyring, nil)
+	suite.NoError(err)
+	suite.Equal(suite.foo["owner"], decrypted["owner"])
+	suite.Equal(encrypted, decrypted.Serialize(false, true, keyring, nil), "should encrypt the same labels identically")
+}
+
 func (suite *LabelsSuite) TestDeserialize() {
 	foo, err := NewLabelsFromStringPlain(suite.fooAsText)
 	suite.NoError(err, "should succeed for valid label text")
@@ -88,13 +116,13 @@ func (suite *LabelsSuite) TestDeserialize() {
 	suite.NoError(err, "should succeed for valid label text")
 	suite.Equal(suite.foo, foo, "should reconstruct original label map")
 
-	foo, err = NewLabelsFromString(suite.fooAsTextEncrypted, suite.aesKey)
+	foo, err = NewLabelsFromString(suite.fooAsTextEncrypted, suite.keyring, nil)
 	suite.NoError(err, "should succeed for valid encrypted label text")
 	for key, val := range suite.foo {
 		suite.Equal(val, foo[key], "should contains all keys from original label map")
 	}
 
-	foo, err = NewLabelsFromString(suite.fooAsTextWithQuotesEncrypted, suite.aesKey)
+	foo, err = NewLabelsFromString(suite.fooAsTextWithQuotesEncrypted, suite.keyring, nil)
 	suite.NoError(err, "should succeed for valid encrypted label text")
 	for key, val := range suite.foo {
 		suite.Equal(val, foo[key], "should contains all keys from original label map")
@@ -104,7 +132,7 @@ func (suite *LabelsSuite) TestDeserialize() {
 	suite.NoError(err, "should succeed for valid label text")
 	suite.Equal(suite.barTextAsMap, bar, "should reconstruct original label map")
 
-	bar, err = NewLabelsFromString(suite.barText, suite.aesKey)
+	bar, err = NewLabelsFromString(suite.barText, suite.keyring, nil)
 	suite.NoError(err, "should succeed for valid encrypted label text")
 	suite.Equal(suite.barTextAsMap, bar, "should reconstruct original label map")
 
@@ -121,6 +149,60 @@ func (suite *LabelsSuite) TestDeserialize() {
 	suite.Nil(multipleHeritage, "if error should return nil")
 }
 
+func (suite *LabelsSuite) TestSignature() {
+	signingKey := []byte("a8Qd3v7Wm2Zr5Tk9Xp1Lc6Hf4Jn0Bs8G")
+	oldKey := []byte("Yt2Rw6Ue9Io3Pa7Sd1Fg5Hj8Kl4Zx0Cv")
+
+	signed := suite.foo.Serialize(true, false, nil, signingKey)
+	suite.Equal(`"heritage=external-dns,external-dns/owner=foo-owner,external-dns/resource=foo-resource,external-dns/txt-signature=8d21c8a115e68b1057b9909d956e2687"`, signed, "should append the signature")
+	foo, err := NewLabelsFromString(signed, nil, [][]byte{signingKey})
+	suite.NoError(err, "should succeed for signed label text")
+	suite.Equal(suite.foo, foo, "should reconstruct original label map without the signature")
+
+	foo, err = NewLabelsFromString(signed, nil, [][]byte{oldKey, signingKey})
+	suite.NoError(err, "should succeed with any of the verification keys")
+	suite.Equal(suite.foo, foo)
+
+	foo, err = NewLabelsFromString(signed, nil, nil)
+	suite.NoError(err, "should not verify the signature without verification keys")
+	suite.Equal(suite.foo, foo, "should drop the signature")
+
+	_, err = NewLabelsFromString(signed, nil, [][]byte{oldKey})
+	suite.Equal(ErrInvalidSignature, err, "should fail for label text signed with another key")
+
+	_, err = NewLabelsFromString(suite.fooAsText, nil, [][]byte{signingKey})
+	suite.Equal(ErrMissingSignature, err, "should fail for label text without signature")
+
+	forged := strings.Replace(signed, "foo-owner", "bar-owner", 1)
+	_, err = NewLabelsFromString(forged, nil, [][]byte{signingKey})
+	suite.Equal(ErrInvalidSignature, err, "should fail for modified label text")
+
+	encrypted := suite.foo.Serialize(true, true, suite.keyring, signingKey)
+	foo, err = NewLabelsFromString(encrypted, suite.keyring, [][]byte{signingKey})
+	suite.NoError(err, "should succeed for encrypted signed label text")
+	suite.Equal(suite.foo["owner"], foo["owner"])
+	suite.Equal(suite.foo["resource"], foo["resource"])
+	suite.NotContains(foo, txtSignature)
+	suite.Equal(encrypted, foo.Serialize(true, true, suite.keyring, signingKey), "should reuse the nonce and sign the same labels identically")
+}
+
+func (suite *LabelsSuite) TestOwnerTargets() {
+	labels := NewLabels()
+	labels.SetOwnerTargets("cluster-a", Targets{"2.2.2.2", "1.1.1.1"})
+	labels.SetOwnerTargets("cluster-b", Targets{"2001:db8::1"})
+	suite.Equal("1.1.1.1;2.2.2.2", labels[OwnerTargetsLabelPrefix+"cluster-a"], "should sort the targets")
+
+	parsed, err := NewLabelsFromStringPlain(labels.SerializePlain(true))
+	suite.NoError(err, "should succeed for owner targets labels")
+	suite.Equal(map[string]Targets{
+		"cluster-a": {"1.1.1.1", "2.2.2.2"},
+		"cluster-b": {"2001:db8::1"},
+	}, parsed.OwnerTargets(), "should reconstruct the targets of each owner")
+
+	labels.SetOwnerTargets("cluster-a", nil)
+	suite.Equal(map[string]Targets{"cluster-b": {"2001:db8::1"}}, labels.OwnerTargets(), "should remove the contribution of an owner without targets")
+}
+
 func TestLabels(t *testing.T) {
 	suite.Run(t, new(LabelsSuite))
 }
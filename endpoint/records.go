@@ -0,0 +1,338 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SRVTarget is the target of an SRV record, as defined by RFC 2782: "priority weight port target"
+type SRVTarget struct {
+	Priority uint16
+	Weight   uint16
+	Port     uint16
+	Target   string
+}
+
+// ParseSRVTarget parses and validates the target of an SRV record
+func ParseSRVTarget(target string) (SRVTarget, error) {
+	fields := strings.Fields(target)
+	if len(fields) != 4 {
+		return SRVTarget{}, fmt.Errorf("invalid SRV target %q: expected \"priority weight port target\"", target)
+	}
+	var srv SRVTarget
+	var err error
+	if srv.Priority, err = parseUint16("priority", fields[0]); err != nil {
+		return SRVTarget{}, fmt.Errorf("invalid SRV target %q: %w", target, err)
+	}
+	if srv.Weight, err = parseUint16("weight", fields[1]); err != nil {
+		return SRVTarget{}, fmt.Errorf("invalid SRV target %q: %w", target, err)
+	}
+	if srv.Port, err = parseUint16("port", fields[2]); err != nil {
+		return SRVTarget{}, fmt.Errorf("invalid SRV target %q: %w", target, err)
+	}
+	if srv.Target, err = parseHost(fields[3]); err != nil {
+		return SRVTarget{}, fmt.Errorf("invalid SRV target %q: %w", target, err)
+	}
+	return srv, nil
+}
+
+// String returns the target in the format of the SRV record
+func (t SRVTarget) String() string {
+	return fmt.Sprintf("%d %d %d %s", t.Priority, t.Weight, t.Port, t.Target)
+}
+
+// MXTarget is the target of an MX record, as defined by RFC 1035: "preference exchange"
+type MXTarget struct {
+	Preference uint16
+	Exchange   string
+}
+
+// ParseMXTarget parses and validates the target of an MX record
+func ParseMXTarget(target string) (MXTarget, error) {
+	fields := strings.Fields(target)
+	if len(fields) != 2 {
+		return MXTarget{}, fmt.Errorf("invalid MX target %q: expected \"preference exchange\"", target)
+	}
+	var mx MXTarget
+	var err error
+	if mx.Preference, err = parseUint16("preference", fields[0]); err != nil {
+		return MXTarget{}, fmt.Errorf("invalid MX target %q: %w", target, err)
+	}
+	if mx.Exchange, err = parseHost(fields[1]); err != nil {
+		return MXTarget{}, fmt.Errorf("invalid MX target %q: %w", target, err)
+	}
+	return mx, nil
+}
+
+// String returns the target in the format of the MX record
+func (t MXTarget) String() string {
+	return fmt.Sprintf("%d %s", t.Preference, t.Exchange)
+}
+
+// CAATarget is the target of a CAA record, as defined by RFC 8659: "flags tag value"
+type CAATarget struct {
+	Flags uint8
+	Tag   string
+	Value string
+}
+
+// ParseCAATarget parses and validates the target of a CAA record. The value may be quoted.
+func ParseCAATarget(target string) (CAATarget, error) {
+	fields, err := splitQuoted(target)
+	if err != nil {
+		return CAATarget{}, fmt.Errorf("invalid CAA target %q: %w", target, err)
+	}
+	if len(fields) != 3 {
+		return CAATarget{}, fmt.Errorf("invalid CAA target %q: expected \"flags tag value\"", target)
+	}
+	var caa CAATarget
+	if caa.Flags, err = parseUint8("flags", fields[0]); err != nil {
+		return CAATarget{}, fmt.Errorf("invalid CAA target %q: %w", target, err)
+	}
+	caa.Tag = strings.ToLower(fields[1])
+	if caa.Tag == "" || strings.IndexFunc(caa.Tag, func(r rune) bool { return !isAlphanumeric(r) }) >= 0 {
+		return CAATarget{}, fmt.Errorf("invalid CAA target %q: tag %q must only contain letters and digits", target, fields[1])
+	}
+	caa.Value = unquote(fields[2])
+	return caa, nil
+}
+
+// String returns the target in the format of the CAA record, with a quoted value
+func (t CAATarget) String() string {
+	return fmt.Sprintf("%d %s %s", t.Flags, t.Tag, quote(t.Value))
+}
+
+// TLSATarget is the target of a TLSA record, as defined by RFC 6698: "usage selector matching-type data"
+type TLSATarget struct {
+	Usage        uint8
+	Selector     uint8
+	MatchingType uint8
+	// Data is the hex encoded certificate association data
+	Data string
+}
+
+// ParseTLSATarget parses and validates the target of a TLSA record
+func ParseTLSATarget(target string) (TLSATarget, error) {
+	fields := strings.Fields(target)
+	if len(fields) < 4 {
+		return TLSATarget{}, fmt.Errorf("invalid TLSA target %q: expected \"usage selector matching-type data\"", target)
+	}
+	var tlsa TLSATarget
+	var err error
+	if tlsa.Usage, err = parseUint8("usage", fields[0]); err != nil || tlsa.Usage > 3 {
+		return TLSATarget{}, fmt.Errorf("invalid TLSA target %q: usage must be between 0 and 3", target)
+	}
+	if tlsa.Selector, err = parseUint8("selector", fields[1]); err != nil || tlsa.Selector > 1 {
+		return TLSATarget{}, fmt.Errorf("invalid TLSA target %q: selector must be 0 or 1", target)
+	}
+	if tlsa.MatchingType, err = parseUint8("matching type", fields[2]); err != nil || tlsa.MatchingType > 2 {
+		return TLSATarget{}, fmt.Errorf("invalid TLSA target %q: matching type must be between 0 and 2", target)
+	}
+	// zone files may split the data in several groups
+	digestLengths := map[uint8]int{1: 32, 2: 64}
+	if tlsa.Data, err = parseHex("data", strings.Join(fields[3:], ""), digestLengths[tlsa.MatchingType]); err != nil {
+		return TLSATarget{}, fmt.Errorf("invalid TLSA target %q: %w", target, err)
+	}
+	return tlsa, nil
+}
+
+// String returns the target in the format of the TLSA record
+func (t TLSATarget) String() string {
+	return fmt.Sprintf("%d %d %d %s", t.Usage, t.Selector, t.MatchingType, t.Data)
+}
+
+// SSHFPTarget is the target of an SSHFP record, as defined by RFC 4255: "algorithm type fingerprint"
+type SSHFPTarget struct {
+	Algorithm uint8
+	Type      uint8
+	// Fingerprint is the hex encoded fingerprint of the key
+	Fingerprint string
+}
+
+// ParseSSHFPTarget parses and validates the target of an SSHFP record
+func ParseSSHFPTarget(target string) (SSHFPTarget, error) {
+	fields := strings.Fields(target)
+	if len(fields) < 3 {
+		return SSHFPTarget{}, fmt.Errorf("invalid SSHFP target %q: expected \"algorithm type fingerprint\"", target)
+	}
+	var sshfp SSHFPTarget
+	var err error
+	if sshfp.Algorithm, err = parseUint8("algorithm", fields[0]); err != nil || sshfp.Algorithm == 0 {
+		return SSHFPTarget{}, fmt.Errorf("invalid SSHFP target %q: algorithm must be between 1 and 255", target)
+	}
+	if sshfp.Type, err = parseUint8("type", fields[1]); err != nil || sshfp.Type == 0 {
+		return SSHFPTarget{}, fmt.Errorf("invalid SSHFP target %q: type must be between 1 and 255", target)
+	}
+	fingerprintLengths := map[uint8]int{1: 20, 2: 32}
+	if sshfp.Fingerprint, err = parseHex("fingerprint", strings.Join(fields[2:], ""), fingerprintLengths[sshfp.Type]); err != nil {
+		return SSHFPTarget{}, fmt.Errorf("invalid SSHFP target %q: %w", target, err)
+	}
+	return sshfp, nil
+}
+
+// String returns the target in the format of the SSHFP record
+func (t SSHFPTarget) String() string {
+	return fmt.Sprintf("%d %d %s", t.Algorithm, t.Type, t.Fingerprint)
+}
+
+// targetParsers parse the targets of the record types with structured targets
+var targetParsers = map[string]func(string) (fmt.Stringer, error){
+	RecordTypeSRV:   func(target string) (fmt.Stringer, error) { return ParseSRVTarget(target) },
+	RecordTypeMX:    func(target string) (fmt.Stringer, error) { return ParseMXTarget(target) },
+	RecordTypeCAA:   func(target string) (fmt.Stringer, error) { return ParseCAATarget(target) },
+	RecordTypeTLSA:  func(target string) (fmt.Stringer, error) { return ParseTLSATarget(target) },
+	RecordTypeSSHFP: func(target string) (fmt.Stringer, error) { return ParseSSHFPTarget(target) },
+	RecordTypeSVCB:  func(target string) (fmt.Stringer, error) { return ParseSVCBTarget(RecordTypeSVCB, target) },
+	RecordTypeHTTPS: func(target string) (fmt.Stringer, error) { return ParseSVCBTarget(RecordTypeHTTPS, target) },
+}
+
+// ValidateTargets returns an error if one of the targets is not valid for the record type. Only the record types
+// with structured targets, e.g. SRV, MX or CAA, are validated.
+func ValidateTargets(recordType string, targets Targets) error {
+	parse, ok := targetParsers[recordType]
+	if !ok {
+		return nil
+	}
+	for _, target := range targets {
+		if _, err := parse(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NormalizeTarget returns the canonical form of a target of the given record type, so that targets which only differ
+// in their formatting compare equal. Targets which cannot be parsed are returned unchanged.
+func NormalizeTarget(recordType, target string) string {
+	if parse, ok := targetParsers[recordType]; ok {
+		if parsed, err := parse(target); err == nil {
+			return parsed.String()
+		}
+	}
+	return target
+}
+
+// Normalized returns the targets in their canonical form for the given record type, see NormalizeTarget.
+// The targets are returned as is for record types without structured targets.
+func (t Targets) Normalized(recordType string) Targets {
+	if _, ok := targetParsers[recordType]; !ok {
+		return t
+	}
+	normalized := make(Targets, len(t))
+	for i, target := range t {
+		normalized[i] = NormalizeTarget(recordType, target)
+	}
+	return normalized
+}
+
+func parseUint16(name, value string) (uint16, error) {
+	v, err := strconv.ParseUint(value, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q is not a number between 0 and 65535", name, value)
+	}
+	return uint16(v), nil
+}
+
+// parseHost validates the host name of a structured target and returns it in its ASCII form, see ToASCIIName.
+// The root name "." is kept, it means that the service is not available (RFC 2782, RFC 7505).
+func parseHost(host string) (string, error) {
+	if host == "." {
+		return host, nil
+	}
+	name, err := ToASCIIName(host)
+	if err != nil {
+		return "", fmt.Errorf("invalid host name: %w", err)
+	}
+	return name, nil
+}
+
+func parseUint8(name, value string) (uint8, error) {
+	v, err := strconv.ParseUint(value, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q is not a number between 0 and 255", name, value)
+	}
+	return uint8(v), nil
+}
+
+// parseHex validates hex encoded data and returns it in lower case. length is the expected number of bytes, 0
+// if any length is fine.
+func parseHex(name, value string, length int) (string, error) {
+	data, err := hex.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return "", fmt.Errorf("%s %q is not hex encoded", name, value)
+	}
+	if length > 0 && len(data) != length {
+		return "", fmt.Errorf("%s %q must be %d bytes long", name, value, length)
+	}
+	return strings.ToLower(value), nil
+}
+
+// splitQuoted splits a target into whitespace separated fields, keeping quoted strings, e.g. the value of a CAA
+// record or an SVCB parameter, in a single field
+func splitQuoted(target string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	inQuote, escaped, inField := false, false, false
+	for _, r := range target {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && inQuote:
+			escaped = true
+		case r == '"':
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+			continue
+		}
+		field.WriteRune(r)
+		inField = true
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quoted string")
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// unquote removes the quotes around a value and unescapes the quotes and backslashes in it
+func unquote(value string) string {
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return value
+	}
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+}
+
+// quote quotes a value, escaping the quotes and backslashes in it
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func isAlphanumeric(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
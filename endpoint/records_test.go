@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSRVTarget(t *testing.T) {
+	srv, err := ParseSRVTarget("10  20 5060\tSIP.Example.org.")
+	require.NoError(t, err)
+	assert.Equal(t, SRVTarget{Priority: 10, Weight: 20, Port: 5060, Target: "sip.example.org"}, srv)
+	assert.Equal(t, "10 20 5060 sip.example.org", srv.String())
+
+	srv, err = ParseSRVTarget("0 0 0 .")
+	require.NoError(t, err)
+	assert.Equal(t, ".", srv.Target)
+
+	for _, target := range []string{
+		"",
+		"10 20 5060",
+		"10 20 5060 sip.example.org extra",
+		"-1 20 5060 sip.example.org",
+		"10 weight 5060 sip.example.org",
+		"10 20 65536 sip.example.org",
+		"10 20 5060 sip..example.org",
+	} {
+		_, err := ParseSRVTarget(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestParseMXTarget(t *testing.T) {
+	mx, err := ParseMXTarget("10 Mail.Example.org.")
+	require.NoError(t, err)
+	assert.Equal(t, MXTarget{Preference: 10, Exchange: "mail.example.org"}, mx)
+	assert.Equal(t, "10 mail.example.org", mx.String())
+
+	for _, target := range []string{"", "mail.example.org", "10", "ten mail.example.org", "10 mail example.org"} {
+		_, err := ParseMXTarget(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestValidateTargets(t *testing.T) {
+	assert.NoError(t, ValidateTargets(RecordTypeSRV, Targets{"0 50 80 a.example.org", "10 50 80 b.example.org"}))
+	assert.Error(t, ValidateTargets(RecordTypeSRV, Targets{"0 50 80 a.example.org", "a.example.org"}))
+	assert.NoError(t, ValidateTargets(RecordTypeMX, Targets{"10 mail.example.org"}))
+	assert.Error(t, ValidateTargets(RecordTypeMX, Targets{"mail.example.org"}))
+	assert.NoError(t, ValidateTargets(RecordTypeA, Targets{"not an address"}))
+}
+
+func TestTargetsNormalized(t *testing.T) {
+	assert.Equal(t, Targets{"0 50 80 a.example.org", "invalid"}, Targets{"0 50  80 A.example.org.", "invalid"}.Normalized(RecordTypeSRV))
+	assert.Equal(t, Targets{"10 mail.example.org"}, Targets{"10 mail.example.org."}.Normalized(RecordTypeMX))
+	assert.Equal(t, Targets{"A.example.org."}, Targets{"A.example.org."}.Normalized(RecordTypeCNAME))
+}
+
+func TestParseCAATarget(t *testing.T) {
+	caa, err := ParseCAATarget(`0 Issue "letsencrypt.org; validationmethods=dns-01"`)
+	require.NoError(t, err)
+	assert.Equal(t, CAATarget{Flags: 0, Tag: "issue", Value: "letsencrypt.org; validationmethods=dns-01"}, caa)
+	assert.Equal(t, `0 issue "letsencrypt.org; validationmethods=dns-01"`, caa.String())
+
+	caa, err = ParseCAATarget("128 iodef mailto:security@example.org")
+	require.NoError(t, err)
+	assert.Equal(t, `128 iodef "mailto:security@example.org"`, caa.String())
+
+	for _, target := range []string{"", "0 issue", "256 issue letsencrypt.org", "0 is-sue letsencrypt.org", `0 issue "letsencrypt.org`} {
+		_, err := ParseCAATarget(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestParseTLSATarget(t *testing.T) {
+	digest := "0C72AC70B745AC19998811B131D662C9AC69DBDBE7CB23E5B514B56664C5D3D6"
+	tlsa, err := ParseTLSATarget("3 1 1 " + digest[:32] + " " + digest[32:])
+	require.NoError(t, err)
+	assert.Equal(t, "3 1 1 0c72ac70b745ac19998811b131d662c9ac69dbdbe7cb23e5b514b56664c5d3d6", tlsa.String())
+
+	for _, target := range []string{"3 1 1", "4 1 1 " + digest, "3 2 1 " + digest, "3 1 3 " + digest, "3 1 2 " + digest, "3 1 1 xyz"} {
+		_, err := ParseTLSATarget(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestParseSSHFPTarget(t *testing.T) {
+	sshfp, err := ParseSSHFPTarget("4 2 123456789ABCDEF67890123456789ABCDEF67890123456789ABCDEF123456789")
+	require.NoError(t, err)
+	assert.Equal(t, SSHFPTarget{Algorithm: 4, Type: 2, Fingerprint: "123456789abcdef67890123456789abcdef67890123456789abcdef123456789"}, sshfp)
+
+	for _, target := range []string{"4 2", "0 2 1234", "4 0 1234", "4 1 1234", "4 2 not-hex"} {
+		_, err := ParseSSHFPTarget(target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestParseSVCBTarget(t *testing.T) {
+	svcb, err := ParseSVCBTarget(RecordTypeHTTPS, `1 . ipv6hint=2001:DB8:0::1 alpn="h2,h3" port=443 mandatory=port,alpn no-default-alpn`)
+	require.NoError(t, err)
+	assert.Equal(t, uint16(1), svcb.Priority)
+	assert.Equal(t, ".", svcb.Target)
+	assert.Equal(t, "1 . mandatory=alpn,port alpn=h2,h3 no-default-alpn port=443 ipv6hint=2001:db8::1", svcb.String())
+
+	svcb, err = ParseSVCBTarget(RecordTypeSVCB, "0 Pool.Example.org.")
+	require.NoError(t, err)
+	assert.Equal(t, "0 pool.example.org", svcb.String())
+
+	svcb, err = ParseSVCBTarget(RecordTypeSVCB, `16 svc.example.org key65333="a b"`)
+	require.NoError(t, err)
+	assert.Equal(t, `16 svc.example.org key65333="a b"`, svcb.String())
+
+	for _, target := range []string{
+		"1",
+		"0 . alpn=h2",
+		"1 . alpn=h2 alpn=h3",
+		"1 . unknown=1",
+		"1 . port=http",
+		"1 . ipv4hint=2001:db8::1",
+		"1 . ipv6hint=192.0.2.1",
+		"1 . no-default-alpn=h2",
+		"1 . alpn",
+		"1 . mandatory=mandatory",
+	} {
+		_, err := ParseSVCBTarget(RecordTypeSVCB, target)
+		assert.Error(t, err, target)
+	}
+}
+
+func TestNormalizeTarget(t *testing.T) {
+	assert.Equal(t, `0 issue "letsencrypt.org"`, NormalizeTarget(RecordTypeCAA, "0 ISSUE letsencrypt.org"))
+	assert.Equal(t, "1 . alpn=h2", NormalizeTarget(RecordTypeHTTPS, `1 . alpn="h2"`))
+	assert.Equal(t, "not a target", NormalizeTarget(RecordTypeHTTPS, "not a target"))
+	assert.Equal(t, "1.2.3.4", NormalizeTarget(RecordTypeA, "1.2.3.4"))
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// svcbParamKeys are the numbers of the SvcParamKeys registered by RFC 9460 and its extensions, which define the
+// canonical order of the parameters
+var svcbParamKeys = map[string]uint16{
+	"mandatory":       0,
+	"alpn":            1,
+	"no-default-alpn": 2,
+	"port":            3,
+	"ipv4hint":        4,
+	"ech":             5,
+	"ipv6hint":        6,
+	"dohpath":         7,
+	"ohttp":           8,
+}
+
+// SVCBParam is a SvcParam of an SVCB or HTTPS record. Value is empty for keys without value, e.g. no-default-alpn.
+type SVCBParam struct {
+	Key   string
+	Value string
+}
+
+// String returns the parameter in the presentation format, quoting the value if needed
+func (p SVCBParam) String() string {
+	if p.Value == "" {
+		return p.Key
+	}
+	if strings.ContainsAny(p.Value, " \t\"\\") {
+		return p.Key + "=" + quote(p.Value)
+	}
+	return p.Key + "=" + p.Value
+}
+
+// SVCBTarget is the target of an SVCB or HTTPS record, as defined by RFC 9460: "priority target [params...]".
+// A priority of 0 is the AliasMode, which does not take parameters.
+type SVCBTarget struct {
+	Priority uint16
+	Target   string
+	Params   []SVCBParam
+}
+
+// ParseSVCBTarget parses and validates the target of an SVCB or HTTPS record, given by recordType. The parameters
+// are returned in their canonical order.
+func ParseSVCBTarget(recordType, target string) (SVCBTarget, error) {
+	fields, err := splitQuoted(target)
+	if err != nil {
+		return SVCBTarget{}, fmt.Errorf("invalid %s target %q: %w", recordType, target, err)
+	}
+	if len(fields) < 2 {
+		return SVCBTarget{}, fmt.Errorf("invalid %s target %q: expected \"priority target [params...]\"", recordType, target)
+	}
+	var svcb SVCBTarget
+	if svcb.Priority, err = parseUint16("priority", fields[0]); err != nil {
+		return SVCBTarget{}, fmt.Errorf("invalid %s target %q: %w", recordType, target, err)
+	}
+	if svcb.Target, err = parseHost(fields[1]); err != nil {
+		return SVCBTarget{}, fmt.Errorf("invalid %s target %q: %w", recordType, target, err)
+	}
+	if svcb.Priority == 0 && len(fields) > 2 {
+		return SVCBTarget{}, fmt.Errorf("invalid %s target %q: parameters are not allowed with priority 0 (AliasMode)", recordType, target)
+	}
+
+	seen := map[string]bool{}
+	for _, field := range fields[2:] {
+		key, value, _ := strings.Cut(field, "=")
+		param := SVCBParam{Key: strings.ToLower(key), Value: unquote(value)}
+		if seen[param.Key] {
+			return SVCBTarget{}, fmt.Errorf("invalid %s target %q: parameter %q is repeated", recordType, target, param.Key)
+		}
+		seen[param.Key] = true
+		if param.Value, err = validateSVCBParam(param); err != nil {
+			return SVCBTarget{}, fmt.Errorf("invalid %s target %q: %w", recordType, target, err)
+		}
+		svcb.Params = append(svcb.Params, param)
+	}
+	sort.SliceStable(svcb.Params, func(i, j int) bool {
+		ki, _ := svcbParamKey(svcb.Params[i].Key)
+		kj, _ := svcbParamKey(svcb.Params[j].Key)
+		return ki < kj
+	})
+	return svcb, nil
+}
+
+// String returns the target in the format of the SVCB and HTTPS records
+func (t SVCBTarget) String() string {
+	s := fmt.Sprintf("%d %s", t.Priority, t.Target)
+	for _, p := range t.Params {
+		s += " " + p.String()
+	}
+	return s
+}
+
+// svcbParamKey returns the number of a registered key or of a key in the generic keyNNNNN form
+func svcbParamKey(key string) (uint16, bool) {
+	if n, ok := svcbParamKeys[key]; ok {
+		return n, true
+	}
+	if !strings.HasPrefix(key, "key") {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(key, "key"), 10, 16)
+	return uint16(n), err == nil
+}
+
+// validateSVCBParam validates the value of a parameter and returns it in its canonical form
+func validateSVCBParam(p SVCBParam) (string, error) {
+	if _, ok := svcbParamKey(p.Key); !ok {
+		return "", fmt.Errorf("unknown parameter %q", p.Key)
+	}
+
+	switch p.Key {
+	case "no-default-alpn":
+		if p.Value != "" {
+			return "", fmt.Errorf("parameter %q does not take a value", p.Key)
+		}
+		return "", nil
+	case "port":
+		port, err := parseUint16("port", p.Value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(port)), nil
+	case "ipv4hint", "ipv6hint":
+		var addresses []string
+		for _, address := range strings.Split(p.Value, ",") {
+			ip := net.ParseIP(address)
+			if ip == nil || (p.Key == "ipv4hint") != (ip.To4() != nil) {
+				return "", fmt.Errorf("parameter %q has an invalid address %q", p.Key, address)
+			}
+			addresses = append(addresses, ip.String())
+		}
+		return strings.Join(addresses, ","), nil
+	case "mandatory":
+		var keys []string
+		for _, key := range strings.Split(strings.ToLower(p.Value), ",") {
+			if _, ok := svcbParamKey(key); !ok || key == "mandatory" {
+				return "", fmt.Errorf("parameter %q has an invalid key %q", p.Key, key)
+			}
+			keys = append(keys, key)
+		}
+		sort.SliceStable(keys, func(i, j int) bool {
+			ki, _ := svcbParamKey(keys[i])
+			kj, _ := svcbParamKey(keys[j])
+			return ki < kj
+		})
+		return strings.Join(keys, ","), nil
+	case "alpn", "ech", "dohpath":
+		if p.Value == "" {
+			return "", fmt.Errorf("parameter %q requires a value", p.Key)
+		}
+	}
+	return p.Value, nil
+}
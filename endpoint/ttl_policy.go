@@ -0,0 +1,132 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TTLRule sets the TTL of the records at and below a domain
+type TTLRule struct {
+	Domain string
+	TTL    TTL
+}
+
+// TTLPolicy decides the TTL of the endpoints of the sources, so that the planner compares the TTLs which are
+// published rather than leaving them to the defaults and limits of each provider. An endpoint without TTL gets
+// the TTL of the most specific rule matching its DNS name, else the default of its record type, else Default.
+// Configured TTLs are then clamped to Min and Max. Zero values are not applied.
+type TTLPolicy struct {
+	Default            TTL
+	RecordTypeDefaults map[string]TTL
+	Rules              []TTLRule
+	Min                TTL
+	Max                TTL
+}
+
+// ParseTTL parses a TTL given in seconds, e.g. "300", or as a duration, e.g. "5m"
+func ParseTTL(s string) (TTL, error) {
+	if seconds, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return TTL(seconds), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%q is neither a number of seconds nor a duration", s)
+	}
+	return TTL(d.Seconds()), nil
+}
+
+// ParseTTLRules parses rules given as "domain=ttl", e.g. "internal.example.org=30s"
+func ParseTTLRules(rules []string) ([]TTLRule, error) {
+	var parsed []TTLRule
+	for _, rule := range rules {
+		domain, value, found := strings.Cut(rule, "=")
+		domain = strings.ToLower(strings.Trim(strings.TrimSpace(domain), "."))
+		if !found || domain == "" {
+			return nil, fmt.Errorf("invalid TTL rule %q: expected \"domain=ttl\"", rule)
+		}
+		ttl, err := ParseTTL(strings.TrimSpace(value))
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("invalid TTL rule %q: the TTL %q is invalid", rule, value)
+		}
+		parsed = append(parsed, TTLRule{Domain: asciiName(domain), TTL: ttl})
+	}
+	return parsed, nil
+}
+
+// ParseRecordTypeTTLs parses default TTLs of record types given as "type=ttl", e.g. "MX=1h"
+func ParseRecordTypeTTLs(values []string) (map[string]TTL, error) {
+	parsed := map[string]TTL{}
+	for _, v := range values {
+		recordType, value, found := strings.Cut(v, "=")
+		recordType = strings.ToUpper(strings.TrimSpace(recordType))
+		if !found || recordType == "" {
+			return nil, fmt.Errorf("invalid record type TTL %q: expected \"type=ttl\"", v)
+		}
+		ttl, err := ParseTTL(strings.TrimSpace(value))
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("invalid record type TTL %q: the TTL %q is invalid", v, value)
+		}
+		parsed[recordType] = ttl
+	}
+	return parsed, nil
+}
+
+// TTL returns the TTL the policy gives to the endpoint
+func (p *TTLPolicy) TTL(ep *Endpoint) TTL {
+	ttl := ep.RecordTTL
+	if !ttl.IsConfigured() {
+		ttl = p.defaultTTL(ep)
+	}
+	if !ttl.IsConfigured() {
+		return ttl
+	}
+	if p.Min.IsConfigured() && ttl < p.Min {
+		ttl = p.Min
+	}
+	if p.Max.IsConfigured() && ttl > p.Max {
+		ttl = p.Max
+	}
+	return ttl
+}
+
+// Apply sets the TTL of the endpoints, see TTL
+func (p *TTLPolicy) Apply(endpoints []*Endpoint) {
+	for _, ep := range endpoints {
+		ep.RecordTTL = p.TTL(ep)
+	}
+}
+
+func (p *TTLPolicy) defaultTTL(ep *Endpoint) TTL {
+	name := strings.ToLower(strings.TrimSuffix(ep.DNSName, "."))
+	var match *TTLRule
+	for i, rule := range p.Rules {
+		if (name == rule.Domain || strings.HasSuffix(name, "."+rule.Domain)) && (match == nil || len(rule.Domain) > len(match.Domain)) {
+			match = &p.Rules[i]
+		}
+	}
+	if match != nil {
+		return match.TTL
+	}
+	if ttl, ok := p.RecordTypeDefaults[ep.RecordType]; ok {
+		return ttl
+	}
+	return p.Default
+}
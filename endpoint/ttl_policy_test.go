@@ -0,0 +1,89 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTTL(t *testing.T) {
+	for s, expected := range map[string]TTL{"300": 300, "5m": 300, "1h30m": 5400, "1.5s": 1} {
+		ttl, err := ParseTTL(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, ttl, s)
+	}
+	_, err := ParseTTL("five minutes")
+	assert.Error(t, err)
+}
+
+func TestParseTTLRules(t *testing.T) {
+	rules, err := ParseTTLRules([]string{"Internal.Example.org.=30s", "bücher.example.org=3600"})
+	require.NoError(t, err)
+	assert.Equal(t, []TTLRule{{Domain: "internal.example.org", TTL: 30}, {Domain: "xn--bcher-kva.example.org", TTL: 3600}}, rules)
+
+	for _, rule := range []string{"example.org", "=300", "example.org=soon", "example.org=-1"} {
+		_, err := ParseTTLRules([]string{rule})
+		assert.Error(t, err, rule)
+	}
+}
+
+func TestParseRecordTypeTTLs(t *testing.T) {
+	ttls, err := ParseRecordTypeTTLs([]string{"mx=1h", "TXT=60"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]TTL{RecordTypeMX: 3600, RecordTypeTXT: 60}, ttls)
+
+	_, err = ParseRecordTypeTTLs([]string{"MX"})
+	assert.Error(t, err)
+}
+
+func TestTTLPolicy(t *testing.T) {
+	policy := &TTLPolicy{
+		Default:            300,
+		RecordTypeDefaults: map[string]TTL{RecordTypeMX: 3600},
+		Rules: []TTLRule{
+			{Domain: "example.org", TTL: 600},
+			{Domain: "internal.example.org", TTL: 30},
+		},
+		Min: 60,
+		Max: 86400,
+	}
+
+	for _, tc := range []struct {
+		name     string
+		endpoint *Endpoint
+		expected TTL
+	}{
+		{"configured TTL", NewEndpointWithTTL("app.example.com", RecordTypeA, 120, "1.2.3.4"), 120},
+		{"configured TTL below the minimum", NewEndpointWithTTL("app.example.com", RecordTypeA, 10, "1.2.3.4"), 60},
+		{"configured TTL above the maximum", NewEndpointWithTTL("app.example.com", RecordTypeA, 604800, "1.2.3.4"), 86400},
+		{"global default", NewEndpoint("app.example.com", RecordTypeA, "1.2.3.4"), 300},
+		{"record type default", NewEndpoint("example.com", RecordTypeMX, "10 mail.example.com"), 3600},
+		{"domain rule", NewEndpoint("app.example.org", RecordTypeA, "1.2.3.4"), 600},
+		{"domain rule at the apex", NewEndpoint("example.org", RecordTypeMX, "10 mail.example.org"), 600},
+		{"most specific domain rule clamped", NewEndpoint("db.internal.example.org", RecordTypeA, "1.2.3.4"), 60},
+		{"label boundary", NewEndpoint("app.myexample.org", RecordTypeA, "1.2.3.4"), 300},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, policy.TTL(tc.endpoint))
+		})
+	}
+
+	assert.Equal(t, TTL(0), (&TTLPolicy{Min: 60}).TTL(NewEndpoint("app.example.com", RecordTypeA, "1.2.3.4")))
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// ValidationReason is the reason an endpoint is refused by Validate
+type ValidationReason string
+
+const (
+	ValidationReasonInvalidName             ValidationReason = "invalid-name"
+	ValidationReasonInvalidTarget           ValidationReason = "invalid-target"
+	ValidationReasonInvalidTTL              ValidationReason = "invalid-ttl"
+	ValidationReasonInvalidProviderSpecific ValidationReason = "invalid-provider-specific"
+)
+
+// MaxTTL is the largest TTL a record can have, see RFC 2181 section 8
+const MaxTTL TTL = math.MaxInt32
+
+// ValidationError is returned by Validate for an invalid endpoint
+type ValidationError struct {
+	Endpoint *Endpoint
+	Reason   ValidationReason
+	Err      error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// providerSpecificSchema validates the values of the provider specific properties with a known format.
+// Properties which are not listed are not validated.
+var providerSpecificSchema = map[string]func(value string) error{
+	"alias": validateBool,
+	"external-dns.alpha.kubernetes.io/cloudflare-proxied": validateBool,
+	"aws/evaluate-target-health":                          validateBool,
+	"aws/weight":                                          validateRange(0, 255),
+	"aws/failover":                                        validateOneOf("PRIMARY", "SECONDARY"),
+}
+
+// Validate returns a *ValidationError if the endpoint cannot be published: its DNS name is not valid for its record
+// type (see ValidateName), it has no targets or a target which is not valid for the record type, its TTL is negative
+// or larger than MaxTTL, or a provider specific property has no name or an invalid value.
+func Validate(ep *Endpoint) error {
+	if err := ValidateName(ep.RecordType, ep.DNSName); err != nil {
+		return &ValidationError{Endpoint: ep, Reason: ValidationReasonInvalidName, Err: err}
+	}
+	if err := validateEndpointTargets(ep); err != nil {
+		return &ValidationError{Endpoint: ep, Reason: ValidationReasonInvalidTarget, Err: err}
+	}
+	if ep.RecordTTL < 0 || ep.RecordTTL > MaxTTL {
+		return &ValidationError{Endpoint: ep, Reason: ValidationReasonInvalidTTL, Err: fmt.Errorf("TTL %d is not between 0 and %d", ep.RecordTTL, MaxTTL)}
+	}
+	for _, property := range ep.ProviderSpecific {
+		if err := validateProviderSpecificProperty(property); err != nil {
+			return &ValidationError{Endpoint: ep, Reason: ValidationReasonInvalidProviderSpecific, Err: err}
+		}
+	}
+	return nil
+}
+
+// ValidateName validates the DNS name of a record of the given type. The names of TXT records are not host names,
+// e.g. the ownership records of wildcards, so only their length is validated; other names must be valid host names,
+// see ToASCIIName.
+func ValidateName(recordType, name string) error {
+	if _, err := ToASCIIName(name); err == nil || recordType != RecordTypeTXT {
+		return err
+	}
+	ascii := strings.TrimSuffix(name, ".")
+	if converted, err := idnaProfile.ToASCII(ascii); err == nil {
+		ascii = converted
+	}
+	if ascii == "" || len(ascii) > 253 {
+		return fmt.Errorf("DNS name %q is empty or longer than 253 octets", name)
+	}
+	for _, label := range strings.Split(ascii, ".") {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("DNS name %q has a label which is empty or longer than 63 octets", name)
+		}
+	}
+	return nil
+}
+
+func validateEndpointTargets(ep *Endpoint) error {
+	recordType, targets := ep.RecordType, ep.Targets
+	if len(targets) == 0 {
+		return fmt.Errorf("the list of targets is empty")
+	}
+	// the targets of alias records, e.g. of AWS, are host names whatever the record type
+	if (recordType == RecordTypeA || recordType == RecordTypeAAAA) && isAlias(ep) {
+		return nil
+	}
+	for _, target := range targets {
+		switch recordType {
+		case RecordTypeA:
+			if ip, err := netip.ParseAddr(target); err != nil || !ip.Is4() {
+				return fmt.Errorf("target %q is not an IPv4 address", target)
+			}
+		case RecordTypeAAAA:
+			if ip, err := netip.ParseAddr(target); err != nil || !ip.Is6() {
+				return fmt.Errorf("target %q is not an IPv6 address", target)
+			}
+		case RecordTypeCNAME, RecordTypeNS, RecordTypePTR:
+			if strings.HasSuffix(target, ".") {
+				return fmt.Errorf("target %q must not end with a dot", target)
+			}
+			if _, err := ToASCIIName(target); err != nil {
+				return fmt.Errorf("invalid target: %w", err)
+			}
+		case RecordTypeNAPTR:
+			if !strings.HasSuffix(target, ".") {
+				return fmt.Errorf("target %q must end with a dot", target)
+			}
+		}
+	}
+	return ValidateTargets(recordType, targets)
+}
+
+func isAlias(ep *Endpoint) bool {
+	value, ok := ep.GetProviderSpecificProperty("alias")
+	alias, err := strconv.ParseBool(value)
+	return ok && err == nil && alias
+}
+
+func validateProviderSpecificProperty(property ProviderSpecificProperty) error {
+	if property.Name == "" {
+		return fmt.Errorf("provider specific property with value %q has no name", property.Value)
+	}
+	if validate, ok := providerSpecificSchema[property.Name]; ok {
+		if err := validate(property.Value); err != nil {
+			return fmt.Errorf("provider specific property %s: %w", property.Name, err)
+		}
+	}
+	return nil
+}
+
+func validateBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("%q is not a boolean", value)
+	}
+	return nil
+}
+
+func validateRange(minimum, maximum int64) func(string) error {
+	return func(value string) error {
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || v < minimum || v > maximum {
+			return fmt.Errorf("%q is not a number between %d and %d", value, minimum, maximum)
+		}
+		return nil
+	}
+}
+
+func validateOneOf(values ...string) func(string) error {
+	return func(value string) error {
+		for _, v := range values {
+			if value == v {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(values, ", "))
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package endpoint
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		endpoint *Endpoint
+		reason   ValidationReason
+	}{
+		{"valid A", NewEndpointWithTTL("www.example.org", RecordTypeA, 300, "1.2.3.4"), ""},
+		{"valid AAAA", NewEndpoint("www.example.org", RecordTypeAAAA, "2001:db8::1"), ""},
+		{"valid CNAME", NewEndpoint("www.example.org", RecordTypeCNAME, "lb.example.com"), ""},
+		{"valid SRV", NewEndpoint("_sip._tcp.example.org", RecordTypeSRV, "10 20 5060 sip.example.org"), ""},
+		{"valid wildcard", NewEndpoint("*.example.org", RecordTypeA, "1.2.3.4"), ""},
+		{"valid wildcard TXT", NewEndpoint("a-*.example.org", RecordTypeTXT, "heritage=external-dns"), ""},
+		{"valid properties", NewEndpoint("www.example.org", RecordTypeCNAME, "lb.example.com").
+			WithProviderSpecific("alias", "true").
+			WithProviderSpecific("aws/weight", "100").
+			WithProviderSpecific("aws/failover", "PRIMARY").
+			WithProviderSpecific("unknown", "anything"), ""},
+		{"valid alias A", NewEndpoint("www.example.org", RecordTypeA, "lb-1234.eu-central-1.elb.amazonaws.com").WithProviderSpecific("alias", "true"), ""},
+		{"alias A without targets", NewEndpoint("www.example.org", RecordTypeA).WithProviderSpecific("alias", "true"), ValidationReasonInvalidTarget},
+		{"A with a host name", NewEndpoint("www.example.org", RecordTypeA, "lb-1234.eu-central-1.elb.amazonaws.com").WithProviderSpecific("alias", "false"), ValidationReasonInvalidTarget},
+		{"long label", NewEndpoint(strings.Repeat("a", 64)+".example.org", RecordTypeA, "1.2.3.4"), ValidationReasonInvalidName},
+		{"long TXT label", NewEndpoint("a-"+strings.Repeat("a", 63)+".example.org", RecordTypeTXT, "text"), ValidationReasonInvalidName},
+		{"wildcard in the middle", NewEndpoint("www.*.example.org", RecordTypeA, "1.2.3.4"), ValidationReasonInvalidName},
+		{"no targets", NewEndpoint("www.example.org", RecordTypeA), ValidationReasonInvalidTarget},
+		{"A with IPv6", NewEndpoint("www.example.org", RecordTypeA, "2001:db8::1"), ValidationReasonInvalidTarget},
+		{"AAAA with IPv4", NewEndpoint("www.example.org", RecordTypeAAAA, "1.2.3.4"), ValidationReasonInvalidTarget},
+		{"CNAME with trailing dot", &Endpoint{DNSName: "www.example.org", RecordType: RecordTypeCNAME, Targets: Targets{"lb.example.com."}}, ValidationReasonInvalidTarget},
+		{"CNAME to an invalid name", NewEndpoint("www.example.org", RecordTypeCNAME, "lb..example.com"), ValidationReasonInvalidTarget},
+		{"NAPTR without trailing dot", &Endpoint{DNSName: "example.org", RecordType: RecordTypeNAPTR, Targets: Targets{`100 10 "S" "SIP+D2U" "" _sip._udp.example.org`}}, ValidationReasonInvalidTarget},
+		{"invalid SRV", NewEndpoint("_sip._tcp.example.org", RecordTypeSRV, "10 5060 sip.example.org"), ValidationReasonInvalidTarget},
+		{"negative TTL", NewEndpointWithTTL("www.example.org", RecordTypeA, -1, "1.2.3.4"), ValidationReasonInvalidTTL},
+		{"TTL too large", NewEndpointWithTTL("www.example.org", RecordTypeA, MaxTTL+1, "1.2.3.4"), ValidationReasonInvalidTTL},
+		{"unnamed property", NewEndpoint("www.example.org", RecordTypeA, "1.2.3.4").WithProviderSpecific("", "true"), ValidationReasonInvalidProviderSpecific},
+		{"invalid proxied", NewEndpoint("www.example.org", RecordTypeA, "1.2.3.4").WithProviderSpecific("external-dns.alpha.kubernetes.io/cloudflare-proxied", "yes"), ValidationReasonInvalidProviderSpecific},
+		{"invalid weight", NewEndpoint("www.example.org", RecordTypeA, "1.2.3.4").WithProviderSpecific("aws/weight", "256"), ValidationReasonInvalidProviderSpecific},
+		{"invalid failover", NewEndpoint("www.example.org", RecordTypeA, "1.2.3.4").WithProviderSpecific("aws/failover", "TERTIARY"), ValidationReasonInvalidProviderSpecific},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.endpoint)
+			if tc.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr), "expected a ValidationError, got %v", err)
+			assert.Equal(t, tc.reason, validationErr.Reason)
+			assert.Same(t, tc.endpoint, validationErr.Endpoint)
+		})
+	}
+}
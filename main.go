@@ -18,10 +18,13 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -33,14 +36,20 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	k8sleaderelection "k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/external-dns/controller"
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns/validation"
+	"sigs.k8s.io/external-dns/pkg/events"
+	"sigs.k8s.io/external-dns/pkg/leaderelection"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
 	"sigs.k8s.io/external-dns/provider/akamai"
@@ -158,10 +167,11 @@ func main() {
 		ResolveLoadBalancerHostname:    cfg.ResolveServiceLoadBalancerHostname,
 		TraefikDisableLegacy:           cfg.TraefikDisableLegacy,
 		TraefikDisableNew:              cfg.TraefikDisableNew,
+		// only the oldest and priority conflict resolvers rely on these labels, which are stored with the records
+		ConflictResolutionLabels: cfg.ConflictResolver == "oldest" || cfg.ConflictResolver == "priority",
 	}
 
-	// Lookup all the selected sources by names and pass them the desired configuration.
-	sources, err := source.ByNames(ctx, &source.SingletonClientGenerator{
+	clientGenerator := &source.SingletonClientGenerator{
 		KubeConfig:   cfg.KubeConfig,
 		APIServerURL: cfg.APIServerURL,
 		// If update events are enabled, disable timeout.
@@ -171,7 +181,10 @@ func main() {
 			}
 			return cfg.RequestTimeout
 		}(),
-	}, cfg.Sources, sourceCfg)
+	}
+
+	// Lookup all the selected sources by names and pass them the desired configuration.
+	sources, err := source.ByNames(ctx, clientGenerator, cfg.Sources, sourceCfg)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -183,6 +196,13 @@ func main() {
 	endpointsSource := source.NewDedupSource(source.NewMultiSource(sources, sourceCfg.DefaultTargets))
 	endpointsSource = source.NewTargetFilterSource(endpointsSource, targetFilter)
 
+	// Apply the TTL policy to the endpoints before they are planned
+	ttlPolicy, err := newTTLPolicy(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	endpointsSource = source.NewTTLSource(endpointsSource, ttlPolicy)
+
 	// RegexDomainFilter overrides DomainFilter
 	var domainFilter endpoint.DomainFilter
 	if cfg.RegexDomainFilter.String() != "" {
@@ -196,7 +216,7 @@ func main() {
 	zoneTagFilter := provider.NewZoneTagFilter(cfg.AWSZoneTagFilter)
 
 	var awsSession *session.Session
-	if cfg.Provider == "aws" || cfg.Provider == "aws-sd" || cfg.Registry == "dynamodb" {
+	if cfg.Provider == "aws" || cfg.Provider == "aws-sd" || cfg.Registry == "dynamodb" || cfg.MigrateRegistryFrom == "dynamodb" || cfg.MigrateRegistryTo == "dynamodb" {
 		awsSession, err = aws.NewSession(
 			aws.AWSSessionConfig{
 				AssumeRole:           cfg.AWSAssumeRole,
@@ -431,24 +451,14 @@ func main() {
 		os.Exit(0)
 	}
 
-	var r registry.Registry
-	switch cfg.Registry {
-	case "dynamodb":
-		config := awsSDK.NewConfig()
-		if cfg.AWSDynamoDBRegion != "" {
-			config = config.WithRegion(cfg.AWSDynamoDBRegion)
+	if cfg.MigrateRegistryFrom != "" {
+		if err := migrateRegistry(ctx, cfg, p, clientGenerator, awsSession); err != nil {
+			log.Fatal(err)
 		}
-		r, err = registry.NewDynamoDBRegistry(p, cfg.TXTOwnerID, dynamodb.New(awsSession, config), cfg.AWSDynamoDBTable, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, []byte(cfg.TXTEncryptAESKey), cfg.TXTCacheInterval)
-	case "noop":
-		r, err = registry.NewNoopRegistry(p)
-	case "txt":
-		r, err = registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, cfg.TXTEncryptEnabled, []byte(cfg.TXTEncryptAESKey))
-	case "aws-sd":
-		r, err = registry.NewAWSSDRegistry(p.(*awssd.AWSSDProvider), cfg.TXTOwnerID)
-	default:
-		log.Fatalf("unknown registry: %s", cfg.Registry)
+		os.Exit(0)
 	}
 
+	r, err := newRegistry(cfg.Registry, cfg, p, clientGenerator, awsSession)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -458,15 +468,65 @@ func main() {
 		log.Fatalf("unknown policy: %s", cfg.Policy)
 	}
 
+	conflictResolver, exists := plan.ConflictResolvers[cfg.ConflictResolver]
+	if !exists {
+		log.Fatalf("unknown conflict resolver: %s", cfg.ConflictResolver)
+	}
+
 	ctrl := controller.Controller{
-		Source:               endpointsSource,
-		Registry:             r,
-		Policy:               policy,
-		Interval:             cfg.Interval,
-		DomainFilter:         domainFilter,
-		ManagedRecordTypes:   cfg.ManagedDNSRecordTypes,
-		ExcludeRecordTypes:   cfg.ExcludeDNSRecordTypes,
-		MinEventSyncInterval: cfg.MinEventSyncInterval,
+		Source:                  endpointsSource,
+		Registry:                r,
+		Policy:                  policy,
+		ConflictResolver:        conflictResolver,
+		AllowApexCNAME:          cfg.AllowApexCNAME,
+		Interval:                cfg.Interval,
+		DomainFilter:            domainFilter,
+		ManagedRecordTypes:      cfg.ManagedDNSRecordTypes,
+		ExcludeRecordTypes:      cfg.ExcludeDNSRecordTypes,
+		MinEventSyncInterval:    cfg.MinEventSyncInterval,
+		ZoneConcurrency:         cfg.ZoneConcurrency,
+		BackoffInitialInterval:  cfg.BackoffInitialInterval,
+		BackoffMaxInterval:      cfg.BackoffMaxInterval,
+		CircuitBreakerThreshold: cfg.CircuitBreakerThreshold,
+		CircuitBreakerCooldown:  cfg.CircuitBreakerCooldown,
+		MaxDeletes:              cfg.MaxDeletes,
+		MaxDeletesPercent:       cfg.MaxDeletesPercent,
+		MaxChanges:              cfg.MaxChanges,
+		TruncateChanges:         cfg.ChangeLimitAction == "truncate",
+		DeletionGracePeriod:     cfg.DeletionGracePeriod,
+		PlanOutput:              cfg.PlanOutput,
+	}
+
+	if cfg.DebugDecisions {
+		http.Handle("/debug/decisions", ctrl.DecisionsHandler())
+	}
+	if cfg.AdminAddress != "" {
+		go serveAdmin(cfg.AdminAddress, ctrl.AdminHandler(cfg.AdminToken))
+	}
+
+	ctrl.AliasFlattener = provider.NewAliasFlattener(p, net.DefaultResolver)
+
+	if zp, ok := p.(provider.ZoneProvider); ok {
+		ctrl.ZoneProvider = zp
+	} else if cfg.ZoneConcurrency > 0 {
+		log.Fatalf("Provider %q does not support applying changes per zone, --zone-concurrency is only supported with the aws, azure, azure-private-dns, cloudflare, google, inmemory, pdns and rfc2136 providers", cfg.Provider)
+	}
+
+	if !cfg.DryRun {
+		for _, src := range sources {
+			if recorder, ok := src.(controller.StatusRecorder); ok {
+				ctrl.StatusRecorders = append(ctrl.StatusRecorders, recorder)
+			}
+		}
+	}
+
+	if cfg.EmitEvents && !cfg.DryRun {
+		recorder, err := newEventRecorder(cfg, clientGenerator)
+		if err != nil {
+			log.Fatalf("failed to configure event recording: %v", err)
+		}
+		defer recorder.Shutdown()
+		ctrl.EventRecorder = recorder
 	}
 
 	if cfg.Once {
@@ -485,8 +545,189 @@ func main() {
 		ctrl.Source.AddEventHandler(ctx, func() { ctrl.ScheduleRunOnce(time.Now()) })
 	}
 
-	ctrl.ScheduleRunOnce(time.Now())
-	ctrl.Run(ctx)
+	if !cfg.LeaderElection {
+		ctrl.ScheduleRunOnce(time.Now())
+		ctrl.Run(ctx)
+		return
+	}
+
+	// sources and their informers are already running, so a standby replica takes over with warm caches
+	leaderElectionConfig, err := newLeaderElectionConfig(cfg, clientGenerator)
+	if err != nil {
+		log.Fatalf("failed to configure leader election: %v", err)
+	}
+	err = leaderelection.Run(ctx, leaderElectionConfig, func(ctx context.Context) {
+		// another leader may have changed the records since the labels were cached, while this replica was standby
+		if invalidator, ok := r.(registry.CacheInvalidator); ok {
+			invalidator.InvalidateCache()
+		}
+		ctrl.ScheduleRunOnce(time.Now())
+		ctrl.Run(ctx)
+	})
+	if err != nil {
+		log.Fatalf("leader election failed: %v", err)
+	}
+}
+
+// newTTLPolicy returns the TTL policy of the endpoints. The minimal TTLs of the dyn, ns1 and rfc2136 providers are
+// deprecated in favor of --min-ttl and raise it for their provider.
+func newTTLPolicy(cfg *externaldns.Config) (*endpoint.TTLPolicy, error) {
+	rules, err := endpoint.ParseTTLRules(cfg.TTLRules)
+	if err != nil {
+		return nil, err
+	}
+	recordTypeDefaults, err := endpoint.ParseRecordTypeTTLs(cfg.RecordTypeTTLs)
+	if err != nil {
+		return nil, err
+	}
+	minTTL := endpoint.TTL(cfg.MinTTL.Seconds())
+	var providerMinTTL endpoint.TTL
+	switch cfg.Provider {
+	case "dyn":
+		providerMinTTL = endpoint.TTL(cfg.DynMinTTLSeconds)
+	case "ns1":
+		providerMinTTL = endpoint.TTL(cfg.NS1MinTTLSeconds)
+	case "rfc2136":
+		providerMinTTL = endpoint.TTL(cfg.RFC2136MinTTL.Seconds())
+	}
+	if providerMinTTL > minTTL {
+		minTTL = providerMinTTL
+	}
+	return &endpoint.TTLPolicy{
+		Default:            endpoint.TTL(cfg.DefaultTTL.Seconds()),
+		RecordTypeDefaults: recordTypeDefaults,
+		Rules:              rules,
+		Min:                minTTL,
+		Max:                endpoint.TTL(cfg.MaxTTL.Seconds()),
+	}, nil
+}
+
+// newRegistry returns the registry with the name, which keeps track of the ownership of the records of the provider.
+func newRegistry(name string, cfg *externaldns.Config, p provider.Provider, clientGenerator source.ClientGenerator, awsSession *session.Session) (registry.Registry, error) {
+	switch name {
+	case "dynamodb":
+		config := awsSDK.NewConfig()
+		if cfg.AWSDynamoDBRegion != "" {
+			config = config.WithRegion(cfg.AWSDynamoDBRegion)
+		}
+		return registry.NewDynamoDBRegistry(p, cfg.TXTOwnerID, dynamodb.New(awsSession, config), cfg.AWSDynamoDBTable, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, []byte(cfg.TXTEncryptAESKey), cfg.TXTCacheInterval)
+	case "configmap":
+		return newConfigMapRegistry(cfg, p, clientGenerator)
+	case "sql":
+		db, err := sql.Open(cfg.SQLRegistryDialect, cfg.SQLRegistryDSN)
+		if err != nil {
+			return nil, fmt.Errorf("opening the database of the SQL registry: %w", err)
+		}
+		return registry.NewSQLRegistry(p, cfg.TXTOwnerID, db, cfg.SQLRegistryDialect, cfg.SQLRegistryTable, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, []byte(cfg.TXTEncryptAESKey))
+	case "noop":
+		return registry.NewNoopRegistry(p)
+	case "txt":
+		return registry.NewTXTRegistry(p, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTOwnerID, cfg.TXTCacheInterval, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, cfg.TXTEncryptEnabled, txtEncryptAESKeys(cfg), txtSigningKeys(cfg), cfg.TXTSigningAcceptUnsigned, cfg.ConflictResolver == "multi-owner")
+	case "aws-sd":
+		return registry.NewAWSSDRegistry(p.(*awssd.AWSSDProvider), cfg.TXTOwnerID)
+	default:
+		return nil, fmt.Errorf("unknown registry: %s", name)
+	}
+}
+
+// txtEncryptAESKeys returns the AES keys of the encryption of the TXT records, starting with the active key.
+func txtEncryptAESKeys(cfg *externaldns.Config) [][]byte {
+	if cfg.TXTEncryptAESKey == "" {
+		return nil
+	}
+	keys := [][]byte{[]byte(cfg.TXTEncryptAESKey)}
+	for _, key := range cfg.TXTEncryptRetiredAESKeys {
+		keys = append(keys, []byte(key))
+	}
+	return keys
+}
+
+// txtSigningKeys returns the keys of the signatures of the TXT records, starting with the signing key.
+func txtSigningKeys(cfg *externaldns.Config) [][]byte {
+	if cfg.TXTSigningKey == "" {
+		return nil
+	}
+	keys := [][]byte{[]byte(cfg.TXTSigningKey)}
+	for _, key := range cfg.TXTSigningVerificationKeys {
+		keys = append(keys, []byte(key))
+	}
+	return keys
+}
+
+// migrateRegistry copies the labels of the records owned by the source registry to the target registry once.
+func migrateRegistry(ctx context.Context, cfg *externaldns.Config, p provider.Provider, clientGenerator source.ClientGenerator, awsSession *session.Session) error {
+	from, err := newRegistry(cfg.MigrateRegistryFrom, cfg, p, clientGenerator, awsSession)
+	if err != nil {
+		return err
+	}
+	to, err := newRegistry(cfg.MigrateRegistryTo, cfg, p, clientGenerator, awsSession)
+	if err != nil {
+		return err
+	}
+	store, ok := to.(registry.LabelStore)
+	if !ok {
+		return fmt.Errorf("registry %q cannot be migrated to", cfg.MigrateRegistryTo)
+	}
+	_, err = registry.Migrate(ctx, from, store, registry.MigrationOptions{
+		DryRun:                 cfg.DryRun,
+		DeleteOwnershipRecords: cfg.MigrateRegistryDeleteOld,
+	})
+	return err
+}
+
+// newConfigMapRegistry returns the registry keeping the ownership of the records in ConfigMaps.
+func newConfigMapRegistry(cfg *externaldns.Config, p provider.Provider, clientGenerator source.ClientGenerator) (*registry.ConfigMapRegistry, error) {
+	kubeClient, err := clientGenerator.KubeClient()
+	if err != nil {
+		return nil, err
+	}
+	configMaps := kubeClient.CoreV1().ConfigMaps(cfg.ConfigMapRegistryNamespace)
+	return registry.NewConfigMapRegistry(p, cfg.TXTOwnerID, configMaps, cfg.ConfigMapRegistryNamespace, cfg.ConfigMapRegistryName, cfg.ConfigMapRegistryShards, cfg.TXTPrefix, cfg.TXTSuffix, cfg.TXTWildcardReplacement, cfg.ManagedDNSRecordTypes, cfg.ExcludeDNSRecordTypes, []byte(cfg.TXTEncryptAESKey))
+}
+
+// newEventRecorder returns the recorder of the events on the source objects.
+func newEventRecorder(cfg *externaldns.Config, clientGenerator source.ClientGenerator) (*events.Recorder, error) {
+	kubeClient, err := clientGenerator.KubeClient()
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := clientGenerator.DynamicKubernetesClient()
+	if err != nil {
+		return nil, err
+	}
+	return events.NewRecorder(kubeClient, dynamicClient, cfg.Namespace, cfg.CRDSourceAPIVersion, cfg.CRDSourceKind), nil
+}
+
+// newLeaderElectionConfig returns the config of the Lease based leader election between replicas.
+func newLeaderElectionConfig(cfg *externaldns.Config, clientGenerator source.ClientGenerator) (k8sleaderelection.LeaderElectionConfig, error) {
+	client, err := clientGenerator.KubeClient()
+	if err != nil {
+		return k8sleaderelection.LeaderElectionConfig{}, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return k8sleaderelection.LeaderElectionConfig{}, err
+	}
+
+	namespace := cfg.LeaderElectionNamespace
+	if namespace == "" {
+		namespace = "default"
+		if data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace"); err == nil {
+			namespace = strings.TrimSpace(string(data))
+		}
+	}
+
+	return k8sleaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Name: cfg.LeaderElectionID, Namespace: namespace},
+			Client:     client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: hostname + "_" + string(uuid.NewUUID())},
+		},
+		LeaseDuration: cfg.LeaderElectionLeaseDuration,
+		RenewDeadline: cfg.LeaderElectionRenewDeadline,
+		RetryPeriod:   cfg.LeaderElectionRetryPeriod,
+		Name:          cfg.LeaderElectionID,
+	}, nil
 }
 
 func handleSigterm(cancel func()) {
@@ -507,3 +748,8 @@ func serveMetrics(address string) {
 
 	log.Fatal(http.ListenAndServe(address, nil))
 }
+
+func serveAdmin(address string, handler http.Handler) {
+	log.Infof("Serving the admin API on %s", address)
+	log.Fatal(http.ListenAndServe(address, handler))
+}
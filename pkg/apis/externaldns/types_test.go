@@ -68,6 +68,11 @@ var (
 		AWSZoneCacheDuration:        0 * time.Second,
 		AWSSDServiceCleanup:         false,
 		AWSDynamoDBTable:            "external-dns",
+		ConfigMapRegistryNamespace:  "default",
+		ConfigMapRegistryName:       "external-dns",
+		ConfigMapRegistryShards:     8,
+		SQLRegistryDialect:          "postgres",
+		SQLRegistryTable:            "external_dns_records",
 		AzureConfigFile:             "/etc/kubernetes/azure.json",
 		AzureResourceGroup:          "",
 		AzureSubscriptionID:         "",
@@ -105,15 +110,23 @@ var (
 		PDNSServer:                  "http://localhost:8081",
 		PDNSAPIKey:                  "",
 		Policy:                      "sync",
+		ConflictResolver:            "per-resource",
+		ChangeLimitAction:           "abort",
 		Registry:                    "txt",
 		TXTOwnerID:                  "default",
 		TXTPrefix:                   "",
 		TXTCacheInterval:            0,
 		Interval:                    time.Minute,
 		MinEventSyncInterval:        5 * time.Second,
+		BackoffMaxInterval:          5 * time.Minute,
+		CircuitBreakerCooldown:      5 * time.Minute,
 		Once:                        false,
 		DryRun:                      false,
 		UpdateEvents:                false,
+		LeaderElection:              false,
+		LeaderElectionLeaseDuration: 15 * time.Second,
+		LeaderElectionRenewDeadline: 10 * time.Second,
+		LeaderElectionRetryPeriod:   2 * time.Second,
 		LogFormat:                   "text",
 		MetricsAddress:              ":7979",
 		LogLevel:                    logrus.InfoLevel.String(),
@@ -182,6 +195,19 @@ var (
 		AWSZoneCacheDuration:        10 * time.Second,
 		AWSSDServiceCleanup:         true,
 		AWSDynamoDBTable:            "custom-table",
+		ConfigMapRegistryNamespace:  "external-dns",
+		ConfigMapRegistryName:       "dns-registry",
+		ConfigMapRegistryShards:     16,
+		SQLRegistryDialect:          "sqlite",
+		SQLRegistryDSN:              "file:registry.db",
+		SQLRegistryTable:            "dns_owners",
+		MigrateRegistryFrom:         "txt",
+		MigrateRegistryTo:           "dynamodb",
+		MigrateRegistryDeleteOld:    true,
+		TXTSigningKey:               "signing-key-of-at-least-32-bytes",
+		TXTSigningVerificationKeys:  []string{"old-signing-key-of-at-least-32-bytes"},
+		TXTSigningAcceptUnsigned:    true,
+		TXTEncryptRetiredAESKeys:    []string{"retired-aes-key-of-32-bytes-long"},
 		AzureConfigFile:             "azure.json",
 		AzureResourceGroup:          "arg",
 		AzureSubscriptionID:         "arg",
@@ -223,17 +249,40 @@ var (
 		TLSClientCert:               "/path/to/cert.pem",
 		TLSClientCertKey:            "/path/to/key.pem",
 		Policy:                      "upsert-only",
+		ConflictResolver:            "merge-targets",
+		AllowApexCNAME:              true,
+		MaxDeletes:                  10,
+		MaxDeletesPercent:           20,
+		MaxChanges:                  100,
+		ChangeLimitAction:           "truncate",
+		DeletionGracePeriod:         10 * time.Minute,
 		Registry:                    "noop",
 		TXTOwnerID:                  "owner-1",
 		TXTPrefix:                   "associated-txt-record",
 		TXTCacheInterval:            12 * time.Hour,
 		Interval:                    10 * time.Minute,
 		MinEventSyncInterval:        50 * time.Second,
+		ZoneConcurrency:             10,
+		BackoffInitialInterval:      5 * time.Second,
+		BackoffMaxInterval:          10 * time.Minute,
+		CircuitBreakerThreshold:     3,
+		CircuitBreakerCooldown:      15 * time.Minute,
 		Once:                        true,
 		DryRun:                      true,
+		PlanOutput:                  "/tmp/plan.json",
 		UpdateEvents:                true,
+		EmitEvents:                  true,
+		LeaderElection:              true,
+		LeaderElectionNamespace:     "external-dns",
+		LeaderElectionID:            "external-dns-public",
+		LeaderElectionLeaseDuration: 30 * time.Second,
+		LeaderElectionRenewDeadline: 20 * time.Second,
+		LeaderElectionRetryPeriod:   5 * time.Second,
 		LogFormat:                   "json",
 		MetricsAddress:              "127.0.0.1:9099",
+		DebugDecisions:              true,
+		AdminAddress:                "127.0.0.1:9098",
+		AdminToken:                  "secret",
 		LogLevel:                    logrus.DebugLevel.String(),
 		ConnectorSourceServer:       "localhost:8081",
 		ExoscaleAPIEnvironment:      "api1",
@@ -249,6 +298,11 @@ var (
 		TransIPPrivateKeyFile:       "/path/to/transip.key",
 		DigitalOceanAPIPageSize:     100,
 		ManagedDNSRecordTypes:       []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME, endpoint.RecordTypeNS},
+		DefaultTTL:                  5 * time.Minute,
+		TTLRules:                    []string{"internal.example.org=30s"},
+		RecordTypeTTLs:              []string{"MX=1h"},
+		MinTTL:                      time.Minute,
+		MaxTTL:                      24 * time.Hour,
 		RFC2136BatchChangeSize:      100,
 		IBMCloudProxied:             true,
 		IBMCloudConfigFile:          "ibmcloud.json",
@@ -370,18 +424,54 @@ func TestParseFlags(t *testing.T) {
 				"--aws-sd-service-cleanup",
 				"--no-aws-evaluate-target-health",
 				"--policy=upsert-only",
+				"--conflict-resolver=merge-targets",
+				"--allow-apex-cname",
+				"--max-deletes=10",
+				"--max-deletes-percent=20",
+				"--max-changes=100",
+				"--change-limit-action=truncate",
+				"--deletion-grace-period=10m",
 				"--registry=noop",
 				"--txt-owner-id=owner-1",
 				"--txt-prefix=associated-txt-record",
 				"--txt-cache-interval=12h",
 				"--dynamodb-table=custom-table",
+				"--configmap-registry-namespace=external-dns",
+				"--configmap-registry-name=dns-registry",
+				"--configmap-registry-shards=16",
+				"--sql-registry-dialect=sqlite",
+				"--sql-registry-dsn=file:registry.db",
+				"--sql-registry-table=dns_owners",
+				"--migrate-registry-from=txt",
+				"--migrate-registry-to=dynamodb",
+				"--migrate-registry-delete-old",
+				"--txt-signing-key=signing-key-of-at-least-32-bytes",
+				"--txt-signing-verification-key=old-signing-key-of-at-least-32-bytes",
+				"--txt-signing-accept-unsigned",
+				"--txt-encrypt-retired-aes-key=retired-aes-key-of-32-bytes-long",
 				"--interval=10m",
 				"--min-event-sync-interval=50s",
+				"--zone-concurrency=10",
+				"--backoff-initial-interval=5s",
+				"--backoff-max-interval=10m",
+				"--circuit-breaker-threshold=3",
+				"--circuit-breaker-cooldown=15m",
 				"--once",
 				"--dry-run",
+				"--plan-output=/tmp/plan.json",
 				"--events",
+				"--emit-events",
+				"--leader-election",
+				"--leader-election-namespace=external-dns",
+				"--leader-election-id=external-dns-public",
+				"--leader-election-lease-duration=30s",
+				"--leader-election-renew-deadline=20s",
+				"--leader-election-retry-period=5s",
 				"--log-format=json",
 				"--metrics-address=127.0.0.1:9099",
+				"--debug-decisions",
+				"--admin-address=127.0.0.1:9098",
+				"--admin-token=secret",
 				"--log-level=debug",
 				"--connector-source-server=localhost:8081",
 				"--exoscale-apienv=api1",
@@ -400,6 +490,11 @@ func TestParseFlags(t *testing.T) {
 				"--managed-record-types=AAAA",
 				"--managed-record-types=CNAME",
 				"--managed-record-types=NS",
+				"--default-ttl=5m",
+				"--ttl-rule=internal.example.org=30s",
+				"--record-type-ttl=MX=1h",
+				"--min-ttl=1m",
+				"--max-ttl=24h",
 				"--rfc2136-batch-change-size=100",
 				"--ibmcloud-proxied",
 				"--ibmcloud-config-file=ibmcloud.json",
@@ -495,18 +590,54 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_AWS_ZONES_CACHE_DURATION":        "10s",
 				"EXTERNAL_DNS_AWS_SD_SERVICE_CLEANUP":          "true",
 				"EXTERNAL_DNS_DYNAMODB_TABLE":                  "custom-table",
+				"EXTERNAL_DNS_CONFIGMAP_REGISTRY_NAMESPACE":    "external-dns",
+				"EXTERNAL_DNS_CONFIGMAP_REGISTRY_NAME":         "dns-registry",
+				"EXTERNAL_DNS_CONFIGMAP_REGISTRY_SHARDS":       "16",
+				"EXTERNAL_DNS_SQL_REGISTRY_DIALECT":            "sqlite",
+				"EXTERNAL_DNS_SQL_REGISTRY_DSN":                "file:registry.db",
+				"EXTERNAL_DNS_SQL_REGISTRY_TABLE":              "dns_owners",
+				"EXTERNAL_DNS_MIGRATE_REGISTRY_FROM":           "txt",
+				"EXTERNAL_DNS_MIGRATE_REGISTRY_TO":             "dynamodb",
+				"EXTERNAL_DNS_MIGRATE_REGISTRY_DELETE_OLD":     "1",
+				"EXTERNAL_DNS_TXT_SIGNING_KEY":                 "signing-key-of-at-least-32-bytes",
+				"EXTERNAL_DNS_TXT_SIGNING_VERIFICATION_KEY":    "old-signing-key-of-at-least-32-bytes",
+				"EXTERNAL_DNS_TXT_SIGNING_ACCEPT_UNSIGNED":     "1",
+				"EXTERNAL_DNS_TXT_ENCRYPT_RETIRED_AES_KEY":     "retired-aes-key-of-32-bytes-long",
 				"EXTERNAL_DNS_POLICY":                          "upsert-only",
+				"EXTERNAL_DNS_CONFLICT_RESOLVER":               "merge-targets",
+				"EXTERNAL_DNS_ALLOW_APEX_CNAME":                "1",
+				"EXTERNAL_DNS_MAX_DELETES":                     "10",
+				"EXTERNAL_DNS_MAX_DELETES_PERCENT":             "20",
+				"EXTERNAL_DNS_MAX_CHANGES":                     "100",
+				"EXTERNAL_DNS_CHANGE_LIMIT_ACTION":             "truncate",
+				"EXTERNAL_DNS_DELETION_GRACE_PERIOD":           "10m",
 				"EXTERNAL_DNS_REGISTRY":                        "noop",
 				"EXTERNAL_DNS_TXT_OWNER_ID":                    "owner-1",
 				"EXTERNAL_DNS_TXT_PREFIX":                      "associated-txt-record",
 				"EXTERNAL_DNS_TXT_CACHE_INTERVAL":              "12h",
 				"EXTERNAL_DNS_INTERVAL":                        "10m",
 				"EXTERNAL_DNS_MIN_EVENT_SYNC_INTERVAL":         "50s",
+				"EXTERNAL_DNS_ZONE_CONCURRENCY":                "10",
+				"EXTERNAL_DNS_BACKOFF_INITIAL_INTERVAL":        "5s",
+				"EXTERNAL_DNS_BACKOFF_MAX_INTERVAL":            "10m",
+				"EXTERNAL_DNS_CIRCUIT_BREAKER_THRESHOLD":       "3",
+				"EXTERNAL_DNS_CIRCUIT_BREAKER_COOLDOWN":        "15m",
 				"EXTERNAL_DNS_ONCE":                            "1",
 				"EXTERNAL_DNS_DRY_RUN":                         "1",
+				"EXTERNAL_DNS_PLAN_OUTPUT":                     "/tmp/plan.json",
 				"EXTERNAL_DNS_EVENTS":                          "1",
+				"EXTERNAL_DNS_EMIT_EVENTS":                     "1",
+				"EXTERNAL_DNS_LEADER_ELECTION":                 "1",
+				"EXTERNAL_DNS_LEADER_ELECTION_NAMESPACE":       "external-dns",
+				"EXTERNAL_DNS_LEADER_ELECTION_ID":              "external-dns-public",
+				"EXTERNAL_DNS_LEADER_ELECTION_LEASE_DURATION":  "30s",
+				"EXTERNAL_DNS_LEADER_ELECTION_RENEW_DEADLINE":  "20s",
+				"EXTERNAL_DNS_LEADER_ELECTION_RETRY_PERIOD":    "5s",
 				"EXTERNAL_DNS_LOG_FORMAT":                      "json",
 				"EXTERNAL_DNS_METRICS_ADDRESS":                 "127.0.0.1:9099",
+				"EXTERNAL_DNS_DEBUG_DECISIONS":                 "1",
+				"EXTERNAL_DNS_ADMIN_ADDRESS":                   "127.0.0.1:9098",
+				"EXTERNAL_DNS_ADMIN_TOKEN":                     "secret",
 				"EXTERNAL_DNS_LOG_LEVEL":                       "debug",
 				"EXTERNAL_DNS_CONNECTOR_SOURCE_SERVER":         "localhost:8081",
 				"EXTERNAL_DNS_EXOSCALE_APIENV":                 "api1",
@@ -522,6 +653,11 @@ func TestParseFlags(t *testing.T) {
 				"EXTERNAL_DNS_TRANSIP_KEYFILE":                 "/path/to/transip.key",
 				"EXTERNAL_DNS_DIGITALOCEAN_API_PAGE_SIZE":      "100",
 				"EXTERNAL_DNS_MANAGED_RECORD_TYPES":            "A\nAAAA\nCNAME\nNS",
+				"EXTERNAL_DNS_DEFAULT_TTL":                     "5m",
+				"EXTERNAL_DNS_TTL_RULE":                        "internal.example.org=30s",
+				"EXTERNAL_DNS_RECORD_TYPE_TTL":                 "MX=1h",
+				"EXTERNAL_DNS_MIN_TTL":                         "1m",
+				"EXTERNAL_DNS_MAX_TTL":                         "24h",
 				"EXTERNAL_DNS_RFC2136_BATCH_CHANGE_SIZE":       "100",
 				"EXTERNAL_DNS_IBMCLOUD_PROXIED":                "1",
 				"EXTERNAL_DNS_IBMCLOUD_CONFIG_FILE":            "ibmcloud.json",
@@ -542,6 +678,51 @@ func TestParseFlags(t *testing.T) {
 	}
 }
 
+func TestParseFlagsAllowApexCNAME(t *testing.T) {
+	for _, ti := range []struct {
+		title    string
+		args     []string
+		envVars  map[string]string
+		expected bool
+	}{
+		{
+			title:    "disabled by default",
+			args:     []string{"--source=service", "--provider=google"},
+			expected: false,
+		},
+		{
+			title:    "enabled by default for a provider which flattens CNAME records",
+			args:     []string{"--source=service", "--provider=cloudflare"},
+			expected: true,
+		},
+		{
+			title:    "disabled by the flag",
+			args:     []string{"--source=service", "--provider=cloudflare", "--no-allow-apex-cname"},
+			expected: false,
+		},
+		{
+			title:    "disabled by the environment variable",
+			args:     []string{"--source=service", "--provider=pdns"},
+			envVars:  map[string]string{"EXTERNAL_DNS_ALLOW_APEX_CNAME": "false"},
+			expected: false,
+		},
+		{
+			title:    "enabled by the flag",
+			args:     []string{"--source=service", "--provider=google", "--allow-apex-cname"},
+			expected: true,
+		},
+	} {
+		t.Run(ti.title, func(t *testing.T) {
+			originalEnv := setEnv(t, ti.envVars)
+			defer func() { restoreEnv(t, originalEnv) }()
+
+			cfg := NewConfig()
+			require.NoError(t, cfg.ParseFlags(ti.args))
+			assert.Equal(t, ti.expected, cfg.AllowApexCNAME)
+		})
+	}
+}
+
 // helper functions
 
 func setEnv(t *testing.T, env map[string]string) map[string]string {
@@ -563,10 +744,14 @@ func restoreEnv(t *testing.T, originalEnv map[string]string) {
 
 func TestPasswordsNotLogged(t *testing.T) {
 	cfg := Config{
-		DynPassword:          "dyn-pass",
-		InfobloxWapiPassword: "infoblox-pass",
-		PDNSAPIKey:           "pdns-api-key",
-		RFC2136TSIGSecret:    "tsig-secret",
+		DynPassword:                "dyn-pass",
+		InfobloxWapiPassword:       "infoblox-pass",
+		PDNSAPIKey:                 "pdns-api-key",
+		RFC2136TSIGSecret:          "tsig-secret",
+		TXTSigningKey:              "signing-key",
+		TXTSigningVerificationKeys: []string{"old-signing-key"},
+		TXTEncryptRetiredAESKeys:   []string{"retired-aes-key"},
+		AdminToken:                 "admin-token",
 	}
 
 	s := cfg.String()
@@ -575,4 +760,7 @@ func TestPasswordsNotLogged(t *testing.T) {
 	assert.False(t, strings.Contains(s, "infoblox-pass"))
 	assert.False(t, strings.Contains(s, "pdns-api-key"))
 	assert.False(t, strings.Contains(s, "tsig-secret"))
+	assert.False(t, strings.Contains(s, "signing-key"))
+	assert.False(t, strings.Contains(s, "retired-aes-key"))
+	assert.False(t, strings.Contains(s, "admin-token"))
 }
@@ -17,11 +17,14 @@ limitations under the License.
 package validation
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
 
 	"k8s.io/apimachinery/pkg/labels"
 
+	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 )
 
@@ -112,6 +115,110 @@ func ValidateConfig(cfg *externaldns.Config) error {
 		return errors.New("txt-prefix and txt-suffix are mutual exclusive")
 	}
 
+	if cfg.ZoneConcurrency < 0 {
+		return errors.New("--zone-concurrency cannot be negative")
+	}
+
+	if len(cfg.TXTSigningVerificationKeys) > 0 && cfg.TXTSigningKey == "" {
+		return errors.New("--txt-signing-verification-key requires --txt-signing-key")
+	}
+
+	if cfg.TXTSigningAcceptUnsigned && cfg.TXTSigningKey == "" {
+		return errors.New("--txt-signing-accept-unsigned requires --txt-signing-key")
+	}
+
+	if len(cfg.TXTEncryptRetiredAESKeys) > 0 && cfg.TXTEncryptAESKey == "" {
+		return errors.New("--txt-encrypt-retired-aes-key requires --txt-encrypt-aes-key")
+	}
+
+	if cfg.MigrateRegistryFrom != "" || cfg.MigrateRegistryTo != "" {
+		if cfg.MigrateRegistryFrom == "" || cfg.MigrateRegistryTo == "" {
+			return errors.New("--migrate-registry-from and --migrate-registry-to must be specified together")
+		}
+		if cfg.MigrateRegistryFrom == cfg.MigrateRegistryTo {
+			return errors.New("--migrate-registry-from and --migrate-registry-to must be different registries")
+		}
+	}
+
+	if cfg.MigrateRegistryDeleteOld && cfg.MigrateRegistryFrom != "txt" {
+		return errors.New("--migrate-registry-delete-old is only supported when migrating from the txt registry")
+	}
+
+	if (cfg.Registry == "sql" || cfg.MigrateRegistryFrom == "sql" || cfg.MigrateRegistryTo == "sql") && cfg.SQLRegistryDSN == "" {
+		return errors.New("--sql-registry-dsn must be specified with the sql registry")
+	}
+
+	if (cfg.Registry == "sql" || cfg.MigrateRegistryFrom == "sql" || cfg.MigrateRegistryTo == "sql") && !slices.Contains(sql.Drivers(), cfg.SQLRegistryDialect) {
+		return fmt.Errorf("no driver of the %s database is compiled in", cfg.SQLRegistryDialect)
+	}
+
+	if (cfg.Registry == "configmap" || cfg.MigrateRegistryTo == "configmap") && cfg.ConfigMapRegistryShards < 1 {
+		return errors.New("--configmap-registry-shards must be at least 1")
+	}
+
+	if cfg.BackoffInitialInterval < 0 || cfg.BackoffMaxInterval < 0 {
+		return errors.New("--backoff-initial-interval and --backoff-max-interval cannot be negative")
+	}
+
+	if cfg.CircuitBreakerThreshold < 0 {
+		return errors.New("--circuit-breaker-threshold cannot be negative")
+	}
+
+	if cfg.MaxDeletes < 0 || cfg.MaxChanges < 0 {
+		return errors.New("--max-deletes and --max-changes cannot be negative")
+	}
+
+	if cfg.MaxDeletesPercent < 0 || cfg.MaxDeletesPercent > 100 {
+		return errors.New("--max-deletes-percent must be between 0 and 100")
+	}
+
+	if cfg.DeletionGracePeriod < 0 {
+		return errors.New("--deletion-grace-period cannot be negative")
+	}
+
+	if cfg.DeletionGracePeriod > 0 && cfg.Registry != "txt" && cfg.Registry != "dynamodb" && cfg.Registry != "configmap" && cfg.Registry != "sql" {
+		return errors.New("--deletion-grace-period is only supported with the txt, dynamodb, configmap and sql registries")
+	}
+
+	if cfg.DefaultTTL < 0 || cfg.MinTTL < 0 || cfg.MaxTTL < 0 {
+		return errors.New("--default-ttl, --min-ttl and --max-ttl cannot be negative")
+	}
+
+	if cfg.MaxTTL > 0 && cfg.MinTTL > cfg.MaxTTL {
+		return errors.New("--min-ttl cannot be greater than --max-ttl")
+	}
+
+	if _, err := endpoint.ParseTTLRules(cfg.TTLRules); err != nil {
+		return fmt.Errorf("--ttl-rule: %w", err)
+	}
+
+	if _, err := endpoint.ParseRecordTypeTTLs(cfg.RecordTypeTTLs); err != nil {
+		return fmt.Errorf("--record-type-ttl: %w", err)
+	}
+
+	if cfg.LeaderElection {
+		if cfg.LeaderElectionID == "" {
+			return errors.New("--leader-election-id must be set when --leader-election is enabled")
+		}
+		if cfg.LeaderElectionRetryPeriod <= 0 {
+			return errors.New("--leader-election-retry-period must be positive")
+		}
+		if cfg.LeaderElectionRenewDeadline <= cfg.LeaderElectionRetryPeriod*6/5 {
+			return errors.New("--leader-election-renew-deadline must be greater than 1.2 times --leader-election-retry-period")
+		}
+		if cfg.LeaderElectionLeaseDuration <= cfg.LeaderElectionRenewDeadline {
+			return errors.New("--leader-election-lease-duration must be greater than --leader-election-renew-deadline")
+		}
+	}
+
+	if cfg.AdminAddress != "" && cfg.AdminToken == "" {
+		return errors.New("--admin-token must be set when --admin-address is set")
+	}
+
+	if cfg.ConflictResolver == "multi-owner" && cfg.Registry != "txt" {
+		return errors.New("--conflict-resolver=multi-owner is only supported with the txt registry")
+	}
+
 	_, err := labels.Parse(cfg.LabelFilter)
 	if err != nil {
 		return errors.New("--label-filter does not specify a valid label selector")
@@ -18,11 +18,14 @@ package validation
 
 import (
 	"testing"
+	"time"
 
 	"sigs.k8s.io/external-dns/pkg/apis/externaldns"
 
+	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
 )
 
 func TestValidateFlags(t *testing.T) {
@@ -279,3 +282,228 @@ func TestValidateGoodRfc2136GssTsigConfig(t *testing.T) {
 		assert.Nil(t, err)
 	}
 }
+
+func TestValidateZoneConcurrency(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.ZoneConcurrency = 10
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.ZoneConcurrency = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.ZoneConcurrency = 10
+	cfg.Registry = "dynamodb"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.Registry = "configmap"
+	cfg.ConfigMapRegistryShards = 8
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.Registry = "sql"
+	cfg.SQLRegistryDSN = "file:registry.db"
+	cfg.SQLRegistryDialect = "sqlite"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateSQLRegistry(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "sql"
+	cfg.SQLRegistryDSN = "postgres://external-dns@localhost/external-dns"
+	cfg.SQLRegistryDialect = "postgres"
+	cfg.DeletionGracePeriod = time.Hour
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.SQLRegistryDialect = "mysql"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.SQLRegistryDialect = "postgres"
+	cfg.SQLRegistryDSN = ""
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateConfigMapRegistry(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.Registry = "configmap"
+	cfg.ConfigMapRegistryShards = 8
+	cfg.DeletionGracePeriod = time.Hour
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.ConfigMapRegistryShards = 0
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateRegistryMigration(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.MigrateRegistryFrom = "txt"
+	cfg.MigrateRegistryTo = "dynamodb"
+	cfg.MigrateRegistryDeleteOld = true
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.MigrateRegistryTo = ""
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.MigrateRegistryTo = "txt"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.MigrateRegistryFrom = "dynamodb"
+	cfg.MigrateRegistryTo = "sql"
+	cfg.SQLRegistryDSN = "postgres://external-dns@localhost/external-dns"
+	cfg.SQLRegistryDialect = "postgres"
+	assert.Error(t, ValidateConfig(cfg), "ownership records are only deleted when migrating from the txt registry")
+
+	cfg.MigrateRegistryDeleteOld = false
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.SQLRegistryDialect = "mysql"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.SQLRegistryDialect = "postgres"
+	cfg.SQLRegistryDSN = ""
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateTXTSigningKeys(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.TXTSigningVerificationKeys = []string{"old-signing-key-of-at-least-32-bytes"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTSigningVerificationKeys = nil
+	cfg.TXTSigningAcceptUnsigned = true
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTSigningKey = "signing-key-of-at-least-32-bytes"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateTXTEncryptRetiredAESKeys(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.TXTEncryptRetiredAESKeys = []string{"retired-aes-key-of-32-bytes-long"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.TXTEncryptAESKey = "active-aes-key-of-32-bytes-long!"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateRetryConfig(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.BackoffInitialInterval = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.BackoffMaxInterval = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.CircuitBreakerThreshold = -1
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateMultiOwnerConflictResolver(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.ConflictResolver = "multi-owner"
+	cfg.Registry = "txt"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.ConflictResolver = "multi-owner"
+	cfg.Registry = "noop"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateChangeLimits(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.MaxDeletes = 10
+	cfg.MaxDeletesPercent = 100
+	cfg.MaxChanges = 100
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.MaxDeletes = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.MaxChanges = -1
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.MaxDeletesPercent = 101
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateDeletionGracePeriod(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.DeletionGracePeriod = time.Hour
+	cfg.Registry = "txt"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.DeletionGracePeriod = -time.Hour
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.DeletionGracePeriod = time.Hour
+	cfg.Registry = "noop"
+	assert.Error(t, ValidateConfig(cfg))
+}
+
+func TestValidateLeaderElection(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.LeaderElection = true
+	cfg.LeaderElectionLeaseDuration = 15 * time.Second
+	cfg.LeaderElectionRenewDeadline = 10 * time.Second
+	cfg.LeaderElectionRetryPeriod = 2 * time.Second
+	assert.Error(t, ValidateConfig(cfg), "the lease name is required")
+
+	cfg.LeaderElectionID = "external-dns-public"
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.LeaderElectionRetryPeriod = 0
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.LeaderElectionRetryPeriod = 9 * time.Second
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.LeaderElectionRetryPeriod = 2 * time.Second
+	cfg.LeaderElectionLeaseDuration = 10 * time.Second
+	assert.Error(t, ValidateConfig(cfg))
+
+	// the durations are not used without leader election
+	cfg.LeaderElection = false
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateAdminToken(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.AdminAddress = "127.0.0.1:7980"
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg.AdminToken = "secret"
+	assert.NoError(t, ValidateConfig(cfg))
+}
+
+func TestValidateTTLPolicy(t *testing.T) {
+	cfg := newValidConfig(t)
+	cfg.DefaultTTL = 5 * time.Minute
+	cfg.MinTTL = time.Minute
+	cfg.MaxTTL = time.Hour
+	cfg.TTLRules = []string{"internal.example.org=30s"}
+	cfg.RecordTypeTTLs = []string{"MX=3600"}
+	assert.NoError(t, ValidateConfig(cfg))
+
+	cfg.MinTTL = 2 * time.Hour
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.DefaultTTL = -time.Minute
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.TTLRules = []string{"internal.example.org"}
+	assert.Error(t, ValidateConfig(cfg))
+
+	cfg = newValidConfig(t)
+	cfg.RecordTypeTTLs = []string{"MX=soon"}
+	assert.Error(t, ValidateConfig(cfg))
+}
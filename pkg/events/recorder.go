@@ -0,0 +1,228 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+// component is the source reported by the events
+const component = "external-dns"
+
+// syncTimeout bounds the time spent filling the cache of the objects of a resource
+const syncTimeout = 10 * time.Second
+
+// syncRetryInterval is the time after which the cache of a resource whose objects could not be listed is filled
+// again, e.g. once its CRD is installed
+const syncRetryInterval = 10 * time.Minute
+
+// resources maps the kind prefix of the resource label set by the sources to the resources it may refer to,
+// in the order they are looked up
+var resources = map[string][]schema.GroupVersionResource{
+	"service":          {{Version: "v1", Resource: "services"}},
+	"ingress":          {{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}},
+	"httproute":        {{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}},
+	"grpcroute":        {{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "grpcroutes"}},
+	"tcproute":         {{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "tcproutes"}},
+	"tlsroute":         {{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "tlsroutes"}},
+	"udproute":         {{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "udproutes"}},
+	"HTTPProxy":        {{Group: "projectcontour.io", Version: "v1", Resource: "httpproxies"}},
+	"gateway":          {{Group: "networking.istio.io", Version: "v1beta1", Resource: "gateways"}},
+	"virtualservice":   {{Group: "networking.istio.io", Version: "v1beta1", Resource: "virtualservices"}},
+	"route":            {{Group: "route.openshift.io", Version: "v1", Resource: "routes"}},
+	"routegroup":       {{Group: "zalando.org", Version: "v1", Resource: "routegroups"}},
+	"host":             {{Group: "getambassador.io", Version: "v2", Resource: "hosts"}},
+	"tcpingress":       {{Group: "configuration.konghq.com", Version: "v1beta1", Resource: "tcpingresses"}},
+	"f5-virtualserver": {{Group: "cis.f5.com", Version: "v1", Resource: "virtualservers"}},
+	"proxy":            {{Group: "gloo.solo.io", Version: "v1", Resource: "proxies"}},
+	"ingressroute": {
+		{Group: "traefik.io", Version: "v1alpha1", Resource: "ingressroutes"},
+		{Group: "traefik.containo.us", Version: "v1alpha1", Resource: "ingressroutes"},
+	},
+	"ingressroutetcp": {
+		{Group: "traefik.io", Version: "v1alpha1", Resource: "ingressroutetcps"},
+		{Group: "traefik.containo.us", Version: "v1alpha1", Resource: "ingressroutetcps"},
+	},
+	"ingressrouteudp": {
+		{Group: "traefik.io", Version: "v1alpha1", Resource: "ingressrouteudps"},
+		{Group: "traefik.containo.us", Version: "v1alpha1", Resource: "ingressrouteudps"},
+	},
+}
+
+// resourceCache is the cache of the objects of a resource, filled by an informer
+type resourceCache struct {
+	lister cache.GenericLister
+	stopCh chan struct{}
+	// failedAt is the time the objects could not be listed, when there is no lister
+	failedAt time.Time
+}
+
+// Recorder records Kubernetes events on the objects identified by the resource label of the records, so that
+// they show up in `kubectl describe`. The objects are looked up in caches filled by informers, which are started
+// on the first event recorded on an object of their resource.
+type Recorder struct {
+	client      dynamic.Interface
+	namespace   string
+	recorder    record.EventRecorder
+	broadcaster record.EventBroadcaster
+	resources   map[string][]schema.GroupVersionResource
+	// caches are the caches of the resources looked up so far, guarded by cachesMux
+	caches    map[schema.GroupVersionResource]*resourceCache
+	cachesMux sync.Mutex
+}
+
+// NewRecorder returns a Recorder creating the events through the given client, on the objects of the namespace or
+// of all namespaces if it is empty. crdAPIVersion and crdKind identify the objects of the CRD source.
+func NewRecorder(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, namespace, crdAPIVersion, crdKind string) *Recorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	r := newRecorder(dynamicClient, namespace, broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: component}), crdAPIVersion, crdKind)
+	r.broadcaster = broadcaster
+	return r
+}
+
+func newRecorder(client dynamic.Interface, namespace string, recorder record.EventRecorder, crdAPIVersion, crdKind string) *Recorder {
+	r := &Recorder{
+		client:    client,
+		namespace: namespace,
+		recorder:  recorder,
+		resources: resources,
+		caches:    map[schema.GroupVersionResource]*resourceCache{},
+	}
+	if gv, err := schema.ParseGroupVersion(crdAPIVersion); err == nil && crdKind != "" {
+		gvr, _ := meta.UnsafeGuessKindToResource(gv.WithKind(crdKind))
+		r.resources = make(map[string][]schema.GroupVersionResource, len(resources)+1)
+		for prefix, gvrs := range resources {
+			r.resources[prefix] = gvrs
+		}
+		r.resources["crd"] = []schema.GroupVersionResource{gvr}
+	}
+	return r
+}
+
+// Event records an event on the object identified by the given resource label. Events on objects which cannot
+// be found are dropped.
+func (r *Recorder) Event(resource, eventType, reason, message string) {
+	ref, err := r.objectReference(resource)
+	if err != nil {
+		log.Debugf("Not recording event %s on %s: %v", reason, resource, err)
+		return
+	}
+	r.recorder.Event(ref, eventType, reason, message)
+}
+
+// Shutdown stops sending the recorded events to the API server and stops the informers
+func (r *Recorder) Shutdown() {
+	if r.broadcaster != nil {
+		r.broadcaster.Shutdown()
+	}
+	r.cachesMux.Lock()
+	defer r.cachesMux.Unlock()
+	for gvr, c := range r.caches {
+		if c.lister != nil {
+			close(c.stopCh)
+		}
+		delete(r.caches, gvr)
+	}
+}
+
+// lister returns the lister of the cached objects of the resource, starting the informer filling the cache on the
+// first call. Resources whose objects cannot be listed, e.g. because their CRD is not installed, are only tried
+// again after syncRetryInterval.
+func (r *Recorder) lister(gvr schema.GroupVersionResource) (cache.GenericLister, error) {
+	r.cachesMux.Lock()
+	defer r.cachesMux.Unlock()
+	if c, ok := r.caches[gvr]; ok {
+		if c.lister != nil {
+			return c.lister, nil
+		}
+		if time.Since(c.failedAt) < syncRetryInterval {
+			return nil, fmt.Errorf("the objects of %s cannot be listed", gvr.String())
+		}
+	}
+
+	informer := dynamicinformer.NewFilteredDynamicInformer(r.client, gvr, r.namespace, 0, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, nil)
+	stopCh := make(chan struct{})
+	go informer.Informer().Run(stopCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		close(stopCh)
+		r.caches[gvr] = &resourceCache{failedAt: time.Now()}
+		return nil, fmt.Errorf("the objects of %s cannot be listed", gvr.String())
+	}
+	r.caches[gvr] = &resourceCache{lister: informer.Lister(), stopCh: stopCh}
+	return informer.Lister(), nil
+}
+
+// objectReference looks up the object identified by the given resource label, e.g. "service/default/nginx"
+func (r *Recorder) objectReference(resource string) (*corev1.ObjectReference, error) {
+	parts := strings.SplitN(resource, "/", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid resource label %q", resource)
+	}
+	prefix, namespace, name := parts[0], parts[1], parts[2]
+	gvrs, ok := r.resources[prefix]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource kind %q", prefix)
+	}
+
+	var lastErr error
+	for _, gvr := range gvrs {
+		lister, err := r.lister(gvr)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		obj, err := lister.ByNamespace(namespace).Get(name)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+		apiVersion, kind := obj.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+		return &corev1.ObjectReference{
+			APIVersion:      apiVersion,
+			Kind:            kind,
+			Namespace:       accessor.GetNamespace(),
+			Name:            accessor.GetName(),
+			UID:             accessor.GetUID(),
+			ResourceVersion: accessor.GetResourceVersion(),
+		}, nil
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+var dnsEndpoints = schema.GroupVersionResource{Group: "externaldns.k8s.io", Version: "v1alpha1", Resource: "dnsendpoints"}
+
+func newObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID("uid-" + name))
+	return obj
+}
+
+// newFakeClient returns a dynamic client which can list every resource events may be recorded on
+func newFakeClient(objects ...runtime.Object) *fakedynamic.FakeDynamicClient {
+	listKinds := map[schema.GroupVersionResource]string{dnsEndpoints: "DNSEndpointList"}
+	for _, gvrs := range resources {
+		for _, gvr := range gvrs {
+			listKinds[gvr] = "FakeList"
+		}
+	}
+	return fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds, objects...)
+}
+
+func TestObjectReference(t *testing.T) {
+	client := newFakeClient(
+		newObject("v1", "Service", "default", "nginx"),
+		newObject("externaldns.k8s.io/v1alpha1", "DNSEndpoint", "default", "records"),
+		newObject("traefik.containo.us/v1alpha1", "IngressRoute", "default", "legacy"),
+	)
+	r := newRecorder(client, "", record.NewFakeRecorder(1), "externaldns.k8s.io/v1alpha1", "DNSEndpoint")
+	defer r.Shutdown()
+
+	for _, tt := range []struct {
+		resource string
+		kind     string
+		uid      string
+	}{
+		{resource: "service/default/nginx", kind: "Service", uid: "uid-nginx"},
+		{resource: "crd/default/records", kind: "DNSEndpoint", uid: "uid-records"},
+		{resource: "ingressroute/default/legacy", kind: "IngressRoute", uid: "uid-legacy"},
+	} {
+		t.Run(tt.resource, func(t *testing.T) {
+			ref, err := r.objectReference(tt.resource)
+			require.NoError(t, err)
+			assert.Equal(t, tt.kind, ref.Kind)
+			assert.Equal(t, "default", ref.Namespace)
+			assert.Equal(t, tt.uid, string(ref.UID))
+		})
+	}
+
+	for _, resource := range []string{"service/default/missing", "unknown/default/nginx", "service/nginx"} {
+		_, err := r.objectReference(resource)
+		assert.Error(t, err, resource)
+	}
+}
+
+func TestEvent(t *testing.T) {
+	client := newFakeClient(newObject("v1", "Service", "default", "nginx"))
+	fake := record.NewFakeRecorder(2)
+	fake.IncludeObject = true
+	r := newRecorder(client, "", fake, "", "")
+	defer r.Shutdown()
+
+	r.Event("service/default/nginx", "Normal", "RecordCreated", "Created record a.example.org A")
+	r.Event("service/default/missing", "Normal", "RecordCreated", "Created record b.example.org A")
+
+	require.Len(t, fake.Events, 1)
+	assert.Equal(t, "Normal RecordCreated Created record a.example.org A involvedObject{kind=Service,apiVersion=v1}", <-fake.Events)
+}
+
+func TestObjectReferenceUsesCache(t *testing.T) {
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+	client := newFakeClient(newObject("v1", "Service", "default", "nginx"))
+	r := newRecorder(client, "default", record.NewFakeRecorder(1), "", "")
+	defer r.Shutdown()
+
+	for i := 0; i < 3; i++ {
+		_, err := r.objectReference("service/default/nginx")
+		require.NoError(t, err)
+	}
+	// the objects are listed and watched once instead of being fetched for every event
+	for _, action := range client.Actions() {
+		assert.Contains(t, []string{"list", "watch"}, action.GetVerb())
+	}
+
+	// objects created later are found once the informer is notified
+	_, err := client.Resource(services).Namespace("default").Create(context.Background(), newObject("v1", "Service", "default", "late"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		ref, err := r.objectReference("service/default/late")
+		return err == nil && ref.UID == "uid-late"
+	}, time.Second, 10*time.Millisecond)
+
+	// objects of other namespaces are not cached
+	_, err = r.objectReference("service/other/nginx")
+	assert.Error(t, err)
+}
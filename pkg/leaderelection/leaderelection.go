@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/tools/leaderelection"
+)
+
+var leader = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "external_dns",
+		Subsystem: "controller",
+		Name:      "leader",
+		Help:      "Whether this instance holds the leader election lease (0: standby, 1: leader).",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(leader)
+}
+
+// Run calls run only while this instance holds the lease of the given leader election config, whose callbacks
+// are overwritten. The context passed to run is canceled when the lease is lost, after which the election is
+// joined again, until ctx is canceled. Standby instances keep everything started before Run warm, so they can
+// take over as soon as the lease expires.
+func Run(ctx context.Context, config leaderelection.LeaderElectionConfig, run func(ctx context.Context)) error {
+	identity := config.Lock.Identity()
+	config.ReleaseOnCancel = true
+	for ctx.Err() == nil {
+		leading := make(chan context.Context, 1)
+		config.Callbacks = leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				leading <- ctx
+			},
+			OnStoppedLeading: func() {
+				leader.Set(0)
+			},
+			OnNewLeader: func(current string) {
+				if current != identity {
+					log.Infof("Waiting for leader election lease %s, currently held by %s", config.Lock.Describe(), current)
+				}
+			},
+		}
+
+		elector, err := leaderelection.NewLeaderElector(config)
+		if err != nil {
+			return err
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			elector.Run(ctx)
+		}()
+
+		select {
+		case leaderCtx := <-leading:
+			log.Infof("Acquired leader election lease %s as %s", config.Lock.Describe(), identity)
+			leader.Set(1)
+			// run is called in this goroutine, so that it has returned before the election is joined again and
+			// cannot overlap with the new leader
+			run(leaderCtx)
+			<-done
+			if ctx.Err() == nil {
+				log.Warnf("Lost leader election lease %s", config.Lock.Describe())
+			}
+		case <-done:
+		}
+	}
+	return nil
+}
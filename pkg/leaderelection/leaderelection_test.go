@@ -0,0 +1,147 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+func testConfig(client *fake.Clientset) leaderelection.LeaderElectionConfig {
+	return leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Name: "external-dns", Namespace: "default"},
+			Client:     client.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: "replica-a"},
+		},
+		LeaseDuration: time.Second,
+		RenewDeadline: 500 * time.Millisecond,
+		RetryPeriod:   100 * time.Millisecond,
+	}
+}
+
+func TestRun(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var running atomic.Bool
+	done := make(chan error)
+	go func() {
+		done <- Run(ctx, testConfig(client), func(ctx context.Context) {
+			running.Store(true)
+			<-ctx.Done()
+			running.Store(false)
+		})
+	}()
+
+	assert.Eventually(t, running.Load, 5*time.Second, 10*time.Millisecond)
+	lease, err := client.CoordinationV1().Leases("default").Get(context.Background(), "external-dns", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "replica-a", *lease.Spec.HolderIdentity)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("leader election did not stop")
+	}
+	assert.False(t, running.Load())
+
+	// the lease is released on shutdown, so that a standby replica takes over immediately
+	lease, err = client.CoordinationV1().Leases("default").Get(context.Background(), "external-dns", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, *lease.Spec.HolderIdentity)
+}
+
+func TestRunReacquire(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var started, stopped atomic.Int32
+	done := make(chan error)
+	go func() {
+		done <- Run(ctx, testConfig(client), func(ctx context.Context) {
+			started.Add(1)
+			<-ctx.Done()
+			stopped.Add(1)
+		})
+	}()
+	assert.Eventually(t, func() bool { return started.Load() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	// another replica takes the lease over, e.g. after a network partition, until run is stopped
+	holder := "replica-b"
+	assert.Eventually(t, func() bool {
+		if stopped.Load() == 0 {
+			lease, err := client.CoordinationV1().Leases("default").Get(context.Background(), "external-dns", metav1.GetOptions{})
+			require.NoError(t, err)
+			lease.Spec.HolderIdentity = &holder
+			lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
+			_, err = client.CoordinationV1().Leases("default").Update(context.Background(), lease, metav1.UpdateOptions{})
+			require.NoError(t, err)
+		}
+		return stopped.Load() == 1
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// the lease of the other replica expires, and run is called again
+	assert.Eventually(t, func() bool { return started.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("leader election did not stop")
+	}
+}
+
+func TestRunStandby(t *testing.T) {
+	holder := "replica-b"
+	duration := int32(60)
+	client := fake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "external-dns", Namespace: "default"},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			AcquireTime:          &metav1.MicroTime{Time: time.Now()},
+			RenewTime:            &metav1.MicroTime{Time: time.Now()},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	ran := false
+	require.NoError(t, Run(ctx, testConfig(client), func(context.Context) { ran = true }))
+	assert.False(t, ran)
+}
+
+func TestRunInvalidConfig(t *testing.T) {
+	config := testConfig(fake.NewSimpleClientset())
+	config.RenewDeadline = config.LeaseDuration
+
+	assert.Error(t, Run(context.Background(), config, func(context.Context) {}))
+}
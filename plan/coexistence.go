@@ -0,0 +1,121 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// coexistenceValidator refuses desired records which would break [RFC 1034 3.6.2]: a DNS name with a CNAME
+// record cannot have any other record. Conflicts amongst the desired records are left to the ConflictResolver,
+// the validator checks the desired records against the current records which stay in place, the zone apexes and
+// the ownership records of the registry.
+//
+// [RFC 1034 3.6.2]: https://datatracker.ietf.org/doc/html/rfc1034#autoid-15
+type coexistenceValidator struct {
+	plan *Plan
+	// current are all current records, including unmanaged ones, by normalized DNS name
+	current map[string][]*endpoint.Endpoint
+	// apexes are the normalized names of the zones
+	apexes map[string]bool
+}
+
+func newCoexistenceValidator(p *Plan) coexistenceValidator {
+	v := coexistenceValidator{
+		plan:    p,
+		current: map[string][]*endpoint.Endpoint{},
+		apexes:  map[string]bool{},
+	}
+	for _, c := range p.Current {
+		name := normalizeDNSName(c.DNSName)
+		v.current[name] = append(v.current[name], c)
+	}
+	for _, zone := range p.ZoneNames {
+		v.apexes[normalizeDNSName(zone)] = true
+	}
+	return v
+}
+
+// validate returns why the desired record cannot be created, or an empty string if it can
+func (v coexistenceValidator) validate(desired *endpoint.Endpoint) string {
+	// records acting as a CNAME are stored as different record types by the provider
+	if alias, ok := desired.GetProviderSpecificProperty("alias"); ok && alias == "true" {
+		return ""
+	}
+
+	name := normalizeDNSName(desired.DNSName)
+	current := v.current[name]
+	for _, c := range current {
+		// an existing record is updated in place, any conflict already exists at the provider
+		if c.RecordType == desired.RecordType && c.SetIdentifier == desired.SetIdentifier {
+			return ""
+		}
+	}
+
+	if desired.RecordType != endpoint.RecordTypeCNAME {
+		for _, c := range current {
+			if c.RecordType == endpoint.RecordTypeCNAME && v.remains(c) {
+				return fmt.Sprintf("%s records cannot coexist with the existing CNAME record", desired.RecordType)
+			}
+			// an ALIAS record is published as the address records of its target
+			if isAliasConflict(desired.RecordType, c.RecordType) && v.remains(c) {
+				return fmt.Sprintf("%s records cannot coexist with the existing %s record", desired.RecordType, c.RecordType)
+			}
+		}
+		return ""
+	}
+
+	if v.apexes[name] && !v.plan.AllowApexCNAME {
+		return "CNAME records are not allowed at the zone apex"
+	}
+	for _, c := range current {
+		if c.RecordType != endpoint.RecordTypeCNAME && v.remains(c) {
+			return fmt.Sprintf("CNAME records cannot coexist with the existing %s record", c.RecordType)
+		}
+	}
+	if v.plan.OwnershipRecordNames != nil {
+		for _, ownershipName := range v.plan.OwnershipRecordNames(desired) {
+			if normalizeDNSName(ownershipName) == name {
+				return "CNAME records cannot coexist with the TXT record the registry stores their ownership in, use a TXT prefix or suffix"
+			}
+		}
+	}
+	return ""
+}
+
+// isAliasConflict returns true if one of the record types is ALIAS and the other one an address record
+func isAliasConflict(a, b string) bool {
+	isAddress := func(recordType string) bool {
+		return recordType == endpoint.RecordTypeA || recordType == endpoint.RecordTypeAAAA
+	}
+	return (a == endpoint.RecordTypeALIAS && isAddress(b)) || (b == endpoint.RecordTypeALIAS && isAddress(a))
+}
+
+// remains returns true if the current record is left in place by the plan, because it is not managed by this
+// instance
+func (v coexistenceValidator) remains(current *endpoint.Endpoint) bool {
+	if !IsManagedRecord(current.RecordType, v.plan.ManagedRecords, v.plan.ExcludeRecords) {
+		return true
+	}
+	if v.plan.OwnerID == "" || current.IsOwnedBy(v.plan.OwnerID) {
+		return false
+	}
+	_, multiOwner := v.plan.ConflictResolver.(releaser)
+	return !multiOwner || !current.IsShared()
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestCalculateCNAMECoexistence(t *testing.T) {
+	sameNameTXT := func(ep *endpoint.Endpoint) []string {
+		return []string{ep.DNSName, "cname-" + ep.DNSName}
+	}
+	prefixedTXT := func(ep *endpoint.Endpoint) []string {
+		return []string{"txt." + ep.DNSName}
+	}
+
+	for _, tc := range []struct {
+		name           string
+		current        []*endpoint.Endpoint
+		desired        []*endpoint.Endpoint
+		allowApex      bool
+		ownershipNames func(ep *endpoint.Endpoint) []string
+		expected       []string
+		expectedCreate int
+	}{
+		{
+			name: "CNAME at the zone apex",
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("example.org", endpoint.RecordTypeCNAME, "ingress/default/a", "", 0, "lb.example.com"),
+			},
+			expected: []string{"example.org CNAME: cname-conflict, CNAME records are not allowed at the zone apex"},
+		},
+		{
+			name: "CNAME at the zone apex allowed",
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("example.org", endpoint.RecordTypeCNAME, "ingress/default/a", "", 0, "lb.example.com"),
+			},
+			allowApex:      true,
+			expected:       []string{"example.org CNAME: created"},
+			expectedCreate: 1,
+		},
+		{
+			name: "CNAME alongside an unmanaged MX record",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeMX, "", "", 0, "10 mail.example.org"),
+			},
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "ingress/default/a", "", 0, "lb.example.com"),
+			},
+			expected: []string{"foo.example.org CNAME: cname-conflict, CNAME records cannot coexist with the existing MX record"},
+		},
+		{
+			name: "CNAME alongside an A record owned by another instance",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "", "other", 0, "1.1.1.1"),
+			},
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "ingress/default/a", "", 0, "lb.example.com"),
+			},
+			expected: []string{
+				`foo.example.org A: owned-by-other, record is owned by "other"`,
+				"foo.example.org CNAME: cname-conflict, CNAME records cannot coexist with the existing A record",
+			},
+		},
+		{
+			name: "CNAME replacing an owned A record",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "", "owner", 0, "1.1.1.1"),
+			},
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "ingress/default/a", "", 0, "lb.example.com"),
+			},
+			expected: []string{
+				"foo.example.org A: deleted, no longer desired",
+				"foo.example.org CNAME: created",
+			},
+			expectedCreate: 1,
+		},
+		{
+			name: "A record alongside an unmanaged CNAME record",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "", "", 0, "lb.example.com"),
+			},
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "ingress/default/a", "", 0, "1.1.1.1"),
+			},
+			expected: []string{
+				`foo.example.org CNAME: owned-by-other, record is owned by ""`,
+				"foo.example.org A: cname-conflict, A records cannot coexist with the existing CNAME record",
+			},
+		},
+		{
+			name: "ALIAS at the zone apex",
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("example.org", endpoint.RecordTypeALIAS, "ingress/default/a", "", 0, "lb.example.com"),
+			},
+			expected:       []string{"example.org ALIAS: created"},
+			expectedCreate: 1,
+		},
+		{
+			name: "ALIAS alongside an A record owned by another instance",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("example.org", endpoint.RecordTypeA, "", "other", 0, "1.1.1.1"),
+			},
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("example.org", endpoint.RecordTypeALIAS, "ingress/default/a", "", 0, "lb.example.com"),
+			},
+			expected: []string{
+				`example.org A: owned-by-other, record is owned by "other"`,
+				"example.org ALIAS: cname-conflict, ALIAS records cannot coexist with the existing A record",
+			},
+		},
+		{
+			name: "CNAME alongside the TXT record of the registry",
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "ingress/default/a", "", 0, "lb.example.com"),
+			},
+			ownershipNames: sameNameTXT,
+			expected:       []string{"foo.example.org CNAME: cname-conflict, CNAME records cannot coexist with the TXT record the registry stores their ownership in, use a TXT prefix or suffix"},
+		},
+		{
+			name: "CNAME with a prefixed TXT record of the registry",
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "ingress/default/a", "", 0, "lb.example.com"),
+			},
+			ownershipNames: prefixedTXT,
+			expected:       []string{"foo.example.org CNAME: created"},
+			expectedCreate: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			p := &Plan{
+				Current:              tc.current,
+				Desired:              tc.desired,
+				ManagedRecords:       []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+				OwnerID:              "owner",
+				ZoneNames:            []string{"example.org"},
+				AllowApexCNAME:       tc.allowApex,
+				OwnershipRecordNames: tc.ownershipNames,
+			}
+
+			calculated := p.Calculate()
+			var decisions []string
+			for _, d := range calculated.Decisions {
+				decisions = append(decisions, d.String())
+			}
+			assert.ElementsMatch(t, tc.expected, decisions)
+			assert.Len(t, calculated.Changes.Create, tc.expectedCreate)
+		})
+	}
+}
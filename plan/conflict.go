@@ -18,6 +18,8 @@ package plan
 
 import (
 	"sort"
+	"strconv"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -32,6 +34,15 @@ type ConflictResolver interface {
 	ResolveRecordTypes(key planKey, row *planTableRow) map[string]*domainEndpoints
 }
 
+// ConflictResolvers is a registry of available conflict resolvers.
+var ConflictResolvers = map[string]ConflictResolver{
+	"per-resource":  PerResource{},
+	"oldest":        OldestResource{},
+	"priority":      Priority{},
+	"merge-targets": MergeTargets{},
+	"multi-owner":   MultiOwner{},
+}
+
 // PerResource allows only one resource to own a given dns name
 type PerResource struct{}
 
@@ -122,4 +133,244 @@ func (s PerResource) less(x, y *endpoint.Endpoint) bool {
 	return x.Targets.IsLess(y.Targets)
 }
 
-// TODO: with cross-resource/cross-cluster setup alternative variations of ConflictResolver can be used
+// OldestResource gives a dns name to the resource which was created first, based on the
+// creation timestamp label set by the sources. Endpoints without a valid creation timestamp
+// are considered the newest. Ties are broken the same way as PerResource.
+type OldestResource struct {
+	PerResource
+}
+
+// ResolveCreate picks the candidate of the oldest resource
+func (s OldestResource) ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	var oldest *endpoint.Endpoint
+	for _, ep := range candidates {
+		if oldest == nil || s.older(ep, oldest) {
+			oldest = ep
+		}
+	}
+	return oldest
+}
+
+// ResolveUpdate picks the candidate of the oldest resource, so the current record
+// is taken over as soon as an older resource claims the dns name
+func (s OldestResource) ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return s.ResolveCreate(candidates)
+}
+
+// older returns true if endpoint x belongs to an older resource than y
+func (s OldestResource) older(x, y *endpoint.Endpoint) bool {
+	xCreated, xOk := creationTimestamp(x)
+	yCreated, yOk := creationTimestamp(y)
+	switch {
+	case xOk != yOk:
+		return xOk
+	case xOk && !xCreated.Equal(yCreated):
+		return xCreated.Before(yCreated)
+	}
+	return s.less(x, y)
+}
+
+func creationTimestamp(ep *endpoint.Endpoint) (time.Time, bool) {
+	value, ok := ep.Labels[endpoint.CreationTimestampLabelKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	created, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Debugf("Ignoring invalid creation timestamp %q of %s", value, ep)
+		return time.Time{}, false
+	}
+	return created, true
+}
+
+// Priority gives a dns name to the resource with the highest priority, as defined by the
+// priority label set by the sources. Endpoints without a valid priority have a priority of 0.
+// Amongst the candidates with the highest priority, the resource is chosen the same way as PerResource.
+type Priority struct {
+	PerResource
+}
+
+// ResolveCreate picks the minimal candidate amongst the ones with the highest priority
+func (s Priority) ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return s.PerResource.ResolveCreate(s.highest(candidates))
+}
+
+// ResolveUpdate keeps the current resource if it still has the highest priority
+func (s Priority) ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return s.PerResource.ResolveUpdate(current, s.highest(candidates))
+}
+
+// highest returns the candidates sharing the highest priority
+func (s Priority) highest(candidates []*endpoint.Endpoint) []*endpoint.Endpoint {
+	var result []*endpoint.Endpoint
+	var max int64
+	for _, ep := range candidates {
+		p := priority(ep)
+		if len(result) == 0 || p > max {
+			result = []*endpoint.Endpoint{ep}
+			max = p
+		} else if p == max {
+			result = append(result, ep)
+		}
+	}
+	return result
+}
+
+func priority(ep *endpoint.Endpoint) int64 {
+	value, ok := ep.Labels[endpoint.PriorityLabelKey]
+	if !ok {
+		return 0
+	}
+	p, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Debugf("Ignoring invalid priority %q of %s", value, ep)
+		return 0
+	}
+	return p
+}
+
+// MergeTargets publishes the targets of all the resources acquiring the same dns name for
+// A and AAAA records. The other record types can not be merged and are resolved like PerResource.
+type MergeTargets struct {
+	PerResource
+}
+
+// ResolveCreate returns the minimal candidate with the targets of all the candidates
+func (s MergeTargets) ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return s.merge(s.PerResource.ResolveCreate(candidates), candidates)
+}
+
+// ResolveUpdate returns the candidate of the current resource with the targets of all the candidates
+func (s MergeTargets) ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return s.merge(s.PerResource.ResolveUpdate(current, candidates), candidates)
+}
+
+// merge returns a copy of base with the union of the targets of the candidates
+func (s MergeTargets) merge(base *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	if base == nil || len(candidates) <= 1 {
+		return base
+	}
+	if base.RecordType != endpoint.RecordTypeA && base.RecordType != endpoint.RecordTypeAAAA {
+		return base
+	}
+
+	targets := make([]endpoint.Targets, 0, len(candidates))
+	for _, ep := range candidates {
+		targets = append(targets, ep.Targets)
+	}
+
+	merged := base.DeepCopy()
+	merged.Targets = unionTargets(targets...)
+	return merged
+}
+
+// ownerResolver is implemented by conflict resolvers which need to know the owner ID of the plan.
+type ownerResolver interface {
+	forOwner(ownerID string) ConflictResolver
+}
+
+// releaser is implemented by conflict resolvers which let several owners share a record.
+type releaser interface {
+	// ResolveRelease is invoked when the owner of the plan no longer desires the "current" record.
+	// It returns the record left to the other owners, or nil if the record has to be deleted.
+	ResolveRelease(current *endpoint.Endpoint) *endpoint.Endpoint
+}
+
+// MultiOwner lets several instances of ExternalDNS, each with its own owner ID, publish the same A and AAAA records.
+// The targets contributed by each owner are tracked in the labels of the record (see endpoint.OwnerTargetsLabelPrefix)
+// and the published targets are the union of them. An owner only removes its own targets once it stops desiring them.
+// Within an owner, the targets of all the resources are merged like MergeTargets. Other record types are resolved
+// like PerResource and can not be shared.
+type MultiOwner struct {
+	MergeTargets
+	// ownerID is the owner whose targets are resolved, set by the plan
+	ownerID string
+}
+
+func (s MultiOwner) forOwner(ownerID string) ConflictResolver {
+	s.ownerID = ownerID
+	return s
+}
+
+// ResolveCreate returns the merged candidates, recording them as the contribution of the owner
+func (s MultiOwner) ResolveCreate(candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return s.contribute(nil, s.MergeTargets.ResolveCreate(candidates))
+}
+
+// ResolveUpdate returns the merged candidates together with the targets contributed by the other owners of "current"
+func (s MultiOwner) ResolveUpdate(current *endpoint.Endpoint, candidates []*endpoint.Endpoint) *endpoint.Endpoint {
+	return s.contribute(current, s.MergeTargets.ResolveUpdate(current, candidates))
+}
+
+// ResolveRelease removes the contribution of the owner from "current"
+func (s MultiOwner) ResolveRelease(current *endpoint.Endpoint) *endpoint.Endpoint {
+	if !current.IsShared() {
+		return nil
+	}
+	contributions := current.Labels.OwnerTargets()
+	delete(contributions, s.ownerID)
+	if len(contributions) == 0 {
+		return nil
+	}
+
+	rest := current.DeepCopy()
+	rest.Labels.SetOwnerTargets(s.ownerID, nil)
+	rest.Targets = unionTargets(ownedTargets(contributions)...)
+	return rest
+}
+
+// contribute records the targets of "desired" as the contribution of the owner, and adds the targets
+// contributed by the other owners of "current"
+func (s MultiOwner) contribute(current, desired *endpoint.Endpoint) *endpoint.Endpoint {
+	if desired == nil || s.ownerID == "" || !isAddressRecord(desired) {
+		return desired
+	}
+	contributions := map[string]endpoint.Targets{}
+	if current != nil {
+		if !current.IsShared() && !current.IsOwnedBy(s.ownerID) {
+			// the record belongs to an owner which does not share it
+			return desired
+		}
+		contributions = current.Labels.OwnerTargets()
+	}
+	contributions[s.ownerID] = desired.Targets
+
+	shared := desired.DeepCopy()
+	if shared.Labels == nil {
+		shared.Labels = endpoint.NewLabels()
+	}
+	for ownerID, targets := range contributions {
+		shared.Labels.SetOwnerTargets(ownerID, targets)
+	}
+	shared.Targets = unionTargets(ownedTargets(contributions)...)
+	return shared
+}
+
+func isAddressRecord(ep *endpoint.Endpoint) bool {
+	return ep.RecordType == endpoint.RecordTypeA || ep.RecordType == endpoint.RecordTypeAAAA
+}
+
+// ownedTargets returns the targets contributed by each owner
+func ownedTargets(contributions map[string]endpoint.Targets) []endpoint.Targets {
+	targets := make([]endpoint.Targets, 0, len(contributions))
+	for _, owned := range contributions {
+		targets = append(targets, owned)
+	}
+	return targets
+}
+
+// unionTargets returns the sorted union of the given targets
+func unionTargets(targets ...endpoint.Targets) endpoint.Targets {
+	seen := map[string]bool{}
+	union := endpoint.Targets{}
+	for _, ts := range targets {
+		for _, target := range ts {
+			if !seen[target] {
+				seen[target] = true
+				union = append(union, target)
+			}
+		}
+	}
+	sort.Sort(union)
+	return union
+}
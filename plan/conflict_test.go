@@ -24,7 +24,13 @@ import (
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
-var _ ConflictResolver = PerResource{}
+var (
+	_ ConflictResolver = PerResource{}
+	_ ConflictResolver = OldestResource{}
+	_ ConflictResolver = Priority{}
+	_ ConflictResolver = MergeTargets{}
+	_ ConflictResolver = MultiOwner{}
+)
 
 type ResolverSuite struct {
 	// resolvers
@@ -292,6 +298,53 @@ func (suite *ResolverSuite) TestPerResource_ResolveRecordTypes() {
 	}
 }
 
+func (suite *ResolverSuite) TestOldestResource() {
+	resolver := OldestResource{}
+	older := withLabel(suite.bar192A, endpoint.CreationTimestampLabelKey, "2023-01-01T00:00:00Z")
+	newer := withLabel(suite.bar127A, endpoint.CreationTimestampLabelKey, "2024-01-01T00:00:00Z")
+	invalid := withLabel(suite.bar127AAnother, endpoint.CreationTimestampLabelKey, "yesterday")
+
+	suite.Equal(older, resolver.ResolveCreate([]*endpoint.Endpoint{newer, older}), "should pick the oldest resource")
+	suite.Equal(newer, resolver.ResolveCreate([]*endpoint.Endpoint{suite.bar192A, newer}), "should prefer resources with a creation timestamp")
+	suite.Equal(newer, resolver.ResolveCreate([]*endpoint.Endpoint{invalid, newer}), "should ignore invalid creation timestamps")
+	suite.Equal(suite.bar127A, resolver.ResolveCreate([]*endpoint.Endpoint{suite.bar192A, suite.bar127A}), "should pick min one without creation timestamps")
+	suite.Equal(older, resolver.ResolveUpdate(newer, []*endpoint.Endpoint{newer, older}), "should hand the record over to the oldest resource")
+}
+
+func (suite *ResolverSuite) TestPriority() {
+	resolver := Priority{}
+	high := withLabel(suite.bar192A, endpoint.PriorityLabelKey, "10")
+	low := withLabel(suite.bar127A, endpoint.PriorityLabelKey, "-1")
+	invalid := withLabel(suite.bar127AAnother, endpoint.PriorityLabelKey, "high")
+
+	suite.Equal(high, resolver.ResolveCreate([]*endpoint.Endpoint{low, high}), "should pick the highest priority")
+	suite.Equal(invalid, resolver.ResolveCreate([]*endpoint.Endpoint{low, invalid}), "should consider invalid priorities as 0")
+	suite.Equal(high, resolver.ResolveUpdate(suite.bar127A, []*endpoint.Endpoint{suite.bar127A, high}), "should take over the record with a higher priority")
+	suite.Equal(suite.bar192A, resolver.ResolveUpdate(suite.bar192A, []*endpoint.Endpoint{suite.bar127A, suite.bar192A}), "should pick existing resource amongst the same priority")
+}
+
+func (suite *ResolverSuite) TestMergeTargets() {
+	resolver := MergeTargets{}
+
+	merged := resolver.ResolveCreate([]*endpoint.Endpoint{suite.bar192A, suite.bar127A, suite.bar127AAnother})
+	suite.Equal(endpoint.Targets{"127.0.0.1", "192.168.0.1", "8.8.8.8"}, merged.Targets, "should union the targets")
+	suite.Equal("ingress/default/bar-127", merged.Labels[endpoint.ResourceLabelKey], "should keep the labels of the min one")
+	suite.Equal(endpoint.Targets{"127.0.0.1"}, suite.bar127A.Targets, "should not modify the candidates")
+
+	merged = resolver.ResolveUpdate(suite.bar192A, []*endpoint.Endpoint{suite.bar127A, suite.bar192A})
+	suite.Equal(endpoint.Targets{"127.0.0.1", "192.168.0.1"}, merged.Targets, "should union the targets")
+	suite.Equal("ingress/default/bar-192", merged.Labels[endpoint.ResourceLabelKey], "should keep the labels of the existing resource")
+
+	suite.Equal(suite.fooV1Cname, resolver.ResolveCreate([]*endpoint.Endpoint{suite.fooV2Cname, suite.fooV1Cname}), "should not merge CNAME records")
+	suite.Equal(suite.bar127A, resolver.ResolveCreate([]*endpoint.Endpoint{suite.bar127A}), "should return a single candidate as is")
+}
+
+func withLabel(ep *endpoint.Endpoint, key, value string) *endpoint.Endpoint {
+	ep = ep.DeepCopy()
+	ep.Labels[key] = value
+	return ep
+}
+
 func TestConflictResolver(t *testing.T) {
 	suite.Run(t, new(ResolverSuite))
 }
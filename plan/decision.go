@@ -0,0 +1,200 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// DecisionReason is the outcome of planning a record
+type DecisionReason string
+
+const (
+	DecisionCreated             DecisionReason = "created"
+	DecisionUpdated             DecisionReason = "updated"
+	DecisionUnchanged           DecisionReason = "unchanged"
+	DecisionDeleted             DecisionReason = "deleted"
+	DecisionPendingDelete       DecisionReason = "pending-delete"
+	DecisionReleased            DecisionReason = "released"
+	DecisionOwnedByOther        DecisionReason = "owned-by-other"
+	DecisionCNAMEConflict       DecisionReason = "cname-conflict"
+	DecisionConflictLost        DecisionReason = "conflict-lost"
+	DecisionFilteredByDomain    DecisionReason = "filtered-by-domain"
+	DecisionUnmanagedRecordType DecisionReason = "unmanaged-record-type"
+	DecisionSkippedByPolicy     DecisionReason = "skipped-by-policy"
+	DecisionInvalidTarget       DecisionReason = "invalid-target"
+	DecisionInvalidName         DecisionReason = "invalid-name"
+	DecisionInvalidTTL          DecisionReason = "invalid-ttl"
+	DecisionInvalidProperty     DecisionReason = "invalid-provider-specific"
+)
+
+// rejectedDecision returns the decision reason of an endpoint refused by endpoint.Validate
+func rejectedDecision(reason endpoint.ValidationReason) DecisionReason {
+	switch reason {
+	case endpoint.ValidationReasonInvalidName:
+		return DecisionInvalidName
+	case endpoint.ValidationReasonInvalidTTL:
+		return DecisionInvalidTTL
+	case endpoint.ValidationReasonInvalidProviderSpecific:
+		return DecisionInvalidProperty
+	}
+	return DecisionInvalidTarget
+}
+
+// Decision explains what the planner did with a desired or current record
+type Decision struct {
+	DNSName       string         `json:"dnsName"`
+	SetIdentifier string         `json:"setIdentifier,omitempty"`
+	RecordType    string         `json:"recordType"`
+	Resource      string         `json:"resource,omitempty"`
+	Owner         string         `json:"owner,omitempty"`
+	Reason        DecisionReason `json:"reason"`
+	Message       string         `json:"message,omitempty"`
+
+	// change is the record added to the changes for this decision, if any
+	change *endpoint.Endpoint
+}
+
+func (d Decision) String() string {
+	s := fmt.Sprintf("%s %s", d.DNSName, d.RecordType)
+	if d.SetIdentifier != "" {
+		s += " (set-identifier: " + d.SetIdentifier + ")"
+	}
+	s += ": " + string(d.Reason)
+	if d.Message != "" {
+		s += ", " + d.Message
+	}
+	return s
+}
+
+// decisionLog collects the decisions taken while calculating a plan
+type decisionLog struct {
+	decisions []Decision
+}
+
+// add records a decision about the given record. change is the record added to the changes, or nil if the
+// decision does not change anything.
+func (l *decisionLog) add(ep *endpoint.Endpoint, reason DecisionReason, change *endpoint.Endpoint, format string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+	l.decisions = append(l.decisions, Decision{
+		DNSName:       ep.DNSName,
+		SetIdentifier: ep.SetIdentifier,
+		RecordType:    ep.RecordType,
+		Resource:      ep.Labels[endpoint.ResourceLabelKey],
+		Owner:         labelOf(endpoint.OwnerLabelKey, ep, change),
+		Reason:        reason,
+		Message:       fmt.Sprintf(format, args...),
+		change:        change,
+	})
+}
+
+// addOwned records a decision which does not change anything about a record held by the given owner
+func (l *decisionLog) addOwned(ep *endpoint.Endpoint, reason DecisionReason, owner, format string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+	l.add(ep, reason, nil, format, args...)
+	l.decisions[len(l.decisions)-1].Owner = owner
+}
+
+// conflictLosers records the candidates which neither won the conflict resolution nor contributed to the
+// resolved record
+func (l *decisionLog) conflictLosers(resolved *endpoint.Endpoint, candidates []*endpoint.Endpoint) {
+	winner := resolved.Labels[endpoint.ResourceLabelKey]
+	for _, c := range candidates {
+		if c == resolved || c.Labels[endpoint.ResourceLabelKey] == winner || containsTargets(resolved.Targets, c.Targets) {
+			continue
+		}
+		l.add(c, DecisionConflictLost, nil, "resolved in favor of %q", winner)
+	}
+}
+
+// dropped replaces the reason of every decision whose change is not part of the changes anymore
+func (l *decisionLog) dropped(changes *Changes, reason DecisionReason, message func(*endpoint.Endpoint) string) {
+	if l == nil {
+		return
+	}
+	planned := map[*endpoint.Endpoint]bool{}
+	for _, list := range [][]*endpoint.Endpoint{changes.Create, changes.UpdateOld, changes.Delete} {
+		for _, ep := range list {
+			planned[ep] = true
+		}
+	}
+	for i, d := range l.decisions {
+		if d.change == nil || planned[d.change] {
+			continue
+		}
+		l.decisions[i].Reason = reason
+		l.decisions[i].Message = message(d.change)
+		l.decisions[i].Owner = d.change.Labels[endpoint.OwnerLabelKey]
+		l.decisions[i].change = nil
+	}
+}
+
+// sorted returns the decisions sorted by DNS name, set identifier, record type and resource
+func (l *decisionLog) sorted() []Decision {
+	if l == nil {
+		return nil
+	}
+	decisions := append([]Decision{}, l.decisions...)
+	sort.SliceStable(decisions, func(i, j int) bool {
+		a, b := decisions[i], decisions[j]
+		if a.DNSName != b.DNSName {
+			return a.DNSName < b.DNSName
+		}
+		if a.SetIdentifier != b.SetIdentifier {
+			return a.SetIdentifier < b.SetIdentifier
+		}
+		if a.RecordType != b.RecordType {
+			return a.RecordType < b.RecordType
+		}
+		return a.Resource < b.Resource
+	})
+	return decisions
+}
+
+// containsTargets returns true if all targets are part of the given set of targets
+func containsTargets(set, targets endpoint.Targets) bool {
+	for _, t := range targets {
+		found := false
+		for _, s := range set {
+			if strings.EqualFold(s, t) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// joinReasons formats the reasons of an update
+func joinReasons(reasons []DiffReason) string {
+	s := make([]string, 0, len(reasons))
+	for _, r := range reasons {
+		s = append(s, string(r))
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func decisionEndpoint(dnsName, recordType, resource, owner string, ttl endpoint.TTL, targets ...string) *endpoint.Endpoint {
+	ep := endpoint.NewEndpointWithTTL(dnsName, recordType, ttl, targets...)
+	if resource != "" {
+		ep.Labels[endpoint.ResourceLabelKey] = resource
+	}
+	if owner != "" {
+		ep.Labels[endpoint.OwnerLabelKey] = owner
+	}
+	return ep
+}
+
+func TestCalculateDecisions(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		current  []*endpoint.Endpoint
+		desired  []*endpoint.Endpoint
+		policies []Policy
+		grace    time.Duration
+		expected []string
+	}{
+		{
+			name: "created and lost conflict",
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "ingress/default/a", "", 0, "1.1.1.1"),
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "ingress/default/b", "", 0, "2.2.2.2"),
+			},
+			expected: []string{
+				"foo.example.org A: created",
+				`foo.example.org A: conflict-lost, resolved in favor of "ingress/default/a"`,
+			},
+		},
+		{
+			name: "CNAME conflict",
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "ingress/default/a", "", 0, "1.1.1.1"),
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "ingress/default/b", "", 0, "lb.example.com"),
+			},
+			expected: []string{
+				"foo.example.org A: created",
+				"foo.example.org CNAME: cname-conflict, CNAME records cannot coexist with other record types",
+			},
+		},
+		{
+			name: "create skipped because the name is owned by another instance",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "", "other", 0, "1.1.1.1"),
+			},
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "ingress/default/a", "", 0, "1.1.1.1"),
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeAAAA, "ingress/default/a", "", 0, "2001:db8::1"),
+			},
+			expected: []string{
+				"foo.example.org A: unchanged",
+				`foo.example.org AAAA: owned-by-other, DNS name is owned by "other"`,
+			},
+		},
+		{
+			name: "updated and unchanged",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("bar.example.org", endpoint.RecordTypeA, "", "owner", 300, "1.1.1.1"),
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "", "owner", 300, "1.1.1.1"),
+			},
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("bar.example.org", endpoint.RecordTypeA, "ingress/default/a", "", 300, "1.1.1.1"),
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "ingress/default/a", "", 600, "1.1.1.1"),
+			},
+			expected: []string{
+				"bar.example.org A: unchanged",
+				"foo.example.org A: updated, ttl changed",
+			},
+		},
+		{
+			name: "deletes of records owned by other instances are dropped",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("bar.example.org", endpoint.RecordTypeA, "", "owner", 0, "1.1.1.1"),
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "", "other", 0, "1.1.1.1"),
+			},
+			expected: []string{
+				"bar.example.org A: deleted, no longer desired",
+				`foo.example.org A: owned-by-other, record is owned by "other"`,
+			},
+		},
+		{
+			name: "filtered records",
+			desired: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.com", endpoint.RecordTypeA, "ingress/default/a", "", 0, "1.1.1.1"),
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeTXT, "ingress/default/a", "", 0, "text"),
+			},
+			expected: []string{
+				"foo.example.com A: filtered-by-domain, DNS name does not match the domain filter",
+				"foo.example.org TXT: unmanaged-record-type, record type TXT is not managed",
+			},
+		},
+		{
+			name: "delete not allowed by policy",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "", "owner", 0, "1.1.1.1"),
+			},
+			policies: []Policy{&UpsertOnlyPolicy{}},
+			expected: []string{
+				"foo.example.org A: skipped-by-policy, change is not allowed by the policies",
+			},
+		},
+		{
+			name: "pending deletion",
+			current: []*endpoint.Endpoint{
+				decisionEndpoint("foo.example.org", endpoint.RecordTypeA, "", "owner", 0, "1.1.1.1"),
+			},
+			grace:    5 * time.Minute,
+			expected: []string{"foo.example.org A: pending-delete, marked for deletion in 5m0s"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			domainFilter := endpoint.NewDomainFilter([]string{"example.org"})
+			p := &Plan{
+				Policies:            tc.policies,
+				Current:             tc.current,
+				Desired:             tc.desired,
+				DomainFilter:        endpoint.MatchAllDomainFilters{&domainFilter},
+				ManagedRecords:      []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+				OwnerID:             "owner",
+				DeletionGracePeriod: tc.grace,
+			}
+
+			var decisions []string
+			for _, d := range p.Calculate().Decisions {
+				decisions = append(decisions, d.String())
+			}
+			assert.Equal(t, tc.expected, decisions)
+		})
+	}
+}
+
+func TestCalculateRejectedDecisions(t *testing.T) {
+	rejected := decisionEndpoint("app.example.org", endpoint.RecordTypeA, "service/default/app", "", -1, "1.2.3.4")
+	p := &Plan{
+		Rejected: []*endpoint.ValidationError{
+			{Endpoint: rejected, Reason: endpoint.ValidationReasonInvalidTTL, Err: errors.New("TTL -1 is not between 0 and 2147483647")},
+		},
+		ManagedRecords: []string{endpoint.RecordTypeA},
+	}
+
+	calculated := p.Calculate()
+	require.Len(t, calculated.Decisions, 1)
+	assert.Equal(t, DecisionInvalidTTL, calculated.Decisions[0].Reason)
+	assert.Equal(t, "service/default/app", calculated.Decisions[0].Resource)
+	assert.Equal(t, "TTL -1 is not between 0 and 2147483647", calculated.Decisions[0].Message)
+	assert.False(t, calculated.Changes.HasChanges())
+}
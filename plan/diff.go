@@ -0,0 +1,327 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// DiffAction is the action planned for a record
+type DiffAction string
+
+const (
+	DiffActionCreate DiffAction = "create"
+	DiffActionUpdate DiffAction = "update"
+	DiffActionDelete DiffAction = "delete"
+)
+
+// DiffReason describes which part of a record an update changes
+type DiffReason string
+
+const (
+	DiffReasonTargets          DiffReason = "targets"
+	DiffReasonTTL              DiffReason = "ttl"
+	DiffReasonProviderSpecific DiffReason = "provider-specific"
+	DiffReasonOwnerTargets     DiffReason = "owner-targets"
+	DiffReasonPendingDelete    DiffReason = "pending-delete"
+	DiffReasonLabels           DiffReason = "labels"
+)
+
+// DiffFormat is a serialization format of a Diff
+type DiffFormat string
+
+const (
+	DiffFormatText DiffFormat = "text"
+	DiffFormatJSON DiffFormat = "json"
+	DiffFormatYAML DiffFormat = "yaml"
+)
+
+// DiffFormatForPath returns the format matching the extension of the given path, defaulting to text
+func DiffFormatForPath(path string) DiffFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return DiffFormatJSON
+	case ".yaml", ".yml":
+		return DiffFormatYAML
+	default:
+		return DiffFormatText
+	}
+}
+
+// RecordValues holds the values of a record which are compared by the planner
+type RecordValues struct {
+	Targets          endpoint.Targets          `json:"targets,omitempty"`
+	TTL              endpoint.TTL              `json:"ttl,omitempty"`
+	ProviderSpecific endpoint.ProviderSpecific `json:"providerSpecific,omitempty"`
+}
+
+// RecordDiff is a single planned change of a record
+type RecordDiff struct {
+	Action        DiffAction    `json:"action"`
+	DNSName       string        `json:"dnsName"`
+	RecordType    string        `json:"recordType"`
+	SetIdentifier string        `json:"setIdentifier,omitempty"`
+	Owner         string        `json:"owner,omitempty"`
+	Resource      string        `json:"resource,omitempty"`
+	Reasons       []DiffReason  `json:"reasons,omitempty"`
+	Old           *RecordValues `json:"old,omitempty"`
+	New           *RecordValues `json:"new,omitempty"`
+}
+
+// DiffSummary counts the planned changes by action
+type DiffSummary struct {
+	Create int `json:"create"`
+	Update int `json:"update"`
+	Delete int `json:"delete"`
+}
+
+// Diff is a stable, serializable representation of Changes
+type Diff struct {
+	Summary DiffSummary  `json:"summary"`
+	Records []RecordDiff `json:"records"`
+}
+
+// Diff returns the changes as a Diff, sorted by record name, type, set identifier and action
+func (c *Changes) Diff() *Diff {
+	d := &Diff{Records: []RecordDiff{}}
+	if c == nil {
+		return d
+	}
+
+	for _, ep := range c.Create {
+		d.Records = append(d.Records, newRecordDiff(DiffActionCreate, nil, ep))
+	}
+	for i := range c.UpdateNew {
+		if i >= len(c.UpdateOld) {
+			break
+		}
+		d.Records = append(d.Records, newRecordDiff(DiffActionUpdate, c.UpdateOld[i], c.UpdateNew[i]))
+	}
+	for _, ep := range c.Delete {
+		d.Records = append(d.Records, newRecordDiff(DiffActionDelete, ep, nil))
+	}
+
+	sort.SliceStable(d.Records, func(i, j int) bool {
+		a, b := d.Records[i], d.Records[j]
+		if a.DNSName != b.DNSName {
+			return a.DNSName < b.DNSName
+		}
+		if a.RecordType != b.RecordType {
+			return a.RecordType < b.RecordType
+		}
+		if a.SetIdentifier != b.SetIdentifier {
+			return a.SetIdentifier < b.SetIdentifier
+		}
+		return actionOrder(a.Action) < actionOrder(b.Action)
+	})
+
+	for _, r := range d.Records {
+		switch r.Action {
+		case DiffActionCreate:
+			d.Summary.Create++
+		case DiffActionUpdate:
+			d.Summary.Update++
+		case DiffActionDelete:
+			d.Summary.Delete++
+		}
+	}
+
+	return d
+}
+
+// Encode serializes the diff in the given format
+func (d *Diff) Encode(format DiffFormat) ([]byte, error) {
+	switch format {
+	case DiffFormatJSON:
+		return json.MarshalIndent(d, "", "  ")
+	case DiffFormatYAML:
+		return yaml.Marshal(d)
+	case DiffFormatText:
+		return []byte(d.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown plan diff format %q", format)
+	}
+}
+
+// String renders the diff in a human-readable form
+func (d *Diff) String() string {
+	var b strings.Builder
+	for _, r := range d.Records {
+		b.WriteString(r.String())
+	}
+	fmt.Fprintf(&b, "Plan: %d to create, %d to update, %d to delete.\n", d.Summary.Create, d.Summary.Update, d.Summary.Delete)
+	return b.String()
+}
+
+// String renders the record diff in a human-readable form
+func (r RecordDiff) String() string {
+	var b strings.Builder
+
+	symbol := map[DiffAction]string{DiffActionCreate: "+", DiffActionUpdate: "~", DiffActionDelete: "-"}[r.Action]
+	fmt.Fprintf(&b, "%s %s %s %s", symbol, r.Action, r.DNSName, r.RecordType)
+	if r.SetIdentifier != "" {
+		fmt.Fprintf(&b, " (set-identifier: %s)", r.SetIdentifier)
+	}
+	if len(r.Reasons) > 0 {
+		fmt.Fprintf(&b, " [%s]", joinReasons(r.Reasons))
+	}
+	b.WriteString("\n")
+
+	if r.Owner != "" {
+		fmt.Fprintf(&b, "    owner: %s\n", r.Owner)
+	}
+	if r.Resource != "" {
+		fmt.Fprintf(&b, "    resource: %s\n", r.Resource)
+	}
+
+	previous, next := &RecordValues{}, &RecordValues{}
+	if r.Old != nil {
+		previous = r.Old
+	}
+	if r.New != nil {
+		next = r.New
+	}
+	writeValue(&b, "targets", r.Old != nil, r.New != nil, previous.Targets.String(), next.Targets.String())
+	writeValue(&b, "ttl", r.Old != nil, r.New != nil, formatTTL(previous.TTL), formatTTL(next.TTL))
+	writeValue(&b, "provider-specific", r.Old != nil, r.New != nil, formatProviderSpecific(previous.ProviderSpecific), formatProviderSpecific(next.ProviderSpecific))
+
+	return b.String()
+}
+
+// writeValue writes a value of a record, showing both the current and desired value if it changed
+func writeValue(b *strings.Builder, name string, hasCurrent, hasDesired bool, current, desired string) {
+	switch {
+	case hasCurrent && hasDesired && desired != "" && current != desired:
+		if current == "" {
+			current = "<none>"
+		}
+		fmt.Fprintf(b, "    %s: %s -> %s\n", name, current, desired)
+	case hasDesired && desired != "":
+		fmt.Fprintf(b, "    %s: %s\n", name, desired)
+	case hasCurrent && current != "":
+		fmt.Fprintf(b, "    %s: %s\n", name, current)
+	}
+}
+
+func newRecordDiff(action DiffAction, current, desired *endpoint.Endpoint) RecordDiff {
+	ep := desired
+	if ep == nil {
+		ep = current
+	}
+	r := RecordDiff{
+		Action:        action,
+		DNSName:       ep.DNSName,
+		RecordType:    ep.RecordType,
+		SetIdentifier: ep.SetIdentifier,
+		Owner:         labelOf(endpoint.OwnerLabelKey, current, desired),
+		Resource:      labelOf(endpoint.ResourceLabelKey, desired, current),
+	}
+	if current != nil {
+		r.Old = recordValues(current)
+	}
+	if desired != nil {
+		r.New = recordValues(desired)
+	}
+	if current != nil && desired != nil {
+		r.Reasons = updateReasons(current, desired)
+	}
+	return r
+}
+
+// updateReasons returns the parts of the record which differ between the current and desired record
+func updateReasons(current, desired *endpoint.Endpoint) []DiffReason {
+	reasons := []DiffReason{}
+	if targetChanged(desired, current) {
+		reasons = append(reasons, DiffReasonTargets)
+	}
+	if shouldUpdateTTL(desired, current) {
+		reasons = append(reasons, DiffReasonTTL)
+	}
+	if shouldUpdateProviderSpecific(desired, current) {
+		reasons = append(reasons, DiffReasonProviderSpecific)
+	}
+	if ownerTargetsChanged(desired, current) {
+		reasons = append(reasons, DiffReasonOwnerTargets)
+	}
+	if isPendingDelete(current) != isPendingDelete(desired) {
+		reasons = append(reasons, DiffReasonPendingDelete)
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, DiffReasonLabels)
+	}
+	return reasons
+}
+
+func recordValues(ep *endpoint.Endpoint) *RecordValues {
+	values := &RecordValues{
+		Targets: append(endpoint.Targets{}, ep.Targets...),
+		TTL:     ep.RecordTTL,
+	}
+	sort.Strings(values.Targets)
+	if len(ep.ProviderSpecific) > 0 {
+		values.ProviderSpecific = append(endpoint.ProviderSpecific{}, ep.ProviderSpecific...)
+		sort.SliceStable(values.ProviderSpecific, func(i, j int) bool {
+			return values.ProviderSpecific[i].Name < values.ProviderSpecific[j].Name
+		})
+	}
+	return values
+}
+
+// labelOf returns the value of the label on the first endpoint which has it set
+func labelOf(key string, endpoints ...*endpoint.Endpoint) string {
+	for _, ep := range endpoints {
+		if ep != nil && ep.Labels[key] != "" {
+			return ep.Labels[key]
+		}
+	}
+	return ""
+}
+
+func formatTTL(ttl endpoint.TTL) string {
+	if !ttl.IsConfigured() {
+		return ""
+	}
+	return fmt.Sprintf("%d", ttl)
+}
+
+func formatProviderSpecific(properties endpoint.ProviderSpecific) string {
+	pairs := make([]string, 0, len(properties))
+	for _, p := range properties {
+		pairs = append(pairs, p.Name+"="+p.Value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
+
+func actionOrder(action DiffAction) int {
+	switch action {
+	case DiffActionDelete:
+		return 0
+	case DiffActionCreate:
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func testDiffChanges() *Changes {
+	owned := func(ep *endpoint.Endpoint) *endpoint.Endpoint {
+		ep.Labels[endpoint.OwnerLabelKey] = "owner"
+		ep.Labels[endpoint.ResourceLabelKey] = "ingress/default/app"
+		return ep
+	}
+	created := endpoint.NewEndpoint("new.example.org", endpoint.RecordTypeA, "2.2.2.2", "1.1.1.1")
+	created.Labels[endpoint.ResourceLabelKey] = "service/default/new"
+	return &Changes{
+		Create: []*endpoint.Endpoint{
+			created,
+		},
+		UpdateOld: []*endpoint.Endpoint{
+			owned(endpoint.NewEndpointWithTTL("ttl.example.org", endpoint.RecordTypeCNAME, 300, "lb.example.com")),
+			owned(endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.1.1.1")),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			owned(endpoint.NewEndpointWithTTL("ttl.example.org", endpoint.RecordTypeCNAME, 600, "lb.example.com")),
+			owned(endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "3.3.3.3").WithProviderSpecific("alias", "false")),
+		},
+		Delete: []*endpoint.Endpoint{
+			owned(endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeAAAA, "2001:db8::1")),
+		},
+	}
+}
+
+func TestChangesDiff(t *testing.T) {
+	diff := testDiffChanges().Diff()
+
+	assert.Equal(t, DiffSummary{Create: 1, Update: 2, Delete: 1}, diff.Summary)
+	require.Len(t, diff.Records, 4)
+
+	update := diff.Records[0]
+	assert.Equal(t, DiffActionUpdate, update.Action)
+	assert.Equal(t, "app.example.org", update.DNSName)
+	assert.Equal(t, "owner", update.Owner)
+	assert.Equal(t, "ingress/default/app", update.Resource)
+	assert.Equal(t, []DiffReason{DiffReasonTargets, DiffReasonProviderSpecific}, update.Reasons)
+	assert.Equal(t, endpoint.Targets{"1.1.1.1"}, update.Old.Targets)
+	assert.Equal(t, endpoint.Targets{"3.3.3.3"}, update.New.Targets)
+
+	del := diff.Records[1]
+	assert.Equal(t, DiffActionDelete, del.Action)
+	assert.Equal(t, endpoint.RecordTypeAAAA, del.RecordType)
+	assert.Nil(t, del.New)
+
+	create := diff.Records[2]
+	assert.Equal(t, DiffActionCreate, create.Action)
+	assert.Empty(t, create.Owner)
+	assert.Equal(t, "service/default/new", create.Resource)
+	assert.Equal(t, endpoint.Targets{"1.1.1.1", "2.2.2.2"}, create.New.Targets)
+	assert.Nil(t, create.Old)
+
+	assert.Equal(t, []DiffReason{DiffReasonTTL}, diff.Records[3].Reasons)
+}
+
+func TestChangesDiffProviderSpecificMatchesPlanner(t *testing.T) {
+	current := endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.1.1.1").WithProviderSpecific("alias", "false")
+	// the planner only considers the last value of a duplicated property
+	desired := endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.1.1.1")
+	desired.ProviderSpecific = endpoint.ProviderSpecific{{Name: "alias", Value: "true"}, {Name: "alias", Value: "false"}}
+	require.False(t, shouldUpdateProviderSpecific(desired, current))
+
+	diff := (&Changes{UpdateOld: []*endpoint.Endpoint{current}, UpdateNew: []*endpoint.Endpoint{desired}}).Diff()
+	require.Len(t, diff.Records, 1)
+	assert.NotContains(t, diff.Records[0].Reasons, DiffReasonProviderSpecific)
+}
+
+func TestChangesDiffString(t *testing.T) {
+	expected := `~ update app.example.org A [targets, provider-specific]
+    owner: owner
+    resource: ingress/default/app
+    targets: 1.1.1.1 -> 3.3.3.3
+    provider-specific: <none> -> alias=false
+- delete app.example.org AAAA
+    owner: owner
+    resource: ingress/default/app
+    targets: 2001:db8::1
++ create new.example.org A
+    resource: service/default/new
+    targets: 1.1.1.1;2.2.2.2
+~ update ttl.example.org CNAME [ttl]
+    owner: owner
+    resource: ingress/default/app
+    targets: lb.example.com
+    ttl: 300 -> 600
+Plan: 1 to create, 2 to update, 1 to delete.
+`
+	assert.Equal(t, expected, testDiffChanges().Diff().String())
+	assert.Equal(t, "Plan: 0 to create, 0 to update, 0 to delete.\n", (&Changes{}).Diff().String())
+}
+
+func TestDiffEncode(t *testing.T) {
+	diff := testDiffChanges().Diff()
+
+	data, err := diff.Encode(DiffFormatJSON)
+	require.NoError(t, err)
+	decoded := &Diff{}
+	require.NoError(t, json.Unmarshal(data, decoded))
+	assert.Equal(t, diff, decoded)
+
+	data, err = diff.Encode(DiffFormatYAML)
+	require.NoError(t, err)
+	decoded = &Diff{}
+	require.NoError(t, yaml.Unmarshal(data, decoded))
+	assert.Equal(t, diff, decoded)
+
+	data, err = diff.Encode(DiffFormatText)
+	require.NoError(t, err)
+	assert.Equal(t, diff.String(), string(data))
+
+	_, err = diff.Encode("xml")
+	assert.Error(t, err)
+}
+
+func TestDiffFormatForPath(t *testing.T) {
+	assert.Equal(t, DiffFormatJSON, DiffFormatForPath("plan.json"))
+	assert.Equal(t, DiffFormatYAML, DiffFormatForPath("/tmp/plan.yaml"))
+	assert.Equal(t, DiffFormatYAML, DiffFormatForPath("plan.YML"))
+	assert.Equal(t, DiffFormatText, DiffFormatForPath("plan.txt"))
+	assert.Equal(t, DiffFormatText, DiffFormatForPath("plan"))
+}
@@ -19,6 +19,7 @@ package plan
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	log "github.com/sirupsen/logrus"
@@ -49,6 +50,25 @@ type Plan struct {
 	ExcludeRecords []string
 	// OwnerID of records to manage
 	OwnerID string
+	// DeletionGracePeriod is the time a record has to stay undesired before it gets deleted, zero to delete
+	// records as soon as they are undesired
+	DeletionGracePeriod time.Duration
+	// ConflictResolver decides which desired record acquires a DNS name claimed by several resources,
+	// PerResource is used if not set
+	ConflictResolver ConflictResolver
+	// ZoneNames are the names of the DNS zones, no CNAME records are created at their apex. If nil, e.g. because
+	// the provider does not list its zones, CNAME records are not checked against the zone apex
+	ZoneNames []string
+	// AllowApexCNAME allows CNAME records at the zone apex, for providers which flatten them
+	AllowApexCNAME bool
+	// OwnershipRecordNames returns the names of the records the registry stores the ownership of a record in, if any
+	OwnershipRecordNames func(ep *endpoint.Endpoint) []string
+	// Rejected are the desired endpoints refused by endpoint.Validate before planning, they are only recorded
+	// as decisions
+	Rejected []*endpoint.ValidationError
+	// Decisions explain the outcome for every desired and current record
+	// Populated after calling Calculate()
+	Decisions []Decision
 }
 
 // Changes holds lists of actions to be executed by dns providers
@@ -61,6 +81,9 @@ type Changes struct {
 	UpdateNew []*endpoint.Endpoint
 	// Records that need to be deleted
 	Delete []*endpoint.Endpoint
+	// Target deltas of the updates, by the key of their desired record. Updates without a delta have to be
+	// rewritten completely. The map is not serialized, its keys are not strings.
+	TargetDeltas map[endpoint.EndpointKey]TargetDelta `json:"-"`
 }
 
 // planKey is a key for a row in `planTable`.
@@ -92,8 +115,14 @@ type planTable struct {
 	resolver ConflictResolver
 }
 
-func newPlanTable() planTable { // TODO: make resolver configurable
-	return planTable{map[planKey]*planTableRow{}, PerResource{}}
+func newPlanTable(resolver ConflictResolver, ownerID string) planTable {
+	if resolver == nil {
+		resolver = PerResource{}
+	}
+	if r, ok := resolver.(ownerResolver); ok {
+		resolver = r.forOwner(ownerID)
+	}
+	return planTable{map[planKey]*planTableRow{}, resolver}
 }
 
 // planTableRow represents a set of current and desired domain resource records.
@@ -154,6 +183,76 @@ func (t *planTable) newPlanKey(e *endpoint.Endpoint) planKey {
 	return key
 }
 
+// release deletes the current record, unless the resolver leaves it to other owners
+func (p *Plan) release(t planTable, changes *Changes, decisions *decisionLog, current *endpoint.Endpoint) {
+	r, ok := t.resolver.(releaser)
+	if !ok {
+		p.delete(changes, decisions, current)
+		return
+	}
+	rest := r.ResolveRelease(current)
+	if rest == nil {
+		p.delete(changes, decisions, current)
+		return
+	}
+	delete(rest.Labels, endpoint.PendingDeleteLabelKey)
+	if targetChanged(rest, current) || ownerTargetsChanged(rest, current) || isPendingDelete(current) {
+		changes.UpdateNew = append(changes.UpdateNew, rest)
+		changes.UpdateOld = append(changes.UpdateOld, current)
+		decisions.add(current, DecisionReleased, current, "left to the other owners")
+		return
+	}
+	decisions.add(current, DecisionReleased, nil, "left to the other owners")
+}
+
+// delete deletes the current record. With a deletion grace period, the record is first marked as pending
+// deletion and only deleted once it has been undesired for the whole period.
+func (p *Plan) delete(changes *Changes, decisions *decisionLog, current *endpoint.Endpoint) {
+	if p.DeletionGracePeriod <= 0 {
+		changes.Delete = append(changes.Delete, current)
+		decisions.add(current, DecisionDeleted, current, "no longer desired")
+		return
+	}
+
+	now := time.Now()
+	if value, ok := current.Labels[endpoint.PendingDeleteLabelKey]; ok {
+		since, err := time.Parse(time.RFC3339, value)
+		switch {
+		case err != nil:
+			log.Debugf("Ignoring invalid pending deletion timestamp %q of %s", value, current)
+		case now.Sub(since) < p.DeletionGracePeriod:
+			log.Debugf("Keeping %s pending deletion since %s", current, value)
+			decisions.add(current, DecisionPendingDelete, nil, "pending deletion since %s", value)
+			return
+		default:
+			changes.Delete = append(changes.Delete, current)
+			decisions.add(current, DecisionDeleted, current, "deletion grace period since %s expired", value)
+			return
+		}
+	}
+
+	pending := current.DeepCopy()
+	if pending.Labels == nil {
+		pending.Labels = endpoint.NewLabels()
+	}
+	pending.Labels[endpoint.PendingDeleteLabelKey] = now.UTC().Format(time.RFC3339)
+	changes.UpdateNew = append(changes.UpdateNew, pending)
+	changes.UpdateOld = append(changes.UpdateOld, current)
+	decisions.add(current, DecisionPendingDelete, current, "marked for deletion in %s", p.DeletionGracePeriod)
+}
+
+// isPendingDelete returns true if the record is marked to be deleted after the deletion grace period
+func isPendingDelete(ep *endpoint.Endpoint) bool {
+	_, ok := ep.Labels[endpoint.PendingDeleteLabelKey]
+	return ok
+}
+
+// isShareable returns true if the resolver lets the owner of the plan join the owners of the current record
+func (t planTable) isShareable(current *endpoint.Endpoint) bool {
+	_, ok := t.resolver.(releaser)
+	return ok && current.IsShared()
+}
+
 func (c *Changes) HasChanges() bool {
 	if len(c.Create) > 0 || len(c.Delete) > 0 {
 		return true
@@ -165,16 +264,26 @@ func (c *Changes) HasChanges() bool {
 // state. It then passes those changes to the current policy for further
 // processing. It returns a copy of Plan with the changes populated.
 func (p *Plan) Calculate() *Plan {
-	t := newPlanTable()
+	t := newPlanTable(p.ConflictResolver, p.OwnerID)
+	decisions := &decisionLog{}
 
 	if p.DomainFilter == nil {
 		p.DomainFilter = endpoint.MatchAllDomainFilters(nil)
 	}
 
-	for _, current := range filterRecordsForPlan(p.Current, p.DomainFilter, p.ManagedRecords, p.ExcludeRecords) {
+	for _, current := range filterRecordsForPlan(p.Current, p.DomainFilter, p.ManagedRecords, p.ExcludeRecords, nil) {
 		t.addCurrent(current)
 	}
-	for _, desired := range filterRecordsForPlan(p.Desired, p.DomainFilter, p.ManagedRecords, p.ExcludeRecords) {
+	for _, rejected := range p.Rejected {
+		decisions.add(rejected.Endpoint, rejectedDecision(rejected.Reason), nil, "%v", rejected.Err)
+	}
+	coexistence := newCoexistenceValidator(p)
+	for _, desired := range filterRecordsForPlan(asciiNames(p.Desired, decisions), p.DomainFilter, p.ManagedRecords, p.ExcludeRecords, decisions) {
+		if reason := coexistence.validate(desired); reason != "" {
+			log.Warnf("Refusing %s %s: %s", desired.DNSName, desired.RecordType, reason)
+			decisions.add(desired, DecisionCNAMEConflict, nil, "%s", reason)
+			continue
+		}
 		t.addCandidate(desired)
 	}
 
@@ -184,16 +293,22 @@ func (p *Plan) Calculate() *Plan {
 		// dns name not taken
 		if len(row.current) == 0 {
 			recordsByType := t.resolver.ResolveRecordTypes(key, row)
+			recordDiscardedTypes(decisions, row, recordsByType)
 			for _, records := range recordsByType {
 				if len(records.candidates) > 0 {
-					changes.Create = append(changes.Create, t.resolver.ResolveCreate(records.candidates))
+					create := t.resolver.ResolveCreate(records.candidates)
+					changes.Create = append(changes.Create, create)
+					decisions.add(create, DecisionCreated, create, "")
+					decisions.conflictLosers(create, records.candidates)
 				}
 			}
 		}
 
 		// dns name released or possibly owned by a different external dns
 		if len(row.current) > 0 && len(row.candidates) == 0 {
-			changes.Delete = append(changes.Delete, row.current...)
+			for _, current := range row.current {
+				p.release(t, changes, decisions, current)
+			}
 		}
 
 		// dns name is taken
@@ -202,10 +317,11 @@ func (p *Plan) Calculate() *Plan {
 
 			// apply changes for each record type
 			recordsByType := t.resolver.ResolveRecordTypes(key, row)
+			recordDiscardedTypes(decisions, row, recordsByType)
 			for _, records := range recordsByType {
 				// record type not desired
 				if records.current != nil && len(records.candidates) == 0 {
-					changes.Delete = append(changes.Delete, records.current)
+					p.release(t, changes, decisions, records.current)
 				}
 
 				// new record type desired
@@ -215,16 +331,21 @@ func (p *Plan) Calculate() *Plan {
 					// validate that this external dns has ownership claim on the domain before
 					// adding the records to planned changes.
 					creates = append(creates, update)
+					decisions.conflictLosers(update, records.candidates)
 				}
 
 				// update existing record
 				if records.current != nil && len(records.candidates) > 0 {
 					update := t.resolver.ResolveUpdate(records.current, records.candidates)
+					decisions.conflictLosers(update, records.candidates)
 
-					if shouldUpdateTTL(update, records.current) || targetChanged(update, records.current) || p.shouldUpdateProviderSpecific(update, records.current) {
+					if shouldUpdateTTL(update, records.current) || targetChanged(update, records.current) || ownerTargetsChanged(update, records.current) || isPendingDelete(records.current) || shouldUpdateProviderSpecific(update, records.current) {
 						inheritOwner(records.current, update)
 						changes.UpdateNew = append(changes.UpdateNew, update)
 						changes.UpdateOld = append(changes.UpdateOld, records.current)
+						decisions.add(update, DecisionUpdated, records.current, "%s changed", joinReasons(updateReasons(records.current, update)))
+					} else {
+						decisions.addOwned(update, DecisionUnchanged, records.current.Labels[endpoint.OwnerLabelKey], "")
 					}
 				}
 			}
@@ -232,40 +353,76 @@ func (p *Plan) Calculate() *Plan {
 			if len(creates) > 0 {
 				// only add creates if the external dns has ownership claim on the domain
 				ownersMatch := true
+				owner := ""
 				for _, current := range row.current {
-					if p.OwnerID != "" && !current.IsOwnedBy(p.OwnerID) {
+					if p.OwnerID != "" && !current.IsOwnedBy(p.OwnerID) && !t.isShareable(current) {
 						ownersMatch = false
+						owner = current.Labels[endpoint.OwnerLabelKey]
 					}
 				}
 
 				if ownersMatch {
 					changes.Create = append(changes.Create, creates...)
+					for _, create := range creates {
+						decisions.add(create, DecisionCreated, create, "")
+					}
+				} else {
+					for _, create := range creates {
+						decisions.addOwned(create, DecisionOwnedByOther, owner, "DNS name is owned by %q", owner)
+					}
 				}
 			}
 		}
 	}
 
-	for _, pol := range p.Policies {
-		changes = pol.Apply(changes)
-	}
-
 	// filter out updates this external dns does not have ownership claim over
 	if p.OwnerID != "" {
-		changes.Delete = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.Delete)
-		changes.UpdateOld = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.UpdateOld)
-		changes.UpdateNew = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.UpdateNew)
+		if _, ok := t.resolver.(releaser); ok {
+			// the owner also releases and joins the records it shares with other owners
+			changes.Delete = endpoint.FilterSharedEndpointsByOwnerID(p.OwnerID, changes.Delete)
+			changes.UpdateOld, changes.UpdateNew = endpoint.FilterSharedUpdatesByOwnerID(p.OwnerID, changes.UpdateOld, changes.UpdateNew)
+		} else {
+			changes.Delete = endpoint.FilterEndpointsByOwnerID(p.OwnerID, changes.Delete)
+			changes.UpdateOld, changes.UpdateNew = endpoint.FilterUpdatesByOwnerID(p.OwnerID, changes.UpdateOld, changes.UpdateNew)
+		}
+		decisions.dropped(changes, DecisionOwnedByOther, func(ep *endpoint.Endpoint) string {
+			return fmt.Sprintf("record is owned by %q", ep.Labels[endpoint.OwnerLabelKey])
+		})
+	}
+
+	// policies are applied to the changes this external dns owns only, so that limits apply to the actual changes
+	for _, pol := range p.Policies {
+		changes = pol.Apply(changes)
 	}
+	decisions.dropped(changes, DecisionSkippedByPolicy, func(*endpoint.Endpoint) string {
+		return "change is not allowed by the policies"
+	})
+	changes.TargetDeltas = targetDeltas(changes.UpdateOld, changes.UpdateNew)
 
 	plan := &Plan{
 		Current:        p.Current,
 		Desired:        p.Desired,
 		Changes:        changes,
 		ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeAAAA, endpoint.RecordTypeCNAME},
+		Decisions:      decisions.sorted(),
 	}
 
 	return plan
 }
 
+// recordDiscardedTypes records the candidates of the record types the resolver discarded to avoid a conflict
+// with a CNAME record
+func recordDiscardedTypes(decisions *decisionLog, row *planTableRow, recordsByType map[string]*domainEndpoints) {
+	for recordType, records := range row.records {
+		if resolved, ok := recordsByType[recordType]; ok && len(resolved.candidates) > 0 {
+			continue
+		}
+		for _, c := range records.candidates {
+			decisions.add(c, DecisionCNAMEConflict, nil, "CNAME records cannot coexist with other record types")
+		}
+	}
+}
+
 func inheritOwner(from, to *endpoint.Endpoint) {
 	if to.Labels == nil {
 		to.Labels = map[string]string{}
@@ -277,7 +434,23 @@ func inheritOwner(from, to *endpoint.Endpoint) {
 }
 
 func targetChanged(desired, current *endpoint.Endpoint) bool {
-	return !desired.Targets.Same(current.Targets)
+	// SRV and MX targets are compared field by field, "10 mail.example.org." and "10  mail.example.org" are the same
+	return !desired.Targets.Normalized(desired.RecordType).Same(current.Targets.Normalized(current.RecordType))
+}
+
+// ownerTargetsChanged returns true if the targets contributed by the owners of a shared record changed
+func ownerTargetsChanged(desired, current *endpoint.Endpoint) bool {
+	desiredTargets := desired.Labels.OwnerTargets()
+	currentTargets := current.Labels.OwnerTargets()
+	if len(desiredTargets) != len(currentTargets) {
+		return true
+	}
+	for ownerID, targets := range desiredTargets {
+		if !targets.Same(currentTargets[ownerID]) {
+			return true
+		}
+	}
+	return false
 }
 
 func shouldUpdateTTL(desired, current *endpoint.Endpoint) bool {
@@ -287,7 +460,7 @@ func shouldUpdateTTL(desired, current *endpoint.Endpoint) bool {
 	return desired.RecordTTL != current.RecordTTL
 }
 
-func (p *Plan) shouldUpdateProviderSpecific(desired, current *endpoint.Endpoint) bool {
+func shouldUpdateProviderSpecific(desired, current *endpoint.Endpoint) bool {
 	desiredProperties := map[string]endpoint.ProviderSpecificProperty{}
 
 	for _, d := range desired.ProviderSpecific {
@@ -314,27 +487,53 @@ func (p *Plan) shouldUpdateProviderSpecific(desired, current *endpoint.Endpoint)
 // Per RFC 1034, CNAME records conflict with all other records - it is the
 // only record with this property. The behavior of the planner may need to be
 // made more sophisticated to codify this.
-func filterRecordsForPlan(records []*endpoint.Endpoint, domainFilter endpoint.MatchAllDomainFilters, managedRecords, excludeRecords []string) []*endpoint.Endpoint {
+func filterRecordsForPlan(records []*endpoint.Endpoint, domainFilter endpoint.MatchAllDomainFilters, managedRecords, excludeRecords []string, decisions *decisionLog) []*endpoint.Endpoint {
 	filtered := []*endpoint.Endpoint{}
 
 	for _, record := range records {
 		// Ignore records that do not match the domain filter provided
 		if !domainFilter.Match(record.DNSName) {
 			log.Debugf("ignoring record %s that does not match domain filter", record.DNSName)
+			decisions.add(record, DecisionFilteredByDomain, nil, "DNS name does not match the domain filter")
 			continue
 		}
 		if IsManagedRecord(record.RecordType, managedRecords, excludeRecords) {
 			filtered = append(filtered, record)
+		} else {
+			decisions.add(record, DecisionUnmanagedRecordType, nil, "record type %s is not managed", record.RecordType)
 		}
 	}
 
 	return filtered
 }
 
+// asciiNames refuses the desired endpoints with an invalid DNS name and converts internationalized names to punycode,
+// so that they match the names of the current records and are passed to the provider in its ASCII form
+func asciiNames(endpoints []*endpoint.Endpoint, decisions *decisionLog) []*endpoint.Endpoint {
+	valid := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if err := endpoint.ValidateName(ep.RecordType, ep.DNSName); err != nil {
+			log.Warnf("Refusing %s %s: %v", ep.DNSName, ep.RecordType, err)
+			decisions.add(ep, DecisionInvalidName, nil, "%v", err)
+			continue
+		}
+		if name, err := endpoint.ToASCIIName(ep.DNSName); err == nil && name != strings.ToLower(strings.TrimSuffix(ep.DNSName, ".")) {
+			converted := *ep
+			converted.DNSName = name
+			ep = &converted
+		}
+		valid = append(valid, ep)
+	}
+	return valid
+}
+
 // normalizeDNSName converts a DNS name to a canonical form, so that we can use string equality
-// it: removes space, converts to lower case, ensures there is a trailing dot
+// it: removes space, converts to lower case and internationalized labels to punycode, ensures there is a trailing dot
 func normalizeDNSName(dnsName string) string {
 	s := strings.TrimSpace(strings.ToLower(dnsName))
+	if name, err := endpoint.ToASCIIName(s); err == nil {
+		s = name
+	}
 	if !strings.HasSuffix(s, ".") {
 		s += "."
 	}
@@ -352,5 +551,9 @@ func IsManagedRecord(record string, managedRecords, excludeRecords []string) boo
 			return true
 		}
 	}
+	// ALIAS records are published as address records, they are managed along with them
+	if record == endpoint.RecordTypeALIAS {
+		return IsManagedRecord(endpoint.RecordTypeA, managedRecords, excludeRecords)
+	}
 	return false
 }
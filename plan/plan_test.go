@@ -18,8 +18,10 @@ package plan
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
 	"sigs.k8s.io/external-dns/endpoint"
@@ -659,10 +661,40 @@ func (suite *PlanTestSuite) TestNoCurrentWithConflictingDesired() {
 	validateEntries(suite.T(), changes.Delete, expectedDelete)
 }
 
+func (suite *PlanTestSuite) TestConflictResolverMergeTargets() {
+	current := []*endpoint.Endpoint{suite.bar127A}
+	desired := []*endpoint.Endpoint{suite.bar127A, suite.bar192A}
+	expectedCreate := []*endpoint.Endpoint{}
+	expectedUpdateOld := []*endpoint.Endpoint{suite.bar127A}
+	expectedUpdateNew := []*endpoint.Endpoint{{
+		DNSName:    "bar",
+		Targets:    endpoint.Targets{"127.0.0.1", "192.168.0.1"},
+		RecordType: "A",
+		Labels: map[string]string{
+			endpoint.ResourceLabelKey: "ingress/default/bar-127",
+		},
+	}}
+	expectedDelete := []*endpoint.Endpoint{}
+
+	p := &Plan{
+		Policies:         []Policy{&SyncPolicy{}},
+		Current:          current,
+		Desired:          desired,
+		ManagedRecords:   []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		ConflictResolver: MergeTargets{},
+	}
+
+	changes := p.Calculate().Changes
+	validateEntries(suite.T(), changes.Create, expectedCreate)
+	validateEntries(suite.T(), changes.UpdateNew, expectedUpdateNew)
+	validateEntries(suite.T(), changes.UpdateOld, expectedUpdateOld)
+	validateEntries(suite.T(), changes.Delete, expectedDelete)
+}
+
 func (suite *PlanTestSuite) TestIgnoreTXT() {
 	current := []*endpoint.Endpoint{suite.fooV2TXT}
-	desired := []*endpoint.Endpoint{suite.fooV2Cname}
-	expectedCreate := []*endpoint.Endpoint{suite.fooV2Cname}
+	desired := []*endpoint.Endpoint{suite.fooA5}
+	expectedCreate := []*endpoint.Endpoint{suite.fooA5}
 	expectedUpdateOld := []*endpoint.Endpoint{}
 	expectedUpdateNew := []*endpoint.Endpoint{}
 	expectedDelete := []*endpoint.Endpoint{}
@@ -683,8 +715,8 @@ func (suite *PlanTestSuite) TestIgnoreTXT() {
 
 func (suite *PlanTestSuite) TestExcludeTXT() {
 	current := []*endpoint.Endpoint{suite.fooV2TXT}
-	desired := []*endpoint.Endpoint{suite.fooV2Cname}
-	expectedCreate := []*endpoint.Endpoint{suite.fooV2Cname}
+	desired := []*endpoint.Endpoint{suite.fooA5}
+	expectedCreate := []*endpoint.Endpoint{suite.fooA5}
 	expectedUpdateOld := []*endpoint.Endpoint{}
 	expectedUpdateNew := []*endpoint.Endpoint{}
 	expectedDelete := []*endpoint.Endpoint{}
@@ -1075,13 +1107,297 @@ func TestShouldUpdateProviderSpecific(tt *testing.T) {
 		},
 	} {
 		tt.Run(test.name, func(t *testing.T) {
-			plan := &Plan{
-				Current:        []*endpoint.Endpoint{test.current},
-				Desired:        []*endpoint.Endpoint{test.desired},
-				ManagedRecords: []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
-			}
-			b := plan.shouldUpdateProviderSpecific(test.desired, test.current)
+			b := shouldUpdateProviderSpecific(test.desired, test.current)
 			assert.Equal(t, test.shouldUpdate, b)
 		})
 	}
 }
+
+func TestMultiOwnerConflictResolver(t *testing.T) {
+	shared := func(owner string, contributions map[string]endpoint.Targets) *endpoint.Endpoint {
+		ep := &endpoint.Endpoint{
+			DNSName:    "shared",
+			RecordType: endpoint.RecordTypeA,
+			Labels:     endpoint.Labels{endpoint.OwnerLabelKey: owner},
+		}
+		for ownerID, targets := range contributions {
+			ep.Labels.SetOwnerTargets(ownerID, targets)
+			ep.Targets = append(ep.Targets, targets...)
+		}
+		return ep
+	}
+	desired := func(targets ...string) *endpoint.Endpoint {
+		return &endpoint.Endpoint{
+			DNSName:    "shared",
+			RecordType: endpoint.RecordTypeA,
+			Targets:    targets,
+			Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "service/default/app"},
+		}
+	}
+
+	for _, tt := range []struct {
+		name              string
+		current           []*endpoint.Endpoint
+		desired           []*endpoint.Endpoint
+		expectedCreate    []*endpoint.Endpoint
+		expectedUpdateNew []*endpoint.Endpoint
+		expectedDelete    []*endpoint.Endpoint
+	}{
+		{
+			name:           "create records the contribution of the owner",
+			desired:        []*endpoint.Endpoint{desired("2.2.2.2")},
+			expectedCreate: []*endpoint.Endpoint{shared("", map[string]endpoint.Targets{"cluster-b": {"2.2.2.2"}})},
+		},
+		{
+			name:              "join a shared record",
+			current:           []*endpoint.Endpoint{shared("cluster-a", map[string]endpoint.Targets{"cluster-a": {"1.1.1.1"}})},
+			desired:           []*endpoint.Endpoint{desired("2.2.2.2")},
+			expectedUpdateNew: []*endpoint.Endpoint{shared("cluster-a", map[string]endpoint.Targets{"cluster-a": {"1.1.1.1"}, "cluster-b": {"2.2.2.2"}})},
+		},
+		{
+			name:    "no changes if the contribution is up to date",
+			current: []*endpoint.Endpoint{shared("cluster-a", map[string]endpoint.Targets{"cluster-a": {"1.1.1.1"}, "cluster-b": {"2.2.2.2"}})},
+			desired: []*endpoint.Endpoint{desired("2.2.2.2")},
+		},
+		{
+			name:              "update only the own contribution",
+			current:           []*endpoint.Endpoint{shared("cluster-a", map[string]endpoint.Targets{"cluster-a": {"1.1.1.1"}, "cluster-b": {"2.2.2.2"}})},
+			desired:           []*endpoint.Endpoint{desired("3.3.3.3")},
+			expectedUpdateNew: []*endpoint.Endpoint{shared("cluster-a", map[string]endpoint.Targets{"cluster-a": {"1.1.1.1"}, "cluster-b": {"3.3.3.3"}})},
+		},
+		{
+			name:              "release the own contribution",
+			current:           []*endpoint.Endpoint{shared("cluster-a", map[string]endpoint.Targets{"cluster-a": {"1.1.1.1"}, "cluster-b": {"2.2.2.2"}})},
+			expectedUpdateNew: []*endpoint.Endpoint{shared("cluster-a", map[string]endpoint.Targets{"cluster-a": {"1.1.1.1"}})},
+		},
+		{
+			name:           "delete the record released by the last owner",
+			current:        []*endpoint.Endpoint{shared("cluster-a", map[string]endpoint.Targets{"cluster-b": {"2.2.2.2"}})},
+			expectedDelete: []*endpoint.Endpoint{shared("cluster-a", map[string]endpoint.Targets{"cluster-b": {"2.2.2.2"}})},
+		},
+		{
+			name:              "migrate a record owned before sharing",
+			current:           []*endpoint.Endpoint{shared("cluster-b", nil)},
+			desired:           []*endpoint.Endpoint{desired("2.2.2.2")},
+			expectedUpdateNew: []*endpoint.Endpoint{shared("cluster-b", map[string]endpoint.Targets{"cluster-b": {"2.2.2.2"}})},
+		},
+		{
+			name:    "do not join a record which is not shared",
+			current: []*endpoint.Endpoint{{DNSName: "shared", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}, Labels: endpoint.Labels{endpoint.OwnerLabelKey: "cluster-a"}}},
+			desired: []*endpoint.Endpoint{desired("2.2.2.2")},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plan{
+				Policies:         []Policy{&SyncPolicy{}},
+				Current:          tt.current,
+				Desired:          tt.desired,
+				ManagedRecords:   []string{endpoint.RecordTypeA},
+				OwnerID:          "cluster-b",
+				ConflictResolver: MultiOwner{},
+			}
+
+			changes := p.Calculate().Changes
+			assertSharedEndpoints(t, tt.expectedCreate, changes.Create)
+			assertSharedEndpoints(t, tt.expectedUpdateNew, changes.UpdateNew)
+			assert.Len(t, changes.UpdateOld, len(tt.expectedUpdateNew))
+			assertSharedEndpoints(t, tt.expectedDelete, changes.Delete)
+		})
+	}
+}
+
+func TestOwnerTargetsIgnoredWithoutMultiOwner(t *testing.T) {
+	current := &endpoint.Endpoint{
+		DNSName:    "shared",
+		RecordType: endpoint.RecordTypeA,
+		Targets:    endpoint.Targets{"1.1.1.1"},
+		Labels:     endpoint.Labels{endpoint.OwnerLabelKey: "cluster-a", endpoint.OwnerTargetsLabelPrefix + "cluster-b": "1.1.1.1"},
+	}
+	desired := &endpoint.Endpoint{
+		DNSName:    "shared",
+		RecordType: endpoint.RecordTypeA,
+		Targets:    endpoint.Targets{"2.2.2.2"},
+		Labels:     endpoint.Labels{endpoint.ResourceLabelKey: "service/default/app"},
+	}
+
+	for _, tt := range []struct {
+		name    string
+		desired []*endpoint.Endpoint
+	}{
+		{name: "do not update a record of another owner", desired: []*endpoint.Endpoint{desired}},
+		{name: "do not delete a record of another owner"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plan{
+				Policies:       []Policy{&SyncPolicy{}},
+				Current:        []*endpoint.Endpoint{current},
+				Desired:        tt.desired,
+				ManagedRecords: []string{endpoint.RecordTypeA},
+				OwnerID:        "cluster-b",
+			}
+
+			changes := p.Calculate().Changes
+			assert.False(t, changes.HasChanges(), "unexpected changes %v", changes)
+		})
+	}
+}
+
+func assertSharedEndpoints(t *testing.T, expected, actual []*endpoint.Endpoint) {
+	t.Helper()
+	if !assert.Len(t, actual, len(expected)) {
+		return
+	}
+	for i := range expected {
+		assert.True(t, expected[i].Targets.Same(actual[i].Targets), "expected targets %v, got %v", expected[i].Targets, actual[i].Targets)
+		assert.Equal(t, expected[i].Labels.OwnerTargets(), actual[i].Labels.OwnerTargets())
+		assert.Equal(t, expected[i].Labels[endpoint.OwnerLabelKey], actual[i].Labels[endpoint.OwnerLabelKey])
+	}
+}
+
+func TestDeletionGracePeriod(t *testing.T) {
+	current := func(labels endpoint.Labels) *endpoint.Endpoint {
+		labels[endpoint.OwnerLabelKey] = "owner"
+		return &endpoint.Endpoint{DNSName: "foo", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}, Labels: labels}
+	}
+	desired := &endpoint.Endpoint{DNSName: "foo", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.1.1.1"}, Labels: endpoint.Labels{}}
+	pendingSince := func(d time.Duration) endpoint.Labels {
+		return endpoint.Labels{endpoint.PendingDeleteLabelKey: time.Now().Add(-d).UTC().Format(time.RFC3339)}
+	}
+
+	for _, tt := range []struct {
+		name            string
+		current         *endpoint.Endpoint
+		desired         []*endpoint.Endpoint
+		expectedPending bool
+		expectedUpdate  bool
+		expectedDelete  bool
+	}{
+		{
+			name:            "undesired record is marked as pending deletion",
+			current:         current(endpoint.Labels{}),
+			expectedPending: true,
+			expectedUpdate:  true,
+		},
+		{
+			name:    "pending record is kept during the grace period",
+			current: current(pendingSince(time.Minute)),
+		},
+		{
+			name:           "pending record is deleted after the grace period",
+			current:        current(pendingSince(time.Hour)),
+			expectedDelete: true,
+		},
+		{
+			name:            "invalid pending deletion timestamp is reset",
+			current:         current(endpoint.Labels{endpoint.PendingDeleteLabelKey: "soon"}),
+			expectedPending: true,
+			expectedUpdate:  true,
+		},
+		{
+			name:           "pending record desired again is unmarked",
+			current:        current(pendingSince(time.Minute)),
+			desired:        []*endpoint.Endpoint{desired},
+			expectedUpdate: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Plan{
+				Policies:            []Policy{&SyncPolicy{}},
+				Current:             []*endpoint.Endpoint{tt.current},
+				Desired:             tt.desired,
+				ManagedRecords:      []string{endpoint.RecordTypeA},
+				OwnerID:             "owner",
+				DeletionGracePeriod: 30 * time.Minute,
+			}
+
+			changes := p.Calculate().Changes
+			assert.Empty(t, changes.Create)
+			if tt.expectedDelete {
+				assert.Equal(t, []*endpoint.Endpoint{tt.current}, changes.Delete)
+			} else {
+				assert.Empty(t, changes.Delete)
+			}
+			if !tt.expectedUpdate {
+				assert.Empty(t, changes.UpdateNew)
+				return
+			}
+			if assert.Len(t, changes.UpdateNew, 1) {
+				assert.Equal(t, []*endpoint.Endpoint{tt.current}, changes.UpdateOld)
+				assert.True(t, changes.UpdateNew[0].Targets.Same(tt.current.Targets))
+				assert.Equal(t, "owner", changes.UpdateNew[0].Labels[endpoint.OwnerLabelKey])
+				_, pending := changes.UpdateNew[0].Labels[endpoint.PendingDeleteLabelKey]
+				assert.Equal(t, tt.expectedPending, pending)
+			}
+		})
+	}
+}
+
+func TestCalculateStructuredTargets(t *testing.T) {
+	current := []*endpoint.Endpoint{
+		decisionEndpoint("_sip._tcp.example.org", endpoint.RecordTypeSRV, "", "owner", 0, "10 20 5060 sip.example.org."),
+		decisionEndpoint("example.org", endpoint.RecordTypeMX, "", "owner", 0, "10 mail.example.org"),
+	}
+	desired := []*endpoint.Endpoint{
+		decisionEndpoint("_sip._tcp.example.org", endpoint.RecordTypeSRV, "service/default/sip", "", 0, "10  20 5060 SIP.example.org"),
+		decisionEndpoint("example.org", endpoint.RecordTypeMX, "service/default/mail", "", 0, "20 mail.example.org"),
+	}
+	// invalid targets are refused by the controller before planning
+	var rejected *endpoint.ValidationError
+	invalid := decisionEndpoint("_http._tcp.example.org", endpoint.RecordTypeSRV, "service/default/web", "", 0, "0 50 web.example.org")
+	require.ErrorAs(t, endpoint.Validate(invalid), &rejected)
+
+	p := &Plan{
+		Current:        current,
+		Desired:        desired,
+		Rejected:       []*endpoint.ValidationError{rejected},
+		ManagedRecords: []string{endpoint.RecordTypeSRV, endpoint.RecordTypeMX},
+		OwnerID:        "owner",
+	}
+
+	calculated := p.Calculate()
+	var decisions []string
+	for _, d := range calculated.Decisions {
+		decisions = append(decisions, d.String())
+	}
+	assert.ElementsMatch(t, []string{
+		"_sip._tcp.example.org SRV: unchanged",
+		"example.org MX: updated, targets changed",
+		`_http._tcp.example.org SRV: invalid-target, invalid SRV target "0 50 web.example.org": expected "priority weight port target"`,
+	}, decisions)
+	assert.Empty(t, calculated.Changes.Create)
+	assert.Len(t, calculated.Changes.UpdateNew, 1)
+}
+
+func TestCalculateInternationalizedNames(t *testing.T) {
+	current := []*endpoint.Endpoint{
+		decisionEndpoint("xn--bcher-kva.example.org", endpoint.RecordTypeA, "", "owner", 0, "1.2.3.4"),
+	}
+	desired := []*endpoint.Endpoint{
+		{DNSName: "Bücher.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}, Labels: endpoint.Labels{}},
+		{DNSName: "faß.example.org", RecordType: endpoint.RecordTypeA, Targets: endpoint.Targets{"1.2.3.4"}, Labels: endpoint.Labels{}},
+		decisionEndpoint("-shop.example.org", endpoint.RecordTypeA, "", "", 0, "1.2.3.4"),
+	}
+
+	domainFilter := endpoint.NewDomainFilter([]string{"bücher.example.org", "faß.example.org", "shop.example.org"})
+	p := &Plan{
+		Current:        current,
+		Desired:        desired,
+		DomainFilter:   endpoint.MatchAllDomainFilters{&domainFilter},
+		ManagedRecords: []string{endpoint.RecordTypeA},
+		OwnerID:        "owner",
+	}
+
+	calculated := p.Calculate()
+	var decisions []string
+	for _, d := range calculated.Decisions {
+		decisions = append(decisions, d.String())
+	}
+	assert.ElementsMatch(t, []string{
+		"xn--bcher-kva.example.org A: unchanged",
+		"xn--fa-hia.example.org A: created",
+		`-shop.example.org A: invalid-name, DNS name "-shop.example.org" is invalid: label "-shop" starts or ends with a hyphen`,
+	}, decisions)
+	require.Len(t, calculated.Changes.Create, 1)
+	assert.Equal(t, "xn--fa-hia.example.org", calculated.Changes.Create[0].DNSName)
+	assert.Equal(t, "Bücher.example.org", desired[0].DNSName)
+}
@@ -16,6 +16,12 @@ limitations under the License.
 
 package plan
 
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
 // Policy allows to apply different rules to a set of changes.
 type Policy interface {
 	Apply(changes *Changes) *Changes
@@ -57,3 +63,100 @@ func (p *CreateOnlyPolicy) Apply(changes *Changes) *Changes {
 		Create: changes.Create,
 	}
 }
+
+// ChangeLimitPolicy guards against runs changing too many records at once, for example when a
+// misconfigured source suddenly returns no endpoints. Changes exceeding a limit are either all
+// dropped or truncated to the limit. Limits set to zero are disabled.
+type ChangeLimitPolicy struct {
+	// MaxDeletes is the maximum number of deletions
+	MaxDeletes int
+	// MaxDeletesPercent is the maximum number of deletions, in percent of OwnedRecords
+	MaxDeletesPercent int
+	// OwnedRecords is the number of records currently owned
+	OwnedRecords int
+	// MaxChanges is the maximum number of creations, updates and deletions
+	MaxChanges int
+	// Truncate applies the changes up to the limit instead of dropping all of them
+	Truncate bool
+	// Exceeded is the name of the last limit exceeded by the changes, set by Apply
+	Exceeded string
+	// ExceededMessage explains why the changes exceeded the limit, set by Apply
+	ExceededMessage string
+}
+
+// Apply applies the change limits, dropping or truncating the changes exceeding them.
+func (p *ChangeLimitPolicy) Apply(changes *Changes) *Changes {
+	p.Exceeded, p.ExceededMessage = "", ""
+
+	if limit, name := p.maxDeletes(); limit >= 0 && len(changes.Delete) > limit {
+		changes = p.exceeded(name, len(changes.Delete), limit, changes)
+		if p.Truncate {
+			changes = &Changes{
+				Create:    changes.Create,
+				UpdateOld: changes.UpdateOld,
+				UpdateNew: changes.UpdateNew,
+				Delete:    changes.Delete[:limit],
+			}
+		}
+	}
+
+	if total := len(changes.Create) + len(changes.UpdateNew) + len(changes.Delete); p.MaxChanges > 0 && total > p.MaxChanges {
+		changes = p.exceeded("max-changes", total, p.MaxChanges, changes)
+		if p.Truncate {
+			changes = truncateChanges(changes, p.MaxChanges)
+		}
+	}
+
+	return changes
+}
+
+// maxDeletes returns the lowest deletion limit and its name, or -1 if none is set.
+func (p *ChangeLimitPolicy) maxDeletes() (int, string) {
+	limit, name := -1, ""
+	if p.MaxDeletes > 0 {
+		limit, name = p.MaxDeletes, "max-deletes"
+	}
+	if p.MaxDeletesPercent > 0 {
+		// rounded up and at least one deletion, so that the limit of small zones does not block every deletion
+		percent := (p.OwnedRecords*p.MaxDeletesPercent + 99) / 100
+		if percent < 1 {
+			percent = 1
+		}
+		if limit < 0 || percent < limit {
+			limit, name = percent, "max-deletes-percent"
+		}
+	}
+	return limit, name
+}
+
+// exceeded records that a limit was exceeded and returns no changes, unless they have to be truncated.
+func (p *ChangeLimitPolicy) exceeded(name string, count, limit int, changes *Changes) *Changes {
+	p.Exceeded = name
+	p.ExceededMessage = fmt.Sprintf("planned changes exceed the %s limit (%d > %d)", name, count, limit)
+	if p.Truncate {
+		log.Warnf("Planned changes exceed the %s limit (%d > %d), truncating the changes", name, count, limit)
+		return changes
+	}
+	log.Errorf("Planned changes exceed the %s limit (%d > %d), no changes are applied", name, count, limit)
+	return &Changes{}
+}
+
+// truncateChanges keeps the first changes up to the limit, favoring creations and updates over deletions.
+func truncateChanges(changes *Changes, limit int) *Changes {
+	truncated := &Changes{}
+	take := func(n int) int {
+		if n > limit {
+			n = limit
+		}
+		limit -= n
+		return n
+	}
+	n := take(len(changes.Create))
+	truncated.Create = changes.Create[:n]
+	n = take(len(changes.UpdateNew))
+	truncated.UpdateOld = changes.UpdateOld[:n]
+	truncated.UpdateNew = changes.UpdateNew[:n]
+	n = take(len(changes.Delete))
+	truncated.Delete = changes.Delete[:n]
+	return truncated
+}
@@ -70,6 +70,92 @@ func TestApply(t *testing.T) {
 	}
 }
 
+// TestChangeLimitPolicy tests that the change limits drop or truncate the changes exceeding them.
+func TestChangeLimitPolicy(t *testing.T) {
+	empty := []*endpoint.Endpoint{}
+	foo := []*endpoint.Endpoint{{DNSName: "foo", Targets: endpoint.Targets{"v1"}}}
+	fooV2 := []*endpoint.Endpoint{{DNSName: "foo", Targets: endpoint.Targets{"v2"}}}
+	bar := []*endpoint.Endpoint{{DNSName: "bar", Targets: endpoint.Targets{"v1"}}}
+	deletes := []*endpoint.Endpoint{
+		{DNSName: "a", Targets: endpoint.Targets{"v1"}},
+		{DNSName: "b", Targets: endpoint.Targets{"v1"}},
+		{DNSName: "c", Targets: endpoint.Targets{"v1"}},
+	}
+	changes := &Changes{Create: bar, UpdateOld: foo, UpdateNew: fooV2, Delete: deletes}
+
+	for _, tc := range []struct {
+		name             string
+		policy           *ChangeLimitPolicy
+		expected         *Changes
+		expectedExceeded string
+	}{
+		{
+			name:     "no limits",
+			policy:   &ChangeLimitPolicy{},
+			expected: changes,
+		},
+		{
+			name:     "within limits",
+			policy:   &ChangeLimitPolicy{MaxDeletes: 3, MaxChanges: 5},
+			expected: changes,
+		},
+		{
+			name:             "max deletes exceeded",
+			policy:           &ChangeLimitPolicy{MaxDeletes: 2},
+			expected:         &Changes{Create: empty, UpdateOld: empty, UpdateNew: empty, Delete: empty},
+			expectedExceeded: "max-deletes",
+		},
+		{
+			name:             "max deletes percent exceeded",
+			policy:           &ChangeLimitPolicy{MaxDeletesPercent: 50, OwnedRecords: 4},
+			expected:         &Changes{Create: empty, UpdateOld: empty, UpdateNew: empty, Delete: empty},
+			expectedExceeded: "max-deletes-percent",
+		},
+		{
+			name:             "max deletes truncated",
+			policy:           &ChangeLimitPolicy{MaxDeletes: 5, MaxDeletesPercent: 50, OwnedRecords: 4, Truncate: true},
+			expected:         &Changes{Create: bar, UpdateOld: foo, UpdateNew: fooV2, Delete: deletes[:2]},
+			expectedExceeded: "max-deletes-percent",
+		},
+		{
+			name:             "max deletes percent rounded up",
+			policy:           &ChangeLimitPolicy{MaxDeletesPercent: 10, OwnedRecords: 15, Truncate: true},
+			expected:         &Changes{Create: bar, UpdateOld: foo, UpdateNew: fooV2, Delete: deletes[:2]},
+			expectedExceeded: "max-deletes-percent",
+		},
+		{
+			name:             "max deletes percent allows a deletion in small zones",
+			policy:           &ChangeLimitPolicy{MaxDeletesPercent: 10, OwnedRecords: 3, Truncate: true},
+			expected:         &Changes{Create: bar, UpdateOld: foo, UpdateNew: fooV2, Delete: deletes[:1]},
+			expectedExceeded: "max-deletes-percent",
+		},
+		{
+			name:             "max changes exceeded",
+			policy:           &ChangeLimitPolicy{MaxChanges: 4},
+			expected:         &Changes{Create: empty, UpdateOld: empty, UpdateNew: empty, Delete: empty},
+			expectedExceeded: "max-changes",
+		},
+		{
+			name:             "max changes truncated",
+			policy:           &ChangeLimitPolicy{MaxChanges: 3, Truncate: true},
+			expected:         &Changes{Create: bar, UpdateOld: foo, UpdateNew: fooV2, Delete: deletes[:1]},
+			expectedExceeded: "max-changes",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result := tc.policy.Apply(changes)
+
+			validateEntries(t, result.Create, tc.expected.Create)
+			validateEntries(t, result.UpdateOld, tc.expected.UpdateOld)
+			validateEntries(t, result.UpdateNew, tc.expected.UpdateNew)
+			validateEntries(t, result.Delete, tc.expected.Delete)
+			if tc.policy.Exceeded != tc.expectedExceeded {
+				t.Errorf("expected exceeded limit %q, got %q", tc.expectedExceeded, tc.policy.Exceeded)
+			}
+		})
+	}
+}
+
 // TestPolicies tests that policies are correctly registered.
 func TestPolicies(t *testing.T) {
 	validatePolicy(t, Policies["sync"], &SyncPolicy{})
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// TargetDelta is the difference between the targets of the current and the desired record of an update.
+// Providers storing every target as a separate record can apply it by only adding and removing the changed
+// targets, instead of rewriting the whole record.
+type TargetDelta struct {
+	// Added are the targets of the desired record the current record does not have
+	Added endpoint.Targets
+	// Removed are the targets of the current record the desired record does not have
+	Removed endpoint.Targets
+	// Kept are the targets both records have
+	Kept endpoint.Targets
+}
+
+// NewTargetDelta returns the delta between the targets of the current and the desired record. It returns false if
+// the update changes more than the targets, e.g. the TTL or a provider specific property, in which case the
+// whole record has to be rewritten.
+func NewTargetDelta(current, desired *endpoint.Endpoint) (TargetDelta, bool) {
+	if current == nil || desired == nil ||
+		normalizeDNSName(current.DNSName) != normalizeDNSName(desired.DNSName) ||
+		current.RecordType != desired.RecordType ||
+		current.SetIdentifier != desired.SetIdentifier ||
+		current.RecordTTL != desired.RecordTTL ||
+		shouldUpdateProviderSpecific(desired, current) {
+		return TargetDelta{}, false
+	}
+
+	delta := TargetDelta{Added: endpoint.Targets{}, Removed: endpoint.Targets{}, Kept: endpoint.Targets{}}
+	// targets are compared like targetChanged does
+	remaining := make(map[string]string, len(current.Targets))
+	for _, target := range current.Targets {
+		remaining[targetKey(current.RecordType, target)] = target
+	}
+	for _, target := range desired.Targets {
+		key := targetKey(desired.RecordType, target)
+		if _, ok := remaining[key]; ok {
+			delta.Kept = append(delta.Kept, target)
+			delete(remaining, key)
+			continue
+		}
+		delta.Added = append(delta.Added, target)
+	}
+	// keep the order of the current targets for the removed ones
+	for _, target := range current.Targets {
+		key := targetKey(current.RecordType, target)
+		if _, ok := remaining[key]; ok {
+			delta.Removed = append(delta.Removed, target)
+			delete(remaining, key)
+		}
+	}
+	return delta, true
+}
+
+// targetKey returns the form of a target used to compare it. Only host names are case-insensitive, the targets of
+// other record types, e.g. TXT, are compared as is.
+func targetKey(recordType, target string) string {
+	normalized := endpoint.NormalizeTarget(recordType, target)
+	switch recordType {
+	case endpoint.RecordTypeCNAME, endpoint.RecordTypeNS, endpoint.RecordTypePTR, endpoint.RecordTypeMX, endpoint.RecordTypeSRV, endpoint.RecordTypeALIAS:
+		return strings.ToLower(normalized)
+	}
+	return normalized
+}
+
+// IsEmpty returns true if the delta neither adds nor removes targets
+func (d TargetDelta) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// targetDeltas returns the target delta of the updates, by the key of their desired record. The current and the
+// desired records are paired by name, type and set identifier, not by their position, since registries add their own
+// records to the updates. Records updated more than once have no delta.
+// It returns nil if no update has a delta.
+func targetDeltas(updateOld, updateNew []*endpoint.Endpoint) map[endpoint.EndpointKey]TargetDelta {
+	current, desired := updatesByKey(updateOld), updatesByKey(updateNew)
+	var deltas map[endpoint.EndpointKey]TargetDelta
+	for key, news := range desired {
+		olds := current[key]
+		if len(news) != 1 || len(olds) != 1 {
+			continue
+		}
+		if delta, ok := NewTargetDelta(olds[0], news[0]); ok {
+			if deltas == nil {
+				deltas = map[endpoint.EndpointKey]TargetDelta{}
+			}
+			deltas[news[0].Key()] = delta
+		}
+	}
+	return deltas
+}
+
+func updatesByKey(endpoints []*endpoint.Endpoint) map[endpoint.EndpointKey][]*endpoint.Endpoint {
+	byKey := make(map[endpoint.EndpointKey][]*endpoint.Endpoint, len(endpoints))
+	for _, ep := range endpoints {
+		key := deltaKey(ep)
+		byKey[key] = append(byKey[key], ep)
+	}
+	return byKey
+}
+
+// deltaKey returns the key updates are paired by
+func deltaKey(ep *endpoint.Endpoint) endpoint.EndpointKey {
+	return endpoint.EndpointKey{DNSName: normalizeDNSName(ep.DNSName), RecordType: ep.RecordType, SetIdentifier: ep.SetIdentifier}
+}
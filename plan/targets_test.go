@@ -0,0 +1,141 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestNewTargetDelta(t *testing.T) {
+	current := endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "1.1.1.1", "2.2.2.2", "3.3.3.3")
+
+	for _, tt := range []struct {
+		name    string
+		desired *endpoint.Endpoint
+		ok      bool
+		want    TargetDelta
+	}{
+		{
+			name:    "targets added and removed",
+			desired: endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "2.2.2.2", "4.4.4.4", "3.3.3.3", "5.5.5.5"),
+			ok:      true,
+			want: TargetDelta{
+				Added:   endpoint.Targets{"4.4.4.4", "5.5.5.5"},
+				Removed: endpoint.Targets{"1.1.1.1"},
+				Kept:    endpoint.Targets{"2.2.2.2", "3.3.3.3"},
+			},
+		},
+		{
+			name:    "same targets",
+			desired: endpoint.NewEndpointWithTTL("FOO.example.org.", endpoint.RecordTypeA, 300, "3.3.3.3", "2.2.2.2", "1.1.1.1"),
+			ok:      true,
+			want: TargetDelta{
+				Added:   endpoint.Targets{},
+				Removed: endpoint.Targets{},
+				Kept:    endpoint.Targets{"3.3.3.3", "2.2.2.2", "1.1.1.1"},
+			},
+		},
+		{
+			name:    "ttl changed",
+			desired: endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 600, "1.1.1.1"),
+		},
+		{
+			name:    "provider specific changed",
+			desired: endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "1.1.1.1").WithProviderSpecific("alias", "true"),
+		},
+		{
+			name:    "set identifier changed",
+			desired: endpoint.NewEndpointWithTTL("foo.example.org", endpoint.RecordTypeA, 300, "1.1.1.1").WithSetIdentifier("a"),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, ok := NewTargetDelta(current, tt.desired)
+			require.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, delta)
+		})
+	}
+}
+
+func TestTargetDelta_CaseInsensitive(t *testing.T) {
+	current := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "LB.example.com")
+	desired := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeCNAME, "lb.example.com")
+
+	delta, ok := NewTargetDelta(current, desired)
+	require.True(t, ok)
+	assert.True(t, delta.IsEmpty())
+	assert.Equal(t, endpoint.Targets{"lb.example.com"}, delta.Kept)
+}
+
+func TestTargetDelta_CaseSensitiveTXT(t *testing.T) {
+	current := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeTXT, "Key=Value", "other")
+	desired := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeTXT, "key=value", "other")
+
+	delta, ok := NewTargetDelta(current, desired)
+	require.True(t, ok)
+	assert.Equal(t, endpoint.Targets{"key=value"}, delta.Added)
+	assert.Equal(t, endpoint.Targets{"Key=Value"}, delta.Removed)
+	assert.Equal(t, endpoint.Targets{"other"}, delta.Kept)
+}
+
+func TestTargetDeltas(t *testing.T) {
+	changes := &Changes{
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("b.example.org", endpoint.RecordTypeA, 300, "1.1.1.1"),
+			endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "1.1.1.1", "2.2.2.2"),
+			endpoint.NewEndpoint("a-a.example.org", endpoint.RecordTypeTXT, "\"heritage=external-dns\""),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.example.org", endpoint.RecordTypeA, "2.2.2.2", "3.3.3.3"),
+			endpoint.NewEndpointWithTTL("b.example.org", endpoint.RecordTypeA, 600, "1.1.1.1"),
+		},
+	}
+
+	// the updates are paired by key, not by position
+	deltas := targetDeltas(changes.UpdateOld, changes.UpdateNew)
+	require.Len(t, deltas, 1)
+	delta, ok := deltas[changes.UpdateNew[0].Key()]
+	require.True(t, ok)
+	assert.Equal(t, endpoint.Targets{"3.3.3.3"}, delta.Added)
+	assert.Equal(t, endpoint.Targets{"1.1.1.1"}, delta.Removed)
+	_, ok = deltas[changes.UpdateNew[1].Key()]
+	assert.False(t, ok, "the TTL changed")
+}
+
+func TestCalculateAttachesTargetDeltas(t *testing.T) {
+	current := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "1.1.1.1", "2.2.2.2")
+	desired := endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeA, "2.2.2.2", "3.3.3.3")
+
+	p := &Plan{
+		Policies:       []Policy{&SyncPolicy{}},
+		Current:        []*endpoint.Endpoint{current},
+		Desired:        []*endpoint.Endpoint{desired},
+		ManagedRecords: []string{endpoint.RecordTypeA},
+	}
+
+	changes := p.Calculate().Changes
+	require.Len(t, changes.UpdateNew, 1)
+	delta, ok := changes.TargetDeltas[changes.UpdateNew[0].Key()]
+	require.True(t, ok)
+	assert.Equal(t, endpoint.Targets{"3.3.3.3"}, delta.Added)
+	assert.Equal(t, endpoint.Targets{"1.1.1.1"}, delta.Removed)
+	assert.Equal(t, endpoint.Targets{"2.2.2.2"}, delta.Kept)
+}
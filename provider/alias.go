@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// AliasProvider is an optional interface a Provider can implement when it can publish ALIAS endpoints itself,
+// e.g. as a native ALIAS record or as a record type it flattens. The ALIAS endpoints it does not support are
+// flattened by the AliasFlattener.
+type AliasProvider interface {
+	// SupportsAlias returns true if the provider publishes the ALIAS endpoint itself
+	SupportsAlias(ep *endpoint.Endpoint) bool
+}
+
+// Resolver looks up the addresses of a host name. It is implemented by net.Resolver.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// AliasFlattener publishes the ALIAS endpoints a provider does not support as the A and AAAA records their
+// targets resolve to. The targets are resolved again on every synchronization, so the records follow the
+// addresses of the targets.
+type AliasFlattener struct {
+	provider AliasProvider
+	resolver Resolver
+}
+
+// NewAliasFlattener returns an AliasFlattener for the ALIAS endpoints the provider does not support
+func NewAliasFlattener(p Provider, resolver Resolver) *AliasFlattener {
+	f := &AliasFlattener{resolver: resolver}
+	if ap, ok := p.(AliasProvider); ok {
+		f.provider = ap
+	}
+	return f
+}
+
+// Flatten replaces the ALIAS endpoints the provider does not support by A and AAAA endpoints. When a target cannot
+// be resolved, the address records in current are kept, so that a resolution failure does not delete the records;
+// an ALIAS endpoint without any is left out.
+func (f *AliasFlattener) Flatten(ctx context.Context, endpoints, current []*endpoint.Endpoint) []*endpoint.Endpoint {
+	flattened := make([]*endpoint.Endpoint, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if ep.RecordType != endpoint.RecordTypeALIAS || (f.provider != nil && f.provider.SupportsAlias(ep)) {
+			flattened = append(flattened, ep)
+			continue
+		}
+
+		addresses, err := f.resolve(ctx, ep.Targets)
+		if err != nil {
+			addresses = currentAddresses(ep, current)
+			if len(addresses) == 0 {
+				log.Warnf("Skipping ALIAS record %s: %v", ep.DNSName, err)
+				continue
+			}
+			log.Warnf("Keeping the current addresses of ALIAS record %s: %v", ep.DNSName, err)
+		}
+
+		var ipv4, ipv6 endpoint.Targets
+		for _, address := range addresses {
+			if net.ParseIP(address).To4() != nil {
+				ipv4 = append(ipv4, address)
+			} else {
+				ipv6 = append(ipv6, address)
+			}
+		}
+		if len(ipv4) > 0 {
+			flattened = append(flattened, flattenedEndpoint(ep, endpoint.RecordTypeA, ipv4))
+		}
+		if len(ipv6) > 0 {
+			flattened = append(flattened, flattenedEndpoint(ep, endpoint.RecordTypeAAAA, ipv6))
+		}
+	}
+	return flattened
+}
+
+// resolve returns the sorted, distinct addresses of the targets
+func (f *AliasFlattener) resolve(ctx context.Context, targets endpoint.Targets) ([]string, error) {
+	seen := map[string]bool{}
+	var addresses []string
+	for _, target := range targets {
+		ips, err := f.resolver.LookupIPAddr(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", target, err)
+		}
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("%s has no addresses", target)
+		}
+		for _, ip := range ips {
+			if address := ip.IP.String(); !seen[address] {
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// currentAddresses returns the targets of the current address records of the ALIAS endpoint
+func currentAddresses(ep *endpoint.Endpoint, current []*endpoint.Endpoint) []string {
+	var addresses []string
+	for _, c := range current {
+		if (c.RecordType == endpoint.RecordTypeA || c.RecordType == endpoint.RecordTypeAAAA) &&
+			strings.EqualFold(strings.TrimSuffix(c.DNSName, "."), strings.TrimSuffix(ep.DNSName, ".")) &&
+			c.SetIdentifier == ep.SetIdentifier {
+			addresses = append(addresses, c.Targets...)
+		}
+	}
+	return addresses
+}
+
+func flattenedEndpoint(ep *endpoint.Endpoint, recordType string, targets endpoint.Targets) *endpoint.Endpoint {
+	flattened := endpoint.NewEndpointWithTTL(ep.DNSName, recordType, ep.RecordTTL, targets...).WithSetIdentifier(ep.SetIdentifier)
+	if len(ep.ProviderSpecific) > 0 {
+		flattened.ProviderSpecific = append(endpoint.ProviderSpecific{}, ep.ProviderSpecific...)
+	}
+	for key, value := range ep.Labels {
+		flattened.Labels[key] = value
+	}
+	return flattened
+}
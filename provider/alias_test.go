@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+type staticResolver map[string][]string
+
+func (r staticResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	addresses, ok := r[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	var ips []net.IPAddr
+	for _, address := range addresses {
+		ips = append(ips, net.IPAddr{IP: net.ParseIP(address)})
+	}
+	return ips, nil
+}
+
+type aliasProvider struct {
+	Provider
+}
+
+func (aliasProvider) SupportsAlias(ep *endpoint.Endpoint) bool {
+	return ep.Targets[0] == "native.example.com"
+}
+
+func TestAliasFlattener(t *testing.T) {
+	resolver := staticResolver{
+		"lb.example.com":    {"192.0.2.2", "2001:db8::1", "192.0.2.1"},
+		"lb-2.example.com":  {"192.0.2.1", "192.0.2.3"},
+		"empty.example.com": {},
+	}
+	alias := endpoint.NewEndpointWithTTL("example.org", endpoint.RecordTypeALIAS, 300, "lb.example.com", "lb-2.example.com").
+		WithProviderSpecific("weight", "10")
+	alias.Labels[endpoint.ResourceLabelKey] = "crd/default/apex"
+	cname := endpoint.NewEndpoint("www.example.org", endpoint.RecordTypeCNAME, "lb.example.com")
+
+	flattened := NewAliasFlattener(struct{ Provider }{}, resolver).Flatten(context.Background(), []*endpoint.Endpoint{alias, cname}, nil)
+	require.Len(t, flattened, 3)
+	assert.Equal(t, endpoint.RecordTypeA, flattened[0].RecordType)
+	assert.Equal(t, endpoint.Targets{"192.0.2.1", "192.0.2.2", "192.0.2.3"}, flattened[0].Targets)
+	assert.Equal(t, endpoint.TTL(300), flattened[0].RecordTTL)
+	assert.Equal(t, "crd/default/apex", flattened[0].Labels[endpoint.ResourceLabelKey])
+	assert.Equal(t, alias.ProviderSpecific, flattened[0].ProviderSpecific)
+	assert.Equal(t, endpoint.RecordTypeAAAA, flattened[1].RecordType)
+	assert.Equal(t, endpoint.Targets{"2001:db8::1"}, flattened[1].Targets)
+	assert.Same(t, cname, flattened[2])
+
+	// a resolution failure keeps the current addresses, or leaves the record out without any
+	current := []*endpoint.Endpoint{
+		endpoint.NewEndpoint("example.org.", endpoint.RecordTypeA, "192.0.2.9"),
+		endpoint.NewEndpoint("other.example.org", endpoint.RecordTypeA, "192.0.2.10"),
+	}
+	flattened = NewAliasFlattener(struct{ Provider }{}, resolver).Flatten(context.Background(), []*endpoint.Endpoint{
+		endpoint.NewEndpoint("example.org", endpoint.RecordTypeALIAS, "unknown.example.com"),
+		endpoint.NewEndpoint("foo.example.org", endpoint.RecordTypeALIAS, "empty.example.com"),
+	}, current)
+	require.Len(t, flattened, 1)
+	assert.Equal(t, "example.org", flattened[0].DNSName)
+	assert.Equal(t, endpoint.Targets{"192.0.2.9"}, flattened[0].Targets)
+
+	// the ALIAS endpoints the provider supports are left as they are
+	native := endpoint.NewEndpoint("example.org", endpoint.RecordTypeALIAS, "native.example.com")
+	flattened = NewAliasFlattener(aliasProvider{}, resolver).Flatten(context.Background(), []*endpoint.Endpoint{native, alias}, nil)
+	require.Len(t, flattened, 3)
+	assert.Same(t, native, flattened[0])
+	assert.Equal(t, endpoint.RecordTypeA, flattened[1].RecordType)
+}
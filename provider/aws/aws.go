@@ -22,6 +22,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -220,6 +221,7 @@ func (cs Route53Changes) Route53Changes() []*route53.Change {
 }
 
 type zonesListCache struct {
+	mux      sync.Mutex
 	age      time.Time
 	duration time.Duration
 	zones    map[string]*route53.HostedZone
@@ -249,6 +251,8 @@ type AWSProvider struct {
 	zonesCache      *zonesListCache
 	// queue for collecting changes to submit them in the next iteration, but after all other changes
 	failedChangesQueue map[string]Route53Changes
+	// ApplyChanges may be called concurrently for distinct zones, the queue is only accessed with the mutex held
+	failedChangesMux sync.Mutex
 }
 
 // AWSConfig contains configuration to create a new AWS provider.
@@ -293,6 +297,8 @@ func NewAWSProvider(awsConfig AWSConfig, client Route53API) (*AWSProvider, error
 
 // Zones returns the list of hosted zones.
 func (p *AWSProvider) Zones(ctx context.Context) (map[string]*route53.HostedZone, error) {
+	p.zonesCache.mux.Lock()
+	defer p.zonesCache.mux.Unlock()
 	if p.zonesCache.zones != nil && time.Since(p.zonesCache.age) < p.zonesCache.duration {
 		log.Debug("Using cached zones list")
 		return p.zonesCache.zones, nil
@@ -359,6 +365,20 @@ func (p *AWSProvider) Zones(ctx context.Context) (map[string]*route53.HostedZone
 	return zones, nil
 }
 
+// ZoneIDNames returns the names of the hosted zones keyed by their ID.
+func (p *AWSProvider) ZoneIDNames(ctx context.Context) (provider.ZoneIDName, error) {
+	zones, err := p.Zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneIDName := provider.ZoneIDName{}
+	for id, zone := range zones {
+		zoneIDName.Add(id, aws.StringValue(zone.Name))
+	}
+	return zoneIDName, nil
+}
+
 // wildcardUnescape converts \\052.abc back to *.abc
 // Route53 stores wildcards escaped: http://docs.aws.amazon.com/Route53/latest/DeveloperGuide/DomainNameFormat.html?shortFooter=true#domain-name-format-asterisk
 func wildcardUnescape(s string) string {
@@ -578,8 +598,10 @@ func (p *AWSProvider) submitChanges(ctx context.Context, changes Route53Changes,
 		var failedUpdate bool
 
 		// group changes into new changes and into changes that failed in a previous iteration and are retried
+		p.failedChangesMux.Lock()
 		retriedChanges, newChanges := findChangesInQueue(cs, p.failedChangesQueue[z])
 		p.failedChangesQueue[z] = nil
+		p.failedChangesMux.Unlock()
 
 		batchCs := append(batchChangeSet(newChanges, p.batchChangeSize, p.batchChangeSizeBytes, p.batchChangeSizeValues),
 			batchChangeSet(retriedChanges, p.batchChangeSize, p.batchChangeSizeBytes, p.batchChangeSizeValues)...)
@@ -620,7 +642,9 @@ func (p *AWSProvider) submitChanges(ctx context.Context, changes Route53Changes,
 							if _, err := p.client.ChangeResourceRecordSetsWithContext(ctx, params); err != nil {
 								failedUpdate = true
 								log.Errorf("Failed submitting change (error: %v), it will be retried in a separate change batch in the next iteration", err)
+								p.failedChangesMux.Lock()
 								p.failedChangesQueue[z] = append(p.failedChangesQueue[z], changes...)
+								p.failedChangesMux.Unlock()
 							} else {
 								successfulChanges = successfulChanges + len(changes)
 							}
@@ -683,6 +707,12 @@ func (p *AWSProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoi
 	for _, ep := range endpoints {
 		alias := false
 
+		// ALIAS endpoints are published as Route53 alias records, like CNAME endpoints pointing to ELBs
+		if ep.RecordType == endpoint.RecordTypeALIAS {
+			ep.RecordType = endpoint.RecordTypeCNAME
+			ep.SetProviderSpecificProperty(providerSpecificAlias, "true")
+		}
+
 		if aliasString, ok := ep.GetProviderSpecificProperty(providerSpecificAlias); ok {
 			alias = aliasString == "true"
 			if alias {
@@ -764,6 +794,13 @@ func (p *AWSProvider) newChange(action string, ep *endpoint.Endpoint) (*Route53C
 		}
 		change.ResourceRecordSet.ResourceRecords = make([]*route53.ResourceRecord, len(ep.Targets))
 		for idx, val := range ep.Targets {
+			if ep.RecordType == endpoint.RecordTypeSVCB || ep.RecordType == endpoint.RecordTypeHTTPS {
+				val = provider.EnsureSVCBTrailingDot(ep.RecordType, val)
+			}
+			// Route53 requires the value of a CAA record to be quoted
+			if ep.RecordType == endpoint.RecordTypeCAA {
+				val = endpoint.NormalizeTarget(ep.RecordType, val)
+			}
 			change.ResourceRecordSet.ResourceRecords[idx] = &route53.ResourceRecord{
 				Value: aws.String(val),
 			}
@@ -1050,6 +1087,12 @@ func suitableZones(hostname string, zones map[string]*route53.HostedZone) []*rou
 	return matchingZones
 }
 
+// SupportsAlias returns true for ALIAS endpoints pointing to a canonical hosted zone, which are published as
+// Route53 alias records. Other targets are not supported by Route53 alias records.
+func (p *AWSProvider) SupportsAlias(ep *endpoint.Endpoint) bool {
+	return len(ep.Targets) == 1 && canonicalHostedZone(ep.Targets[0]) != ""
+}
+
 // useAlias determines if AWS ALIAS should be used.
 func useAlias(ep *endpoint.Endpoint, preferCNAME bool) bool {
 	if preferCNAME {
@@ -1110,7 +1153,7 @@ func cleanZoneID(id string) string {
 
 func (p *AWSProvider) SupportedRecordType(recordType string) bool {
 	switch recordType {
-	case "MX":
+	case endpoint.RecordTypeMX, endpoint.RecordTypeCAA, endpoint.RecordTypeTLSA, endpoint.RecordTypeSSHFP, endpoint.RecordTypeSVCB, endpoint.RecordTypeHTTPS:
 		return true
 	default:
 		return provider.SupportedRecordType(recordType)
@@ -316,6 +316,18 @@ func TestAWSZones(t *testing.T) {
 	}
 }
 
+func TestAWSZoneIDNames(t *testing.T) {
+	p, _ := newAWSProvider(t, endpoint.NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), provider.NewZoneIDFilter([]string{}), provider.NewZoneTypeFilter(""), defaultEvaluateTargetHealth, false, nil)
+
+	zones, err := p.ZoneIDNames(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, provider.ZoneIDName{
+		"/hostedzone/zone-1.ext-dns-test-2.teapot.zalan.do.": "zone-1.ext-dns-test-2.teapot.zalan.do.",
+		"/hostedzone/zone-2.ext-dns-test-2.teapot.zalan.do.": "zone-2.ext-dns-test-2.teapot.zalan.do.",
+		"/hostedzone/zone-3.ext-dns-test-2.teapot.zalan.do.": "zone-3.ext-dns-test-2.teapot.zalan.do.",
+	}, zones)
+}
+
 func TestAWSRecordsFilter(t *testing.T) {
 	provider, _ := newAWSProvider(t, endpoint.DomainFilter{}, provider.ZoneIDFilter{}, provider.ZoneTypeFilter{}, false, false, nil)
 	domainFilter := provider.GetDomainFilter()
@@ -492,6 +504,12 @@ func TestAWSRecords(t *testing.T) {
 			TTL:             aws.Int64(recordTTL),
 			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String("10 mailhost1.example.com")}, {Value: aws.String("20 mailhost2.example.com")}},
 		},
+		{
+			Name:            aws.String("caa.zone-1.ext-dns-test-2.teapot.zalan.do."),
+			Type:            aws.String(route53.RRTypeCaa),
+			TTL:             aws.Int64(recordTTL),
+			ResourceRecords: []*route53.ResourceRecord{{Value: aws.String(`0 issue "letsencrypt.org"`)}},
+		},
 	})
 
 	records, err := provider.Records(context.Background())
@@ -517,6 +535,7 @@ func TestAWSRecords(t *testing.T) {
 		endpoint.NewEndpointWithTTL("healthcheck-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, endpoint.TTL(recordTTL), "foo.example.com").WithSetIdentifier("test-set-1").WithProviderSpecific(providerSpecificWeight, "10").WithProviderSpecific(providerSpecificHealthCheckID, "foo-bar-healthcheck-id").WithProviderSpecific(providerSpecificAlias, "false"),
 		endpoint.NewEndpointWithTTL("healthcheck-test.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, endpoint.TTL(recordTTL), "4.3.2.1").WithSetIdentifier("test-set-2").WithProviderSpecific(providerSpecificWeight, "20").WithProviderSpecific(providerSpecificHealthCheckID, "abc-def-healthcheck-id"),
 		endpoint.NewEndpointWithTTL("mail.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeMX, endpoint.TTL(recordTTL), "10 mailhost1.example.com", "20 mailhost2.example.com"),
+		endpoint.NewEndpointWithTTL("caa.zone-1.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCAA, endpoint.TTL(recordTTL), `0 issue "letsencrypt.org"`),
 	})
 }
 
@@ -531,6 +550,7 @@ func TestAWSAdjustEndpoints(t *testing.T) {
 		endpoint.NewEndpoint("cname-test-elb-no-alias.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "false"),
 		endpoint.NewEndpoint("cname-test-elb-no-eth.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificEvaluateTargetHealth, "false"), // eth = evaluate target health
 		endpoint.NewEndpoint("cname-test-elb-alias.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "true").WithProviderSpecific(providerSpecificEvaluateTargetHealth, "true"),
+		endpoint.NewEndpoint("alias-test-elb.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeALIAS, "foo.eu-central-1.elb.amazonaws.com"),
 	}
 
 	records, err := provider.AdjustEndpoints(records)
@@ -544,9 +564,16 @@ func TestAWSAdjustEndpoints(t *testing.T) {
 		endpoint.NewEndpoint("cname-test-elb-no-alias.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeCNAME, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "false"),
 		endpoint.NewEndpoint("cname-test-elb-no-eth.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "true").WithProviderSpecific(providerSpecificEvaluateTargetHealth, "false"), // eth = evaluate target health
 		endpoint.NewEndpoint("cname-test-elb-alias.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "true").WithProviderSpecific(providerSpecificEvaluateTargetHealth, "true"),
+		endpoint.NewEndpoint("alias-test-elb.zone-2.ext-dns-test-2.teapot.zalan.do", endpoint.RecordTypeA, "foo.eu-central-1.elb.amazonaws.com").WithProviderSpecific(providerSpecificAlias, "true").WithProviderSpecific(providerSpecificEvaluateTargetHealth, "true"),
 	})
 }
 
+func TestAWSSupportsAlias(t *testing.T) {
+	p := &AWSProvider{}
+	assert.True(t, p.SupportsAlias(endpoint.NewEndpoint("example.org", endpoint.RecordTypeALIAS, "foo.eu-central-1.elb.amazonaws.com")))
+	assert.False(t, p.SupportsAlias(endpoint.NewEndpoint("example.org", endpoint.RecordTypeALIAS, "lb.example.com")))
+}
+
 func TestAWSApplyChanges(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -1652,6 +1679,35 @@ func TestAWSCreateRecordsWithCNAME(t *testing.T) {
 	})
 }
 
+func TestAWSCreateRecordsWithCAA(t *testing.T) {
+	provider, _ := newAWSProvider(t, endpoint.NewDomainFilter([]string{"ext-dns-test-2.teapot.zalan.do."}), provider.NewZoneIDFilter([]string{}), provider.NewZoneTypeFilter(""), defaultEvaluateTargetHealth, false, nil)
+
+	records := []*endpoint.Endpoint{
+		{DNSName: "create-test.zone-1.ext-dns-test-2.teapot.zalan.do", Targets: endpoint.Targets{"0 issue letsencrypt.org"}, RecordType: endpoint.RecordTypeCAA},
+	}
+
+	adjusted, err := provider.AdjustEndpoints(records)
+	require.NoError(t, err)
+	require.NoError(t, provider.ApplyChanges(context.Background(), &plan.Changes{
+		Create: adjusted,
+	}))
+
+	recordSets := listAWSRecords(t, provider.client, "/hostedzone/zone-1.ext-dns-test-2.teapot.zalan.do.")
+
+	validateRecords(t, recordSets, []*route53.ResourceRecordSet{
+		{
+			Name: aws.String("create-test.zone-1.ext-dns-test-2.teapot.zalan.do."),
+			Type: aws.String(endpoint.RecordTypeCAA),
+			TTL:  aws.Int64(300),
+			ResourceRecords: []*route53.ResourceRecord{
+				{
+					Value: aws.String(`0 issue "letsencrypt.org"`),
+				},
+			},
+		},
+	})
+}
+
 func TestAWSCreateRecordsWithALIAS(t *testing.T) {
 	for key, evaluateTargetHealth := range map[string]bool{
 		"true":  true,
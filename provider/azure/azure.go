@@ -126,6 +126,11 @@ func (p *AzureProvider) Records(ctx context.Context) (endpoints []*endpoint.Endp
 					continue
 				}
 				targets := extractAzureTargets(recordSet)
+				// alias record sets of type A are ALIAS endpoints targeting the Azure resource
+				if len(targets) == 0 && recordType == string(dns.RecordTypeA) && recordSet.Properties != nil && recordSet.Properties.TargetResource != nil && recordSet.Properties.TargetResource.ID != nil {
+					recordType = endpoint.RecordTypeALIAS
+					targets = []string{*recordSet.Properties.TargetResource.ID}
+				}
 				if len(targets) == 0 {
 					log.Debugf("Failed to extract targets for '%s' with type '%s'.", name, recordType)
 					continue
@@ -148,6 +153,22 @@ func (p *AzureProvider) Records(ctx context.Context) (endpoints []*endpoint.Endp
 	return endpoints, nil
 }
 
+// ZoneIDNames returns the names of the zones keyed by their name, which changes are mapped to.
+func (p *AzureProvider) ZoneIDNames(ctx context.Context) (provider.ZoneIDName, error) {
+	zones, err := p.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneIDName := provider.ZoneIDName{}
+	for _, z := range zones {
+		if z.Name != nil {
+			zoneIDName.Add(*z.Name, *z.Name)
+		}
+	}
+	return zoneIDName, nil
+}
+
 // ApplyChanges applies the given changes.
 //
 // Returns nil if the operation was successful or an error if the operation failed.
@@ -246,7 +267,7 @@ func (p *AzureProvider) deleteRecords(ctx context.Context, deleted azureChangeMa
 				log.Infof("Would delete %s record named '%s' for Azure DNS zone '%s'.", ep.RecordType, name, zone)
 			} else {
 				log.Infof("Deleting %s record named '%s' for Azure DNS zone '%s'.", ep.RecordType, name, zone)
-				if _, err := p.recordSetsClient.Delete(ctx, p.resourceGroup, zone, name, dns.RecordType(ep.RecordType), nil); err != nil {
+				if _, err := p.recordSetsClient.Delete(ctx, p.resourceGroup, zone, name, azureRecordType(ep), nil); err != nil {
 					log.Errorf(
 						"Failed to delete %s record named '%s' for Azure DNS zone '%s': %v",
 						ep.RecordType,
@@ -294,7 +315,7 @@ func (p *AzureProvider) updateRecords(ctx context.Context, updated azureChangeMa
 					p.resourceGroup,
 					zone,
 					name,
-					dns.RecordType(ep.RecordType),
+					azureRecordType(ep),
 					recordSet,
 					nil,
 				)
@@ -331,6 +352,16 @@ func (p *AzureProvider) newRecordSet(endpoint *endpoint.Endpoint) (dns.RecordSet
 	if endpoint.RecordTTL.IsConfigured() {
 		ttl = int64(endpoint.RecordTTL)
 	}
+	if isAzureAlias(endpoint) {
+		return dns.RecordSet{
+			Properties: &dns.RecordSetProperties{
+				TTL: to.Ptr(ttl),
+				TargetResource: &dns.SubResource{
+					ID: to.Ptr(endpoint.Targets[0]),
+				},
+			},
+		}, nil
+	}
 	switch dns.RecordType(endpoint.RecordType) {
 	case dns.RecordTypeA:
 		aRecords := make([]*dns.ARecord, len(endpoint.Targets))
@@ -399,6 +430,24 @@ func (p *AzureProvider) newRecordSet(endpoint *endpoint.Endpoint) (dns.RecordSet
 	return dns.RecordSet{}, fmt.Errorf("unsupported record type '%s'", endpoint.RecordType)
 }
 
+// SupportsAlias returns true for ALIAS endpoints targeting an Azure resource by its ID, which are published as alias
+// record sets. Other targets are not supported by alias record sets.
+func (p *AzureProvider) SupportsAlias(ep *endpoint.Endpoint) bool {
+	return isAzureAlias(ep)
+}
+
+func isAzureAlias(ep *endpoint.Endpoint) bool {
+	return ep.RecordType == endpoint.RecordTypeALIAS && len(ep.Targets) == 1 && strings.HasPrefix(strings.ToLower(ep.Targets[0]), "/subscriptions/")
+}
+
+// azureRecordType returns the type of the record set of the endpoint, ALIAS endpoints are alias record sets of type A
+func azureRecordType(ep *endpoint.Endpoint) dns.RecordType {
+	if ep.RecordType == endpoint.RecordTypeALIAS {
+		return dns.RecordTypeA
+	}
+	return dns.RecordType(ep.RecordType)
+}
+
 // Helper function (shared with test code)
 func formatAzureDNSName(recordName, zoneName string) string {
 	if recordName == "@" {
@@ -150,6 +150,22 @@ func (p *AzurePrivateDNSProvider) Records(ctx context.Context) (endpoints []*end
 	return endpoints, nil
 }
 
+// ZoneIDNames returns the names of the private zones keyed by their name, which changes are mapped to.
+func (p *AzurePrivateDNSProvider) ZoneIDNames(ctx context.Context) (provider.ZoneIDName, error) {
+	zones, err := p.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneIDName := provider.ZoneIDName{}
+	for _, z := range zones {
+		if z.Name != nil {
+			zoneIDName.Add(*z.Name, *z.Name)
+		}
+	}
+	return zoneIDName, nil
+}
+
 // ApplyChanges applies the given changes.
 //
 // Returns nil if the operation was successful or an error if the operation failed.
@@ -319,6 +319,26 @@ func TestAzurePrivateDNSMultiRecord(t *testing.T) {
 	validateAzureEndpoints(t, actual, expected)
 }
 
+func TestAzurePrivateDNSZoneIDNames(t *testing.T) {
+	provider, err := newMockedAzurePrivateDNSProvider(endpoint.NewDomainFilter([]string{"example.com"}), provider.NewZoneIDFilter([]string{""}), true, "k8s",
+		[]*privatedns.PrivateZone{
+			createMockPrivateZone("example.com", "/privateDnsZones/example.com"),
+			createMockPrivateZone("other.org", "/privateDnsZones/other.org"),
+		},
+		nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zones, err := provider.ZoneIDNames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zones) != 1 || zones["example.com"] != "example.com" {
+		t.Fatalf("expected only the example.com zone, got %v", zones)
+	}
+}
+
 func TestAzurePrivateDNSApplyChanges(t *testing.T) {
 	recordsClient := mockPrivateRecordSetsClient{}
 
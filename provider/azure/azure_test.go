@@ -325,6 +325,65 @@ func TestAzureMultiRecord(t *testing.T) {
 	validateAzureEndpoints(t, actual, expected)
 }
 
+func TestAzureAliasRecord(t *testing.T) {
+	publicIP := "/subscriptions/sub/resourceGroups/k8s/providers/Microsoft.Network/publicIPAddresses/ingress"
+	provider, err := newMockedAzureProvider(endpoint.NewDomainFilter([]string{"example.com"}), endpoint.NewDomainFilter([]string{}), provider.NewZoneIDFilter([]string{""}), true, "k8s", "",
+		[]*dns.Zone{
+			createMockZone("example.com", "/dnszones/example.com"),
+		},
+		[]*dns.RecordSet{
+			{
+				Name: to.Ptr("@"),
+				Type: to.Ptr("Microsoft.Network/dnszones/" + endpoint.RecordTypeA),
+				Properties: &dns.RecordSetProperties{
+					TTL:            to.Ptr(int64(60)),
+					TargetResource: &dns.SubResource{ID: to.Ptr(publicIP)},
+				},
+			},
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := provider.Records(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	validateAzureEndpoints(t, actual, []*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("example.com", endpoint.RecordTypeALIAS, 60, publicIP),
+	})
+
+	alias := endpoint.NewEndpointWithTTL("example.com", endpoint.RecordTypeALIAS, 60, publicIP)
+	assert.True(t, provider.SupportsAlias(alias))
+	assert.False(t, provider.SupportsAlias(endpoint.NewEndpoint("example.com", endpoint.RecordTypeALIAS, "lb.example.org")))
+	assert.Equal(t, dns.RecordTypeA, azureRecordType(alias))
+
+	recordSet, err := provider.newRecordSet(alias)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, publicIP, *recordSet.Properties.TargetResource.ID)
+	assert.Nil(t, recordSet.Properties.ARecords)
+}
+
+func TestAzureZoneIDNames(t *testing.T) {
+	provider, err := newMockedAzureProvider(endpoint.NewDomainFilter([]string{"example.com"}), endpoint.NewDomainFilter([]string{}), provider.NewZoneIDFilter([]string{""}), true, "k8s", "",
+		[]*dns.Zone{
+			createMockZone("example.com", "/dnszones/example.com"),
+			createMockZone("other.org", "/dnszones/other.org"),
+		},
+		nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zones, err := provider.ZoneIDNames(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{"example.com": "example.com"}, map[string]string(zones))
+}
+
 func TestAzureApplyChanges(t *testing.T) {
 	recordsClient := mockRecordSetsClient{}
 
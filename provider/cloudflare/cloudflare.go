@@ -53,12 +53,17 @@ var proxyEnabled *bool = boolPtr(true)
 var proxyDisabled *bool = boolPtr(false)
 
 var recordTypeProxyNotSupported = map[string]bool{
-	"LOC": true,
-	"MX":  true,
-	"NS":  true,
-	"SPF": true,
-	"TXT": true,
-	"SRV": true,
+	"LOC":   true,
+	"MX":    true,
+	"NS":    true,
+	"SPF":   true,
+	"TXT":   true,
+	"SRV":   true,
+	"CAA":   true,
+	"TLSA":  true,
+	"SSHFP": true,
+	"SVCB":  true,
+	"HTTPS": true,
 }
 
 // cloudFlareDNS is the subset of the CloudFlare API that we actually use.  Add methods as required. Signatures must match exactly.
@@ -146,6 +151,7 @@ func getUpdateDNSRecordParam(cfc cloudFlareChange) cloudflare.UpdateDNSRecordPar
 		Proxied: cfc.ResourceRecord.Proxied,
 		Type:    cfc.ResourceRecord.Type,
 		Content: cfc.ResourceRecord.Content,
+		Data:    cfc.ResourceRecord.Data,
 	}
 }
 
@@ -157,6 +163,7 @@ func getCreateDNSRecordParam(cfc cloudFlareChange) cloudflare.CreateDNSRecordPar
 		Proxied: cfc.ResourceRecord.Proxied,
 		Type:    cfc.ResourceRecord.Type,
 		Content: cfc.ResourceRecord.Content,
+		Data:    cfc.ResourceRecord.Data,
 	}
 }
 
@@ -237,6 +244,20 @@ func (p *CloudFlareProvider) Zones(ctx context.Context) ([]cloudflare.Zone, erro
 	return result, nil
 }
 
+// ZoneIDNames returns the names of the zones keyed by their ID.
+func (p *CloudFlareProvider) ZoneIDNames(ctx context.Context) (provider.ZoneIDName, error) {
+	zones, err := p.Zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneIDName := provider.ZoneIDName{}
+	for _, z := range zones {
+		zoneIDName.Add(z.ID, z.Name)
+	}
+	return zoneIDName, nil
+}
+
 // Records returns the list of records.
 func (p *CloudFlareProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 	zones, err := p.Zones(ctx)
@@ -314,6 +335,10 @@ func (p *CloudFlareProvider) submitChanges(ctx context.Context, changes []*cloud
 
 	var failedZones []string
 	for zoneID, changes := range changesByZone {
+		// with --zone-concurrency, every call only changes a single zone
+		if len(changes) == 0 {
+			continue
+		}
 		records, err := p.listDNSRecordsWithAutoPagination(ctx, zoneID)
 		if err != nil {
 			return fmt.Errorf("could not fetch records from zone, %v", err)
@@ -386,6 +411,10 @@ func (p *CloudFlareProvider) submitChanges(ctx context.Context, changes []*cloud
 func (p *CloudFlareProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
 	adjustedEndpoints := []*endpoint.Endpoint{}
 	for _, e := range endpoints {
+		// CloudFlare flattens CNAME records at the zone apex
+		if e.RecordType == endpoint.RecordTypeALIAS {
+			e.RecordType = endpoint.RecordTypeCNAME
+		}
 		proxied := shouldBeProxied(e, p.proxiedByDefault)
 		if proxied {
 			e.RecordTTL = 0
@@ -397,6 +426,11 @@ func (p *CloudFlareProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]
 	return adjustedEndpoints, nil
 }
 
+// SupportsAlias returns true, ALIAS endpoints are published as CNAME records
+func (p *CloudFlareProvider) SupportsAlias(*endpoint.Endpoint) bool {
+	return true
+}
+
 // changesByZone separates a multi-zone change into a single change per zone.
 func (p *CloudFlareProvider) changesByZone(zones []cloudflare.Zone, changeSet []*cloudFlareChange) map[string][]*cloudFlareChange {
 	changes := make(map[string][]*cloudFlareChange)
@@ -421,7 +455,8 @@ func (p *CloudFlareProvider) changesByZone(zones []cloudflare.Zone, changeSet []
 
 func (p *CloudFlareProvider) getRecordID(records []cloudflare.DNSRecord, record cloudflare.DNSRecord) string {
 	for _, zoneRecord := range records {
-		if zoneRecord.Name == record.Name && zoneRecord.Type == record.Type && zoneRecord.Content == record.Content {
+		if zoneRecord.Name == record.Name && zoneRecord.Type == record.Type &&
+			endpoint.NormalizeTarget(zoneRecord.Type, zoneRecord.Content) == endpoint.NormalizeTarget(record.Type, record.Content) {
 			return zoneRecord.ID
 		}
 	}
@@ -444,10 +479,46 @@ func (p *CloudFlareProvider) newCloudFlareChange(action string, endpoint *endpoi
 			Proxied: &proxied,
 			Type:    endpoint.RecordType,
 			Content: target,
+			Data:    recordData(endpoint.RecordType, target),
 		},
 	}
 }
 
+// recordData returns the structured data CloudFlare requires instead of the content for some record types, or nil
+func recordData(recordType, target string) interface{} {
+	switch recordType {
+	case endpoint.RecordTypeCAA:
+		caa, err := endpoint.ParseCAATarget(target)
+		if err != nil {
+			return nil
+		}
+		return map[string]interface{}{"flags": caa.Flags, "tag": caa.Tag, "value": caa.Value}
+	case endpoint.RecordTypeTLSA:
+		tlsa, err := endpoint.ParseTLSATarget(target)
+		if err != nil {
+			return nil
+		}
+		return map[string]interface{}{"usage": tlsa.Usage, "selector": tlsa.Selector, "matching_type": tlsa.MatchingType, "certificate": tlsa.Data}
+	case endpoint.RecordTypeSSHFP:
+		sshfp, err := endpoint.ParseSSHFPTarget(target)
+		if err != nil {
+			return nil
+		}
+		return map[string]interface{}{"algorithm": sshfp.Algorithm, "type": sshfp.Type, "fingerprint": sshfp.Fingerprint}
+	case endpoint.RecordTypeSVCB, endpoint.RecordTypeHTTPS:
+		svcb, err := endpoint.ParseSVCBTarget(recordType, target)
+		if err != nil {
+			return nil
+		}
+		params := make([]string, 0, len(svcb.Params))
+		for _, param := range svcb.Params {
+			params = append(params, param.String())
+		}
+		return map[string]interface{}{"priority": svcb.Priority, "target": provider.EnsureTrailingDot(svcb.Target), "value": strings.Join(params, " ")}
+	}
+	return nil
+}
+
 // listDNSRecords performs automatic pagination of results on requests to cloudflare.ListDNSRecords with custom per_page values
 func (p *CloudFlareProvider) listDNSRecordsWithAutoPagination(ctx context.Context, zoneID string) ([]cloudflare.DNSRecord, error) {
 	var records []cloudflare.DNSRecord
@@ -496,7 +567,7 @@ func groupByNameAndType(records []cloudflare.DNSRecord) []*endpoint.Endpoint {
 	groups := map[string][]cloudflare.DNSRecord{}
 
 	for _, r := range records {
-		if !provider.SupportedRecordType(r.Type) {
+		if !supportedRecordType(r.Type) {
 			continue
 		}
 
@@ -527,6 +598,16 @@ func groupByNameAndType(records []cloudflare.DNSRecord) []*endpoint.Endpoint {
 	return endpoints
 }
 
+// supportedRecordType returns true for the record types CloudFlare supports in addition to the common ones
+func supportedRecordType(recordType string) bool {
+	switch recordType {
+	case endpoint.RecordTypeCAA, endpoint.RecordTypeTLSA, endpoint.RecordTypeSSHFP, endpoint.RecordTypeSVCB, endpoint.RecordTypeHTTPS:
+		return true
+	default:
+		return provider.SupportedRecordType(recordType)
+	}
+}
+
 // boolPtr is used as a helper function to return a pointer to a boolean
 // Needed because some parameters require a pointer.
 func boolPtr(b bool) *bool {
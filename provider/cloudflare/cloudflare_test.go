@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
@@ -117,6 +118,7 @@ func getDNSRecordFromRecordParams(rp any) cloudflare.DNSRecord {
 			Proxied: params.Proxied,
 			Type:    params.Type,
 			Content: params.Content,
+			Data:    params.Data,
 		}
 	case cloudflare.UpdateDNSRecordParams:
 		return cloudflare.DNSRecord{
@@ -125,6 +127,7 @@ func getDNSRecordFromRecordParams(rp any) cloudflare.DNSRecord {
 			Proxied: params.Proxied,
 			Type:    params.Type,
 			Content: params.Content,
+			Data:    params.Data,
 		}
 	default:
 		return cloudflare.DNSRecord{}
@@ -319,10 +322,10 @@ func AssertActions(t *testing.T, provider *CloudFlareProvider, endpoints []*endp
 
 	changes := plan.Calculate().Changes
 
-	// Records other than A, CNAME and NS are not supported by planner, just create them
-	for _, endpoint := range endpoints {
-		if endpoint.RecordType != "A" && endpoint.RecordType != "CNAME" && endpoint.RecordType != "NS" {
-			changes.Create = append(changes.Create, endpoint)
+	// Records of types which are not managed are not supported by planner, just create them
+	for _, ep := range endpoints {
+		if !slices.Contains(managedRecords, ep.RecordType) {
+			changes.Create = append(changes.Create, ep)
 		}
 	}
 
@@ -372,6 +375,58 @@ func TestCloudflareA(t *testing.T) {
 	)
 }
 
+func TestCloudflareStructuredRecords(t *testing.T) {
+	// the planner does not order the changes of distinct record types, so every record type is asserted on its own
+	AssertActions(t, &CloudFlareProvider{}, []*endpoint.Endpoint{
+		{
+			RecordType: endpoint.RecordTypeCAA,
+			DNSName:    "bar.com",
+			Targets:    endpoint.Targets{`0 issue "letsencrypt.org"`},
+			ProviderSpecific: endpoint.ProviderSpecific{
+				{Name: "external-dns.alpha.kubernetes.io/cloudflare-proxied", Value: "true"},
+			},
+		},
+	}, []MockAction{
+		{
+			Name:   "Create",
+			ZoneId: "001",
+			RecordData: cloudflare.DNSRecord{
+				Type:    endpoint.RecordTypeCAA,
+				Name:    "bar.com",
+				Content: `0 issue "letsencrypt.org"`,
+				Data:    map[string]interface{}{"flags": uint8(0), "tag": "issue", "value": "letsencrypt.org"},
+				TTL:     1,
+				Proxied: proxyDisabled,
+			},
+		},
+	},
+		[]string{endpoint.RecordTypeCAA, endpoint.RecordTypeHTTPS},
+	)
+
+	AssertActions(t, &CloudFlareProvider{}, []*endpoint.Endpoint{
+		{
+			RecordType: endpoint.RecordTypeHTTPS,
+			DNSName:    "bar.com",
+			Targets:    endpoint.Targets{"1 . alpn=h2"},
+		},
+	}, []MockAction{
+		{
+			Name:   "Create",
+			ZoneId: "001",
+			RecordData: cloudflare.DNSRecord{
+				Type:    endpoint.RecordTypeHTTPS,
+				Name:    "bar.com",
+				Content: "1 . alpn=h2",
+				Data:    map[string]interface{}{"priority": uint16(1), "target": ".", "value": "alpn=h2"},
+				TTL:     1,
+				Proxied: proxyDisabled,
+			},
+		},
+	},
+		[]string{endpoint.RecordTypeCAA, endpoint.RecordTypeHTTPS},
+	)
+}
+
 func TestCloudflareCname(t *testing.T) {
 	endpoints := []*endpoint.Endpoint{
 		{
@@ -624,6 +679,18 @@ func TestCloudflareZones(t *testing.T) {
 	assert.Equal(t, "bar.com", zones[0].Name)
 }
 
+func TestCloudflareZoneIDNames(t *testing.T) {
+	p := &CloudFlareProvider{
+		Client:       NewMockCloudFlareClient(),
+		domainFilter: endpoint.NewDomainFilter([]string{"bar.com"}),
+		zoneIDFilter: provider.NewZoneIDFilter([]string{""}),
+	}
+
+	zones, err := p.ZoneIDNames(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, provider.ZoneIDName{"001": "bar.com"}, zones)
+}
+
 func TestCloudFlareZonesWithIDFilter(t *testing.T) {
 	client := NewMockCloudFlareClient()
 	client.listZonesError = errors.New("shouldn't need to list zones when ZoneIDFilter in use")
@@ -858,6 +925,18 @@ func TestCloudflareGetRecordID(t *testing.T) {
 		Type:    endpoint.RecordTypeA,
 		Content: "1.2.3.4",
 	}))
+
+	records = append(records, cloudflare.DNSRecord{
+		Name:    "bar.de",
+		Type:    endpoint.RecordTypeCAA,
+		Content: `0 issue "letsencrypt.org"`,
+		ID:      "3",
+	})
+	assert.Equal(t, "3", p.getRecordID(records, cloudflare.DNSRecord{
+		Name:    "bar.de",
+		Type:    endpoint.RecordTypeCAA,
+		Content: "0 ISSUE letsencrypt.org",
+	}))
 }
 
 func TestCloudflareGroupByNameAndType(t *testing.T) {
@@ -1333,3 +1412,17 @@ func TestCustomTTLWithEnabledProxyNotChanged(t *testing.T) {
 	assert.Equal(t, 0, len(planned.Changes.UpdateOld), "no new changes should be here")
 	assert.Equal(t, 0, len(planned.Changes.Delete), "no new changes should be here")
 }
+
+func TestCloudflareAdjustEndpointsAlias(t *testing.T) {
+	p := &CloudFlareProvider{proxiedByDefault: true}
+	assert.True(t, p.SupportsAlias(endpoint.NewEndpoint("bar.com", endpoint.RecordTypeALIAS, "lb.example.com")))
+
+	endpoints, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("bar.com", endpoint.RecordTypeALIAS, "lb.example.com"),
+	})
+	assert.NoError(t, err)
+	assert.Len(t, endpoints, 1)
+	assert.Equal(t, endpoint.RecordTypeCNAME, endpoints[0].RecordType)
+	proxied, _ := endpoints[0].GetProviderSpecificProperty("external-dns.alpha.kubernetes.io/cloudflare-proxied")
+	assert.Equal(t, "true", proxied)
+}
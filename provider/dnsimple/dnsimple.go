@@ -184,7 +184,7 @@ func (p *dnsimpleProvider) Records(ctx context.Context) (endpoints []*endpoint.E
 			}
 			for _, record := range records.Data {
 				switch record.Type {
-				case "A", "CNAME", "TXT":
+				case "A", "CNAME", "TXT", endpoint.RecordTypeALIAS:
 					break
 				default:
 					continue
@@ -206,6 +206,11 @@ func (p *dnsimpleProvider) Records(ctx context.Context) (endpoints []*endpoint.E
 	return endpoints, nil
 }
 
+// SupportsAlias returns true, ALIAS endpoints are published as DNSimple ALIAS records
+func (p *dnsimpleProvider) SupportsAlias(*endpoint.Endpoint) bool {
+	return true
+}
+
 // newDnsimpleChange initializes a new change to dns records
 func newDnsimpleChange(action string, e *endpoint.Endpoint) *dnsimpleChange {
 	ttl := dnsimpleRecordTTL
@@ -96,7 +96,18 @@ func TestDnsimpleServices(t *testing.T) {
 		Type:     "A",
 	}
 
-	records := []dnsimple.ZoneRecord{firstRecord, secondRecord, thirdRecord, fourthRecord}
+	fifthRecord := dnsimple.ZoneRecord{
+		ID:       5,
+		ZoneID:   "example.com",
+		ParentID: 0,
+		Name:     "alias",
+		Content:  "target.example.org",
+		TTL:      3600,
+		Priority: 0,
+		Type:     "ALIAS",
+	}
+
+	records := []dnsimple.ZoneRecord{firstRecord, secondRecord, thirdRecord, fourthRecord, fifthRecord}
 	dnsimpleListRecordsResponse = dnsimple.ZoneRecordsResponse{
 		Response: dnsimple.Response{Pagination: &dnsimple.Pagination{}},
 		Data:     records,
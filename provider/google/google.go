@@ -208,6 +208,20 @@ func (p *GoogleProvider) Zones(ctx context.Context) (map[string]*dns.ManagedZone
 	return zones, nil
 }
 
+// ZoneIDNames returns the DNS names of all relevant zones keyed by zone name.
+func (p *GoogleProvider) ZoneIDNames(ctx context.Context) (provider.ZoneIDName, error) {
+	zones, err := p.Zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zoneIDName := provider.ZoneIDName{}
+	for _, z := range zones {
+		zoneIDName.Add(z.Name, z.DnsName)
+	}
+	return zoneIDName, nil
+}
+
 // Records returns the list of records in all relevant zones.
 func (p *GoogleProvider) Records(ctx context.Context) (endpoints []*endpoint.Endpoint, _ error) {
 	zones, err := p.Zones(ctx)
@@ -252,7 +266,7 @@ func (p *GoogleProvider) ApplyChanges(ctx context.Context, changes *plan.Changes
 // SupportedRecordType returns true if the record type is supported by the provider
 func (p *GoogleProvider) SupportedRecordType(recordType string) bool {
 	switch recordType {
-	case "MX":
+	case endpoint.RecordTypeMX, endpoint.RecordTypeCAA, endpoint.RecordTypeTLSA, endpoint.RecordTypeSSHFP, endpoint.RecordTypeSVCB, endpoint.RecordTypeHTTPS:
 		return true
 	default:
 		return provider.SupportedRecordType(recordType)
@@ -445,6 +459,19 @@ func newRecord(ep *endpoint.Endpoint) *dns.ResourceRecordSet {
 		}
 	}
 
+	if ep.RecordType == endpoint.RecordTypeSVCB || ep.RecordType == endpoint.RecordTypeHTTPS {
+		for i, svcbRecord := range ep.Targets {
+			targets[i] = provider.EnsureSVCBTrailingDot(ep.RecordType, svcbRecord)
+		}
+	}
+
+	// Cloud DNS requires the value of a CAA record to be quoted
+	if ep.RecordType == endpoint.RecordTypeCAA {
+		for i, caaRecord := range ep.Targets {
+			targets[i] = endpoint.NormalizeTarget(ep.RecordType, caaRecord)
+		}
+	}
+
 	// no annotation results in a Ttl of 0, default to 300 for backwards-compatibility
 	var ttl int64 = googleRecordTTL
 	if ep.RecordTTL.IsConfigured() {
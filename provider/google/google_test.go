@@ -463,6 +463,8 @@ func TestNewFilteredRecords(t *testing.T) {
 		// test fallback to Ttl:300 when Ttl==0 :
 		endpoint.NewEndpointWithTTL("update-test.zone-1.ext-dns-test-2.gcp.zalan.do", endpoint.RecordTypeA, 0, "8.8.8.8"),
 		endpoint.NewEndpointWithTTL("update-test-mx.zone-1.ext-dns-test-2.gcp.zalan.do", endpoint.RecordTypeMX, 6000, "10 mail.elb.amazonaws.com"),
+		endpoint.NewEndpointWithTTL("update-test-caa.zone-1.ext-dns-test-2.gcp.zalan.do", endpoint.RecordTypeCAA, 6000, `0 issue "letsencrypt.org"`, "0 iodef mailto:security@example.org"),
+		endpoint.NewEndpointWithTTL("update-test-https.zone-1.ext-dns-test-2.gcp.zalan.do", endpoint.RecordTypeHTTPS, 6000, "1 svc.elb.amazonaws.com alpn=h2,h3"),
 		endpoint.NewEndpoint("delete-test.zone-1.ext-dns-test-2.gcp.zalan.do", endpoint.RecordTypeA, "8.8.8.8"),
 		endpoint.NewEndpoint("delete-test-cname.zone-1.ext-dns-test-2.gcp.zalan.do", endpoint.RecordTypeCNAME, "qux.elb.amazonaws.com"),
 	})
@@ -473,6 +475,8 @@ func TestNewFilteredRecords(t *testing.T) {
 		{Name: "update-test-cname.zone-1.ext-dns-test-2.gcp.zalan.do.", Rrdatas: []string{"bar.elb.amazonaws.com."}, Type: "CNAME", Ttl: 4000},
 		{Name: "update-test.zone-1.ext-dns-test-2.gcp.zalan.do.", Rrdatas: []string{"8.8.8.8"}, Type: "A", Ttl: 300},
 		{Name: "update-test-mx.zone-1.ext-dns-test-2.gcp.zalan.do.", Rrdatas: []string{"10 mail.elb.amazonaws.com."}, Type: "MX", Ttl: 6000},
+		{Name: "update-test-caa.zone-1.ext-dns-test-2.gcp.zalan.do.", Rrdatas: []string{`0 issue "letsencrypt.org"`, `0 iodef "mailto:security@example.org"`}, Type: "CAA", Ttl: 6000},
+		{Name: "update-test-https.zone-1.ext-dns-test-2.gcp.zalan.do.", Rrdatas: []string{"1 svc.elb.amazonaws.com. alpn=h2,h3"}, Type: "HTTPS", Ttl: 6000},
 		{Name: "delete-test.zone-1.ext-dns-test-2.gcp.zalan.do.", Rrdatas: []string{"8.8.8.8"}, Type: "A", Ttl: 300},
 		{Name: "delete-test-cname.zone-1.ext-dns-test-2.gcp.zalan.do.", Rrdatas: []string{"qux.elb.amazonaws.com."}, Type: "CNAME", Ttl: 300},
 	})
@@ -121,6 +121,11 @@ func (im *InMemoryProvider) Zones() map[string]string {
 	return im.filter.Zones(im.client.Zones())
 }
 
+// ZoneIDNames returns the filtered zones keyed by zone ID
+func (im *InMemoryProvider) ZoneIDNames(ctx context.Context) (provider.ZoneIDName, error) {
+	return provider.ZoneIDName(im.Zones()), nil
+}
+
 // Records returns the list of endpoints
 func (im *InMemoryProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 	defer im.OnRecords()
@@ -162,7 +162,7 @@ func (p *NS1Provider) Records(ctx context.Context) ([]*endpoint.Endpoint, error)
 		}
 
 		for _, record := range zoneData.Records {
-			if provider.SupportedRecordType(record.Type) {
+			if provider.SupportedRecordType(record.Type) || record.Type == endpoint.RecordTypeALIAS {
 				endpoints = append(endpoints, endpoint.NewEndpointWithTTL(
 					record.Domain,
 					record.Type,
@@ -177,6 +177,11 @@ func (p *NS1Provider) Records(ctx context.Context) ([]*endpoint.Endpoint, error)
 	return endpoints, nil
 }
 
+// SupportsAlias returns true, ALIAS endpoints are published as NS1 ALIAS records
+func (p *NS1Provider) SupportsAlias(*endpoint.Endpoint) bool {
+	return true
+}
+
 // ns1BuildRecord returns a dns.Record for a change set
 func (p *NS1Provider) ns1BuildRecord(zoneName string, change *ns1Change) *dns.Record {
 	record := dns.NewRecord(zoneName, change.Endpoint.DNSName, change.Endpoint.RecordType, map[string]string{}, []string{})
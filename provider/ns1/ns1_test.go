@@ -58,9 +58,16 @@ func (m *MockNS1DomainClient) GetZone(zone string) (*dns.Zone, *http.Response, e
 		Type:     "A",
 		ID:       "123456789abcdefghijklmno",
 	}
+	alias := &dns.ZoneRecord{
+		Domain:   "foo.com",
+		ShortAns: []string{"lb.bar.com"},
+		TTL:      3600,
+		Type:     "ALIAS",
+		ID:       "123456789abcdefghijklmnp",
+	}
 	z := &dns.Zone{
 		Zone:    "foo.com",
-		Records: []*dns.ZoneRecord{r},
+		Records: []*dns.ZoneRecord{r, alias},
 		TTL:     3600,
 		ID:      "12345678910111213141516a",
 	}
@@ -138,7 +145,9 @@ func TestNS1Records(t *testing.T) {
 
 	records, err := provider.Records(ctx)
 	require.NoError(t, err)
-	assert.Equal(t, 1, len(records))
+	assert.Equal(t, 2, len(records))
+	assert.Equal(t, endpoint.RecordTypeALIAS, records[1].RecordType)
+	assert.True(t, provider.SupportsAlias(records[1]))
 
 	provider.client = &MockNS1GetZoneFail{}
 	_, err = provider.Records(ctx)
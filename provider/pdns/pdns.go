@@ -271,6 +271,21 @@ func (p *PDNSProvider) convertRRSetToEndpoints(rr pgo.RrSet) (endpoints []*endpo
 	return endpoints, nil
 }
 
+// AdjustEndpoints publishes ALIAS endpoints as CNAME records, which are converted to ALIAS records at the zone apex
+func (p *PDNSProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	for _, ep := range endpoints {
+		if ep.RecordType == endpoint.RecordTypeALIAS {
+			ep.RecordType = endpoint.RecordTypeCNAME
+		}
+	}
+	return endpoints, nil
+}
+
+// SupportsAlias returns true, ALIAS endpoints are published as CNAME or ALIAS records
+func (p *PDNSProvider) SupportsAlias(*endpoint.Endpoint) bool {
+	return true
+}
+
 // ConvertEndpointsToZones marshals endpoints into pdns compatible Zone structs
 func (p *PDNSProvider) ConvertEndpointsToZones(eps []*endpoint.Endpoint, changetype pdnsChangeType) (zonelist []pgo.Zone, _ error) {
 	zonelist = []pgo.Zone{}
@@ -317,6 +332,10 @@ func (p *PDNSProvider) ConvertEndpointsToZones(eps []*endpoint.Endpoint, changet
 					if ep.RecordType == "CNAME" || ep.RecordType == "ALIAS" {
 						t = provider.EnsureTrailingDot(t)
 					}
+					// PowerDNS requires fully qualified target names
+					if ep.RecordType == endpoint.RecordTypeSVCB || ep.RecordType == endpoint.RecordTypeHTTPS {
+						t = provider.EnsureSVCBTrailingDot(ep.RecordType, t)
+					}
 					records = append(records, pgo.Record{Content: t})
 				}
 
@@ -407,6 +426,21 @@ func (p *PDNSProvider) mutateRecords(endpoints []*endpoint.Endpoint, changetype
 	return nil
 }
 
+// ZoneIDNames returns the names of the zones matching the domain filter keyed by their ID.
+func (p *PDNSProvider) ZoneIDNames(ctx context.Context) (provider.ZoneIDName, error) {
+	zones, _, err := p.client.ListZones()
+	if err != nil {
+		return nil, err
+	}
+	filteredZones, _ := p.client.PartitionZones(zones)
+
+	zoneIDName := provider.ZoneIDName{}
+	for _, zone := range filteredZones {
+		zoneIDName.Add(zone.Id, zone.Name)
+	}
+	return zoneIDName, nil
+}
+
 // Records returns all DNS records controlled by the configured PDNS server (for all zones)
 func (p *PDNSProvider) Records(ctx context.Context) (endpoints []*endpoint.Endpoint, _ error) {
 	zones, _, err := p.client.ListZones()
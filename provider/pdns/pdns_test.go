@@ -29,6 +29,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/provider"
 )
 
 // FIXME: What do we do about labels?
@@ -887,6 +888,23 @@ func (suite *NewPDNSProviderTestSuite) TestPDNSRecords() {
 	assert.NotNil(suite.T(), err)
 }
 
+func (suite *NewPDNSProviderTestSuite) TestPDNSZoneIDNames() {
+	p := &PDNSProvider{
+		client: &PDNSAPIClientStubPartitionZones{},
+	}
+
+	// only the zones matching the domain filter are returned
+	zones, err := p.ZoneIDNames(context.Background())
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), provider.ZoneIDName{"example.com.": "example.com."}, zones)
+
+	p = &PDNSProvider{
+		client: &PDNSAPIClientStubListZonesFailure{},
+	}
+	_, err = p.ZoneIDNames(context.Background())
+	assert.NotNil(suite.T(), err)
+}
+
 func (suite *NewPDNSProviderTestSuite) TestPDNSConvertEndpointsToZones() {
 	// Function definition: ConvertEndpointsToZones(endpoints []*endpoint.Endpoint, changetype pdnsChangeType) (zonelist []pgo.Zone, _ error)
 
@@ -944,12 +962,39 @@ func (suite *NewPDNSProviderTestSuite) TestPDNSConvertEndpointsToZones() {
 		}
 	}
 
+	// Check endpoints of type HTTPS have a fully qualified target name
+	zlist, err = p.ConvertEndpointsToZones([]*endpoint.Endpoint{
+		endpoint.NewEndpointWithTTL("svc.example.com", endpoint.RecordTypeHTTPS, endpoint.TTL(300), "1 svc.example.com alpn=h2"),
+	}, PdnsReplace)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), zlist, 1)
+	assert.Equal(suite.T(), "1 svc.example.com. alpn=h2", zlist[0].Rrsets[0].Records[0].Content)
+
 	// Check endpoints of type CNAME are converted to ALIAS on the domain apex
 	zlist, err = p.ConvertEndpointsToZones(endpointsApexRecords, PdnsReplace)
 	assert.Nil(suite.T(), err)
 	assert.Equal(suite.T(), []pgo.Zone{ZoneEmptyToApexPatch}, zlist)
 }
 
+func (suite *NewPDNSProviderTestSuite) TestPDNSAdjustEndpointsAlias() {
+	p := &PDNSProvider{
+		client: &PDNSAPIClientStubEmptyZones{},
+	}
+	assert.True(suite.T(), p.SupportsAlias(endpoint.NewEndpoint("example.com", endpoint.RecordTypeALIAS, "lb.example.org")))
+
+	endpoints, err := p.AdjustEndpoints([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("example.com", endpoint.RecordTypeALIAS, "lb.example.org"),
+	})
+	assert.Nil(suite.T(), err)
+
+	// the apex CNAME record is published as an ALIAS record
+	zlist, err := p.ConvertEndpointsToZones(endpoints, PdnsReplace)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), zlist, 1)
+	assert.Equal(suite.T(), "ALIAS", zlist[0].Rrsets[0].Type_)
+	assert.Equal(suite.T(), "lb.example.org.", zlist[0].Rrsets[0].Records[0].Content)
+}
+
 func (suite *NewPDNSProviderTestSuite) TestPDNSConvertEndpointsToZonesPartitionZones() {
 	// Test DomainFilters
 	p := &PDNSProvider{
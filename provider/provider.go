@@ -51,6 +51,14 @@ type Provider interface {
 	GetDomainFilter() endpoint.DomainFilter
 }
 
+// ZoneProvider is an optional interface a Provider can implement to report the
+// zones it manages. It allows the controller to apply changes zone by zone, so
+// providers implementing it must support concurrent calls to ApplyChanges when
+// each call only targets a single, distinct zone.
+type ZoneProvider interface {
+	ZoneIDNames(ctx context.Context) (ZoneIDName, error)
+}
+
 type BaseProvider struct{}
 
 func (b BaseProvider) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
@@ -81,6 +89,17 @@ func EnsureTrailingDot(hostname string) string {
 	return strings.TrimSuffix(hostname, ".") + "."
 }
 
+// EnsureSVCBTrailingDot returns an SVCB or HTTPS target with a fully qualified target name, for providers which
+// store the records in the zone file format. Targets which cannot be parsed are returned as is.
+func EnsureSVCBTrailingDot(recordType, target string) string {
+	svcb, err := endpoint.ParseSVCBTarget(recordType, target)
+	if err != nil {
+		return target
+	}
+	svcb.Target = EnsureTrailingDot(svcb.Target)
+	return svcb.String()
+}
+
 // Difference tells which entries need to be respectively
 // added, removed, or left untouched for "current" to be transformed to "desired"
 func Difference(current, desired []string) ([]string, []string, []string) {
@@ -23,6 +23,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+
+	"sigs.k8s.io/external-dns/endpoint"
 )
 
 func TestMain(m *testing.M) {
@@ -46,6 +48,12 @@ func TestEnsureTrailingDot(t *testing.T) {
 	}
 }
 
+func TestEnsureSVCBTrailingDot(t *testing.T) {
+	assert.Equal(t, "1 svc.example.org. alpn=h2", EnsureSVCBTrailingDot(endpoint.RecordTypeHTTPS, "1 svc.example.org alpn=h2"))
+	assert.Equal(t, "1 . alpn=h2", EnsureSVCBTrailingDot(endpoint.RecordTypeSVCB, `1 . alpn="h2"`))
+	assert.Equal(t, "invalid", EnsureSVCBTrailingDot(endpoint.RecordTypeSVCB, "invalid"))
+}
+
 func TestDifference(t *testing.T) {
 	current := []string{"foo", "bar"}
 	desired := []string{"bar", "baz"}
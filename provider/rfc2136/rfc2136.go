@@ -158,6 +158,15 @@ func (r rfc2136Provider) KeyData() (keyName string, handle *gss.Client, err erro
 	return keyName, handle, err
 }
 
+// ZoneIDNames returns the configured zones keyed by their name.
+func (r rfc2136Provider) ZoneIDNames(ctx context.Context) (provider.ZoneIDName, error) {
+	zoneIDName := provider.ZoneIDName{}
+	for _, z := range r.zoneNames {
+		zoneIDName.Add(dns.Fqdn(z), dns.Fqdn(z))
+	}
+	return zoneIDName, nil
+}
+
 // Records returns the list of records.
 func (r rfc2136Provider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 	rrs, err := r.List()
@@ -195,6 +204,10 @@ OuterLoop:
 		case dns.TypeNS:
 			rrValues = []string{rr.(*dns.NS).Ns}
 			rrType = "NS"
+		case dns.TypeCAA, dns.TypeTLSA, dns.TypeSSHFP, dns.TypeSVCB, dns.TypeHTTPS:
+			// the targets of these records are their RDATA in the presentation format
+			rrValues = []string{strings.TrimPrefix(rr.String(), rr.Header().String())}
+			rrType = dns.TypeToString[rr.Header().Rrtype]
 		default:
 			continue // Unhandled record type
 		}
@@ -334,6 +347,11 @@ func (r rfc2136Provider) ApplyChanges(ctx context.Context, changes *plan.Changes
 			r.krb5Realm = strings.ToUpper(zone)
 			m[zone].SetUpdate(zone)
 
+			// every target is a separate RR, so an update of the targets only touches the RRs which changed
+			if delta, ok := changes.TargetDeltas[ep.Key()]; ok {
+				r.UpdateTargets(m[zone], ep, delta)
+				continue
+			}
 			r.UpdateRecord(m[zone], changes.UpdateOld[i], ep)
 		}
 
@@ -399,6 +417,17 @@ func (r rfc2136Provider) UpdateRecord(m *dns.Msg, oldEp *endpoint.Endpoint, newE
 	return r.AddRecord(m, newEp)
 }
 
+// UpdateTargets only removes and adds the RRs of the targets in the delta
+func (r rfc2136Provider) UpdateTargets(m *dns.Msg, ep *endpoint.Endpoint, delta plan.TargetDelta) error {
+	removed, added := *ep, *ep
+	removed.Targets, added.Targets = delta.Removed, delta.Added
+	if err := r.RemoveRecord(m, &removed); err != nil {
+		return err
+	}
+
+	return r.AddRecord(m, &added)
+}
+
 func (r rfc2136Provider) AddRecord(m *dns.Msg, ep *endpoint.Endpoint) error {
 	log.Debugf("AddRecord.ep=%s", ep)
 
@@ -30,6 +30,7 @@ import (
 	"github.com/miekg/dns"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
@@ -371,6 +372,31 @@ func TestRfc2136GetRecords(t *testing.T) {
 	assert.True(t, contains(recs, "v2.foo.com"))
 }
 
+func TestRfc2136GetRecordsStructuredTypes(t *testing.T) {
+	stub := newStub()
+	err := stub.setOutput([]string{
+		`caa.foo.com 3600 CAA 0 issue "letsencrypt.org"`,
+		`caa.foo.com 3600 CAA 0 iodef "mailto:security@foo.com"`,
+		`svc.foo.com 3600 HTTPS 1 . alpn="h2,h3" port=443`,
+		"_443._tcp.foo.com 3600 TLSA 3 1 1 0C72AC70B745AC19998811B131D662C9AC69DBDBE7CB23E5B514B56664C5D3D6",
+	})
+	assert.NoError(t, err)
+
+	provider, err := createRfc2136StubProvider(stub)
+	assert.NoError(t, err)
+
+	recs, err := provider.Records(context.Background())
+	assert.NoError(t, err)
+
+	require.Len(t, recs, 3)
+	assert.Equal(t, endpoint.RecordTypeCAA, recs[0].RecordType)
+	assert.Equal(t, endpoint.Targets{`0 issue "letsencrypt.org"`, `0 iodef "mailto:security@foo.com"`}, recs[0].Targets)
+	assert.Equal(t, endpoint.RecordTypeHTTPS, recs[1].RecordType)
+	assert.Equal(t, endpoint.Targets{"1 . alpn=h2,h3 port=443"}, recs[1].Targets.Normalized(endpoint.RecordTypeHTTPS))
+	assert.Equal(t, endpoint.RecordTypeTLSA, recs[2].RecordType)
+	assert.NoError(t, endpoint.ValidateTargets(endpoint.RecordTypeTLSA, recs[2].Targets))
+}
+
 // Make sure the test version of SendMessage raises an error
 // if a zone update ever contains records outside of it's zone
 // as the TestRfc2136ApplyChanges tests all assume this
@@ -455,6 +481,16 @@ func TestRfc2136ApplyChanges(t *testing.T) {
 
 // These tests all use the foo.com and foobar.com zones with no filters
 // createMsgs and updateMsgs need sorted when are are used
+func TestRfc2136ZoneIDNames(t *testing.T) {
+	stub := newStub()
+	p, err := createRfc2136StubProviderWithZones(stub)
+	assert.NoError(t, err)
+
+	zones, err := p.(provider.ZoneProvider).ZoneIDNames(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, provider.ZoneIDName{"foo.com.": "foo.com.", "foobar.com.": "foobar.com."}, zones)
+}
+
 func TestRfc2136ApplyChangesWithZones(t *testing.T) {
 	stub := newStub()
 	provider, err := createRfc2136StubProviderWithZones(stub)
@@ -713,6 +749,62 @@ func TestRfc2136ApplyChangesWithUpdate(t *testing.T) {
 	assert.True(t, strings.Contains(stub.updateMsgs[1].String(), "boom"))
 }
 
+func TestRfc2136ApplyChangesWithTargetsUpdate(t *testing.T) {
+	stub := newStub()
+
+	provider, err := createRfc2136StubProvider(stub)
+	assert.NoError(t, err)
+
+	p := &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("v1.foo.com", "A", endpoint.TTL(400), "1.1.1.1", "2.2.2.2"),
+			endpoint.NewEndpointWithTTL("v2.foo.com", "A", endpoint.TTL(400), "1.1.1.1", "2.2.2.2"),
+		},
+		UpdateNew: []*endpoint.Endpoint{
+			endpoint.NewEndpointWithTTL("v1.foo.com", "A", endpoint.TTL(400), "2.2.2.2", "3.3.3.3"),
+			endpoint.NewEndpointWithTTL("v2.foo.com", "A", endpoint.TTL(500), "2.2.2.2", "3.3.3.3"),
+		},
+		// the TTL of v2.foo.com changed, so it has no delta
+		TargetDeltas: map[endpoint.EndpointKey]plan.TargetDelta{
+			{DNSName: "v1.foo.com", RecordType: "A"}: {
+				Added:   endpoint.Targets{"3.3.3.3"},
+				Removed: endpoint.Targets{"1.1.1.1"},
+				Kept:    endpoint.Targets{"2.2.2.2"},
+			},
+		},
+	}
+
+	err = provider.ApplyChanges(context.Background(), p)
+	assert.NoError(t, err)
+
+	// both updates are sent in a single message
+	require.NotEmpty(t, stub.updateMsgs)
+	msg := stub.updateMsgs[0].String()
+	var v1, v2 []string
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		switch {
+		case strings.HasPrefix(line, "v1.foo.com."):
+			v1 = append(v1, line)
+		case strings.HasPrefix(line, "v2.foo.com."):
+			v2 = append(v2, line)
+		}
+	}
+
+	// only the changed target of v1.foo.com is touched
+	assert.ElementsMatch(t, []string{
+		"v1.foo.com. 0 NONE A 1.1.1.1",
+		"v1.foo.com. 400 IN A 3.3.3.3",
+	}, v1)
+	// the TTL of v2.foo.com changes, so the whole record is rewritten
+	assert.ElementsMatch(t, []string{
+		"v2.foo.com. 0 NONE A 1.1.1.1",
+		"v2.foo.com. 0 NONE A 2.2.2.2",
+		"v2.foo.com. 500 IN A 2.2.2.2",
+		"v2.foo.com. 500 IN A 3.3.3.3",
+	}, v2)
+}
+
 func TestChunkBy(t *testing.T) {
 	var records []*endpoint.Endpoint
 
@@ -75,10 +75,11 @@ func (sdr *AWSSDRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, er
 // inserted in the AWS SD instance as a CreateID field
 func (sdr *AWSSDRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
 	filteredChanges := &plan.Changes{
-		Create:    changes.Create,
-		UpdateNew: endpoint.FilterEndpointsByOwnerID(sdr.ownerID, changes.UpdateNew),
-		UpdateOld: endpoint.FilterEndpointsByOwnerID(sdr.ownerID, changes.UpdateOld),
-		Delete:    endpoint.FilterEndpointsByOwnerID(sdr.ownerID, changes.Delete),
+		Create:       changes.Create,
+		UpdateNew:    endpoint.FilterEndpointsByOwnerID(sdr.ownerID, changes.UpdateNew),
+		UpdateOld:    endpoint.FilterEndpointsByOwnerID(sdr.ownerID, changes.UpdateOld),
+		Delete:       endpoint.FilterEndpointsByOwnerID(sdr.ownerID, changes.Delete),
+		TargetDeltas: changes.TargetDeltas,
 	}
 
 	sdr.updateLabels(filteredChanges.Create)
@@ -0,0 +1,509 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+const (
+	configMapAttributeMigrate = "configmap/needs-migration"
+
+	// configMapRegistryLabel is the label of the ConfigMaps of a registry, whose value is the name of the registry
+	configMapRegistryLabel = "externaldns.k8s.io/registry"
+)
+
+// ConfigMapAPI is the subset of the Kubernetes ConfigMaps API of a namespace that we actually use, implemented by
+// the ConfigMaps of the CoreV1 client.
+type ConfigMapAPI interface {
+	List(ctx context.Context, opts metav1.ListOptions) (*corev1.ConfigMapList, error)
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*corev1.ConfigMap, error)
+	Create(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.CreateOptions) (*corev1.ConfigMap, error)
+	Update(ctx context.Context, configMap *corev1.ConfigMap, opts metav1.UpdateOptions) (*corev1.ConfigMap, error)
+}
+
+// ConfigMapRegistry implements registry interface with ownership kept in Kubernetes ConfigMaps. The labels of the
+// records are sharded over ConfigMaps named after the registry, which are shared by all owners and updated with
+// optimistic concurrency, so that a record is only registered by its first owner.
+type ConfigMapRegistry struct {
+	provider provider.Provider
+	ownerID  string // refers to the owner id of the current instance
+
+	configMapAPI ConfigMapAPI
+	namespace    string
+	name         string
+	shards       int
+
+	// For migration from TXT registry
+	mapper              nameMapper
+	wildcardReplacement string
+	managedRecordTypes  []string
+	excludeRecordTypes  []string
+	txtEncryptAESKey    []byte
+
+	// ApplyChanges may be called concurrently for distinct zones, the caches are only accessed with the mutex held.
+	cacheMux sync.Mutex
+
+	// cache the labels of the records owned by us, and the ConfigMaps they are kept in.
+	labels         map[endpoint.EndpointKey]endpoint.Labels
+	configMaps     map[endpoint.EndpointKey]string
+	orphanedLabels sets.Set[endpoint.EndpointKey]
+}
+
+// configMapEntry is the value of the key of a record in a ConfigMap of the registry
+type configMapEntry struct {
+	Owner  string            `json:"owner"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// configMapChange is a change of the entry of a record; an entry without labels is deleted
+type configMapChange struct {
+	key    endpoint.EndpointKey
+	labels endpoint.Labels
+}
+
+// NewConfigMapRegistry returns a new ConfigMapRegistry object.
+func NewConfigMapRegistry(provider provider.Provider, ownerID string, configMapAPI ConfigMapAPI, namespace, name string, shards int, txtPrefix, txtSuffix, txtWildcardReplacement string, managedRecordTypes, excludeRecordTypes []string, txtEncryptAESKey []byte) (*ConfigMapRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	if namespace == "" || name == "" {
+		return nil, errors.New("namespace and name of the ConfigMaps cannot be empty")
+	}
+	if shards < 1 {
+		return nil, errors.New("the number of ConfigMaps must be at least 1")
+	}
+
+	if len(txtEncryptAESKey) == 0 {
+		txtEncryptAESKey = nil
+	} else if len(txtEncryptAESKey) != 32 {
+		return nil, errors.New("the AES Encryption key must have a length of 32 bytes")
+	}
+	if len(txtPrefix) > 0 && len(txtSuffix) > 0 {
+		return nil, errors.New("txt-prefix and txt-suffix are mutually exclusive")
+	}
+
+	return &ConfigMapRegistry{
+		provider:            provider,
+		ownerID:             ownerID,
+		configMapAPI:        configMapAPI,
+		namespace:           namespace,
+		name:                name,
+		shards:              shards,
+		mapper:              newaffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement),
+		wildcardReplacement: txtWildcardReplacement,
+		managedRecordTypes:  managedRecordTypes,
+		excludeRecordTypes:  excludeRecordTypes,
+		txtEncryptAESKey:    txtEncryptAESKey,
+		configMaps:          map[endpoint.EndpointKey]string{},
+	}, nil
+}
+
+func (im *ConfigMapRegistry) GetDomainFilter() endpoint.DomainFilter {
+	return im.provider.GetDomainFilter()
+}
+
+func (im *ConfigMapRegistry) OwnerID() string {
+	return im.ownerID
+}
+
+// Records returns the current records from the registry.
+func (im *ConfigMapRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+
+	if im.labels == nil {
+		if err := im.readLabels(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanedLabels := sets.KeySet(im.labels)
+	endpoints := make([]*endpoint.Endpoint, 0, len(records))
+	migration := newTXTMigration(im.mapper, im.wildcardReplacement, im.txtEncryptAESKey)
+	for _, record := range records {
+		key := record.Key()
+		if labels := im.labels[key]; labels != nil {
+			record.Labels = labels
+			orphanedLabels.Delete(key)
+		} else {
+			record.Labels = endpoint.NewLabels()
+			if migration.collect(record) {
+				continue
+			}
+		}
+
+		endpoints = append(endpoints, record)
+	}
+
+	im.orphanedLabels = orphanedLabels
+
+	// Migrate label data from TXT registry.
+	migration.migrate(endpoints, im.labels, configMapAttributeMigrate)
+
+	// Remove any unused TXT ownership records owned by us
+	endpoints = append(endpoints, migration.obsoleteRecords(im.ownerID, im.managedRecordTypes, im.excludeRecordTypes)...)
+
+	return endpoints, nil
+}
+
+// ApplyChanges updates the DNS provider and the ConfigMaps with the changes.
+func (im *ConfigMapRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	filteredChanges := &plan.Changes{
+		Create:       changes.Create,
+		UpdateNew:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:       endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+		TargetDeltas: changes.TargetDeltas,
+	}
+
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	// The labels are dropped when the changes of another zone failed.
+	if im.labels == nil {
+		if err := im.readLabels(ctx); err != nil {
+			return err
+		}
+	}
+
+	entries := make([]configMapChange, 0, len(filteredChanges.Create)+len(filteredChanges.UpdateNew))
+	for _, r := range filteredChanges.Create {
+		if r.Labels == nil {
+			r.Labels = make(map[string]string)
+		}
+		r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+
+		key := r.Key()
+		if oldLabels := im.labels[key]; oldLabels != nil {
+			im.orphanedLabels.Delete(key)
+			if labelsEqual(oldLabels, r.Labels) {
+				continue
+			}
+		}
+		entries = append(entries, configMapChange{key: key, labels: r.Labels})
+		im.labels[key] = r.Labels
+	}
+
+	for _, r := range filteredChanges.Delete {
+		delete(im.labels, r.Key())
+	}
+
+	oldLabels := make(map[endpoint.EndpointKey]endpoint.Labels, len(filteredChanges.UpdateOld))
+	needMigration := map[endpoint.EndpointKey]bool{}
+	for _, r := range filteredChanges.UpdateOld {
+		oldLabels[r.Key()] = r.Labels
+
+		if _, ok := r.GetProviderSpecificProperty(configMapAttributeMigrate); ok {
+			needMigration[r.Key()] = true
+		}
+	}
+
+	for _, r := range filteredChanges.UpdateNew {
+		key := r.Key()
+		if needMigration[key] || !labelsEqual(oldLabels[key], r.Labels) {
+			entries = append(entries, configMapChange{key: key, labels: r.Labels})
+		}
+		im.labels[key] = r.Labels
+	}
+
+	skipped, err := im.updateConfigMaps(ctx, entries)
+	if err != nil {
+		im.labels = nil
+		return err
+	}
+	for key := range skipped {
+		for i, ep := range filteredChanges.Create {
+			if ep.Key() == key {
+				// We lost a race with a different owner or another owner has an orphaned ownership record.
+				log.Infof("Skipping endpoint %v because owner does not match", ep)
+				filteredChanges.Create = append(filteredChanges.Create[:i], filteredChanges.Create[i+1:]...)
+				delete(im.labels, key)
+				break
+			}
+		}
+	}
+
+	// The provider is called without the mutex, so that the changes of distinct zones are applied in parallel.
+	im.cacheMux.Unlock()
+	err = im.provider.ApplyChanges(ctx, filteredChanges)
+	im.cacheMux.Lock()
+	if err != nil {
+		im.labels = nil
+		return err
+	}
+
+	entries = make([]configMapChange, 0, len(filteredChanges.Delete)+len(im.orphanedLabels))
+	for _, r := range filteredChanges.Delete {
+		entries = append(entries, configMapChange{key: r.Key()})
+	}
+	for key := range im.orphanedLabels {
+		entries = append(entries, configMapChange{key: key})
+		delete(im.labels, key)
+	}
+	im.orphanedLabels = nil
+	if _, err := im.updateConfigMaps(ctx, entries); err != nil {
+		im.labels = nil
+		return err
+	}
+	return nil
+}
+
+// AdjustEndpoints modifies the endpoints as needed by the specific provider.
+func (im *ConfigMapRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return im.provider.AdjustEndpoints(endpoints)
+}
+
+func (im *ConfigMapRegistry) readLabels(ctx context.Context) error {
+	list, err := im.configMapAPI.List(ctx, metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", configMapRegistryLabel, im.name),
+	})
+	if err != nil {
+		return fmt.Errorf("listing ConfigMaps of registry %q: %w", im.name, err)
+	}
+
+	labels := map[endpoint.EndpointKey]endpoint.Labels{}
+	configMaps := map[endpoint.EndpointKey]string{}
+	for _, cm := range list.Items {
+		for dataKey, value := range cm.Data {
+			key, entry, err := fromConfigMapEntry(dataKey, value)
+			if err != nil {
+				log.Warnf("Ignoring invalid entry %q of ConfigMap %s/%s: %v", dataKey, cm.Namespace, cm.Name, err)
+				continue
+			}
+			if entry.Owner != im.ownerID {
+				continue
+			}
+			labels[key] = endpoint.NewLabels()
+			for k, v := range entry.Labels {
+				labels[key][k] = v
+			}
+			labels[key][endpoint.OwnerLabelKey] = im.ownerID
+			configMaps[key] = cm.Name
+		}
+	}
+
+	im.labels = labels
+	im.configMaps = configMaps
+	return nil
+}
+
+// updateConfigMaps writes the changes to the ConfigMaps of their records, and returns the keys of the records which
+// are registered by another owner and were not changed.
+func (im *ConfigMapRegistry) updateConfigMaps(ctx context.Context, changes []configMapChange) (sets.Set[endpoint.EndpointKey], error) {
+	byConfigMap := map[string][]configMapChange{}
+	for _, change := range changes {
+		name, ok := im.configMaps[change.key]
+		if !ok {
+			name = im.configMapName(change.key)
+		}
+		byConfigMap[name] = append(byConfigMap[name], change)
+	}
+	names := make([]string, 0, len(byConfigMap))
+	for name := range byConfigMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	skipped := sets.New[endpoint.EndpointKey]()
+	for _, name := range names {
+		conflicts, err := im.updateConfigMap(ctx, name, byConfigMap[name])
+		if err != nil {
+			return nil, err
+		}
+		skipped = skipped.Union(conflicts)
+	}
+	return skipped, nil
+}
+
+// updateConfigMap writes the changes to a ConfigMap, creating it if needed. The ConfigMap is updated with the
+// resourceVersion it was read with, and read again and updated when it was changed in the meantime.
+func (im *ConfigMapRegistry) updateConfigMap(ctx context.Context, name string, changes []configMapChange) (sets.Set[endpoint.EndpointKey], error) {
+	var skipped sets.Set[endpoint.EndpointKey]
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		skipped = sets.New[endpoint.EndpointKey]()
+		cm, err := im.configMapAPI.Get(ctx, name, metav1.GetOptions{})
+		create := apierrors.IsNotFound(err)
+		if create {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: im.namespace,
+					Labels:    map[string]string{configMapRegistryLabel: im.name},
+				},
+			}
+		} else if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+
+		for _, change := range changes {
+			dataKey := toConfigMapKey(change.key)
+			if value, ok := cm.Data[dataKey]; ok {
+				if _, entry, err := fromConfigMapEntry(dataKey, value); err == nil && entry.Owner != im.ownerID {
+					skipped.Insert(change.key)
+					continue
+				}
+			}
+			if change.labels == nil {
+				delete(cm.Data, dataKey)
+				delete(im.configMaps, change.key)
+				log.Infof("DELETE ConfigMap %s/%s entry for %q", im.namespace, name, change.key.DNSName)
+				continue
+			}
+			value, err := toConfigMapEntry(im.ownerID, change.labels)
+			if err != nil {
+				return err
+			}
+			cm.Data[dataKey] = value
+			im.configMaps[change.key] = name
+			log.Infof("UPSERT ConfigMap %s/%s entry for %q", im.namespace, name, change.key.DNSName)
+		}
+
+		if create {
+			_, err = im.configMapAPI.Create(ctx, cm, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// created by another owner in the meantime, read it again
+				return apierrors.NewConflict(corev1.Resource("configmaps"), name, err)
+			}
+			return err
+		}
+		_, err = im.configMapAPI.Update(ctx, cm, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("updating ConfigMap %s/%s: %w", im.namespace, name, err)
+	}
+	return skipped, nil
+}
+
+// configMapName returns the name of the ConfigMap a new record is registered in
+func (im *ConfigMapRegistry) configMapName(key endpoint.EndpointKey) string {
+	h := fnv.New32a()
+	h.Write([]byte(toConfigMapKey(key)))
+	return fmt.Sprintf("%s-%d", im.name, h.Sum32()%uint32(im.shards))
+}
+
+// toConfigMapKey encodes the key of a record to the characters allowed in the keys of ConfigMaps
+func toConfigMapKey(key endpoint.EndpointKey) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s#%s#%s", key.DNSName, key.RecordType, key.SetIdentifier)))
+}
+
+func fromConfigMapEntry(dataKey, value string) (endpoint.EndpointKey, configMapEntry, error) {
+	var entry configMapEntry
+	decoded, err := base64.RawURLEncoding.DecodeString(dataKey)
+	if err != nil {
+		return endpoint.EndpointKey{}, entry, err
+	}
+	split := strings.SplitN(string(decoded), "#", 3)
+	if len(split) != 3 {
+		return endpoint.EndpointKey{}, entry, fmt.Errorf("invalid key %q", decoded)
+	}
+	if err := json.Unmarshal([]byte(value), &entry); err != nil {
+		return endpoint.EndpointKey{}, entry, err
+	}
+	return endpoint.EndpointKey{DNSName: split[0], RecordType: split[1], SetIdentifier: split[2]}, entry, nil
+}
+
+func toConfigMapEntry(owner string, labels endpoint.Labels) (string, error) {
+	entry := configMapEntry{Owner: owner, Labels: map[string]string{}}
+	for k, v := range labels {
+		if k == endpoint.OwnerLabelKey {
+			continue
+		}
+		entry.Labels[k] = v
+	}
+	value, err := json.Marshal(entry)
+	return string(value), err
+}
+
+func labelsEqual(a, b endpoint.Labels) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+// InvalidateCache drops the cached labels, so that they are read again from the ConfigMaps with the next records.
+func (im *ConfigMapRegistry) InvalidateCache() {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	im.labels = nil
+}
+
+// StoredLabels reads the labels of the records owned by the registry from the ConfigMaps.
+func (im *ConfigMapRegistry) StoredLabels(ctx context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error) {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	if err := im.readLabels(ctx); err != nil {
+		return nil, err
+	}
+	return maps.Clone(im.labels), nil
+}
+
+// StoreLabels writes the labels of records to the ConfigMaps, and returns the keys of the records registered by
+// another owner.
+func (im *ConfigMapRegistry) StoreLabels(ctx context.Context, labels map[endpoint.EndpointKey]endpoint.Labels) ([]endpoint.EndpointKey, error) {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	if err := im.readLabels(ctx); err != nil {
+		return nil, err
+	}
+	changes := make([]configMapChange, 0, len(labels))
+	for key, l := range labels {
+		changes = append(changes, configMapChange{key: key, labels: l})
+	}
+	// The labels are read again with the records.
+	im.labels = nil
+
+	skipped, err := im.updateConfigMaps(ctx, changes)
+	if err != nil {
+		return nil, err
+	}
+	return skipped.UnsortedList(), nil
+}
@@ -0,0 +1,385 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+	"sigs.k8s.io/external-dns/provider/inmemory"
+)
+
+func TestConfigMapRegistryNew(t *testing.T) {
+	p := inmemory.NewInMemoryProvider()
+	api := newConfigMapAPIStub()
+
+	_, err := NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 4, "", "", "", []string{}, []string{}, nil)
+	require.NoError(t, err)
+
+	_, err = NewConfigMapRegistry(p, "", api, "default", "external-dns", 4, "", "", "", []string{}, []string{}, nil)
+	require.EqualError(t, err, "owner id cannot be empty")
+
+	_, err = NewConfigMapRegistry(p, "test-owner", api, "", "external-dns", 4, "", "", "", []string{}, []string{}, nil)
+	require.EqualError(t, err, "namespace and name of the ConfigMaps cannot be empty")
+
+	_, err = NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 0, "", "", "", []string{}, []string{}, nil)
+	require.EqualError(t, err, "the number of ConfigMaps must be at least 1")
+
+	_, err = NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 4, "", "", "", []string{}, []string{}, []byte("too-short"))
+	require.EqualError(t, err, "the AES Encryption key must have a length of 32 bytes")
+
+	_, err = NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 4, "testPrefix", "testSuffix", "", []string{}, []string{}, nil)
+	require.EqualError(t, err, "txt-prefix and txt-suffix are mutually exclusive")
+}
+
+func TestConfigMapRegistryRecords(t *testing.T) {
+	p := newConfigMapTestProvider(t,
+		endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeCNAME, "foo.loadbalancer.com"),
+		endpoint.NewEndpoint("bar.test-zone.example.org", endpoint.RecordTypeCNAME, "my-domain.com"),
+		endpoint.NewEndpoint("txt.bar.test-zone.example.org", endpoint.RecordTypeTXT, "\"heritage=external-dns,external-dns/owner=test-owner,external-dns/resource=ingress/default/bar\""),
+		endpoint.NewEndpoint("baz.test-zone.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+	)
+	api := newConfigMapAPIStub(
+		newRegistryConfigMap("external-dns-0", map[endpoint.EndpointKey]configMapEntry{
+			{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/foo"}},
+			{DNSName: "baz.test-zone.example.org", RecordType: endpoint.RecordTypeA}:     {Owner: "other-owner"},
+			{DNSName: "gone.test-zone.example.org", RecordType: endpoint.RecordTypeA}:    {Owner: "test-owner"},
+		}),
+	)
+
+	r, err := NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 4, "txt.", "", "", []string{endpoint.RecordTypeCNAME, endpoint.RecordTypeTXT}, nil, nil)
+	require.NoError(t, err)
+
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+
+	byName := map[string]*endpoint.Endpoint{}
+	for _, record := range records {
+		byName[record.DNSName] = record
+	}
+	require.Len(t, byName, 3)
+	assert.Equal(t, endpoint.Labels{
+		endpoint.OwnerLabelKey:    "test-owner",
+		endpoint.ResourceLabelKey: "ingress/default/foo",
+	}, byName["foo.test-zone.example.org"].Labels)
+	assert.Empty(t, byName["baz.test-zone.example.org"].Labels[endpoint.OwnerLabelKey])
+
+	// the labels of the TXT record are migrated, the TXT record is deleted once they are stored
+	bar := byName["bar.test-zone.example.org"]
+	assert.Equal(t, "test-owner", bar.Labels[endpoint.OwnerLabelKey])
+	assert.Equal(t, "ingress/default/bar", bar.Labels[endpoint.ResourceLabelKey])
+	_, needsMigration := bar.GetProviderSpecificProperty(configMapAttributeMigrate)
+	assert.True(t, needsMigration)
+
+	assert.True(t, r.orphanedLabels.Has(endpoint.EndpointKey{DNSName: "gone.test-zone.example.org", RecordType: endpoint.RecordTypeA}))
+
+	barNew := bar.DeepCopy()
+	barNew.DeleteProviderSpecificProperty(configMapAttributeMigrate)
+	require.NoError(t, r.ApplyChanges(context.Background(), &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{bar},
+		UpdateNew: []*endpoint.Endpoint{barNew},
+	}))
+	entries := readRegistryConfigMap(t, api, "external-dns-0")
+	assert.NotContains(t, entries, endpoint.EndpointKey{DNSName: "gone.test-zone.example.org", RecordType: endpoint.RecordTypeA})
+
+	records, err = r.Records(context.Background())
+	require.NoError(t, err)
+	byName = map[string]*endpoint.Endpoint{}
+	for _, record := range records {
+		byName[record.DNSName] = record
+	}
+	require.Contains(t, byName, "txt.bar.test-zone.example.org")
+	assert.Equal(t, "test-owner", byName["txt.bar.test-zone.example.org"].Labels[endpoint.OwnerLabelKey])
+	assert.Equal(t, "ingress/default/bar", byName["bar.test-zone.example.org"].Labels[endpoint.ResourceLabelKey])
+}
+
+func TestConfigMapRegistryApplyChanges(t *testing.T) {
+	p := newConfigMapTestProvider(t,
+		endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeCNAME, "foo.loadbalancer.com"),
+		endpoint.NewEndpoint("bar.test-zone.example.org", endpoint.RecordTypeCNAME, "my-domain.com"),
+	)
+	api := newConfigMapAPIStub(
+		newRegistryConfigMap("external-dns-0", map[endpoint.EndpointKey]configMapEntry{
+			{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}:   {Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/foo"}},
+			{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}:   {Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/bar"}},
+			{DNSName: "taken.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {Owner: "other-owner"},
+		}),
+	)
+
+	r, err := NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 1, "", "", "", []string{endpoint.RecordTypeCNAME}, nil, nil)
+	require.NoError(t, err)
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+
+	var foo, bar *endpoint.Endpoint
+	for _, record := range records {
+		switch record.DNSName {
+		case "foo.test-zone.example.org":
+			foo = record
+		case "bar.test-zone.example.org":
+			bar = record
+		}
+	}
+	fooNew := foo.DeepCopy()
+	fooNew.Targets = endpoint.Targets{"foo2.loadbalancer.com"}
+	fooNew.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo2"
+
+	newRecord := endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeCNAME, "new.loadbalancer.com")
+	newRecord.Labels[endpoint.ResourceLabelKey] = "ingress/default/new"
+
+	err = r.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newRecord,
+			endpoint.NewEndpoint("taken.test-zone.example.org", endpoint.RecordTypeCNAME, "taken.loadbalancer.com"),
+		},
+		UpdateOld: []*endpoint.Endpoint{foo},
+		UpdateNew: []*endpoint.Endpoint{fooNew},
+		Delete:    []*endpoint.Endpoint{bar},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[endpoint.EndpointKey]configMapEntry{
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}:   {Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/foo2"}},
+		{DNSName: "new.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}:   {Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/new"}},
+		{DNSName: "taken.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {Owner: "other-owner"},
+	}, readRegistryConfigMap(t, api, "external-dns-0"))
+
+	// the record registered by another owner is not created
+	records, err = p.Records(context.Background())
+	require.NoError(t, err)
+	names := []string{}
+	for _, record := range records {
+		names = append(names, record.DNSName)
+	}
+	assert.ElementsMatch(t, []string{"foo.test-zone.example.org", "new.test-zone.example.org"}, names)
+}
+
+func TestConfigMapRegistryApplyChangesConflict(t *testing.T) {
+	p := newConfigMapTestProvider(t)
+	api := newConfigMapAPIStub(newRegistryConfigMap("external-dns-0", nil))
+
+	// another owner registers the record between the read and the update of the ConfigMap
+	conflicts := 0
+	api.beforeUpdate = func() {
+		if conflicts == 0 {
+			conflicts++
+			api.store(newRegistryConfigMap("external-dns-0", map[endpoint.EndpointKey]configMapEntry{
+				{DNSName: "new.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {Owner: "other-owner"},
+			}))
+		}
+	}
+
+	r, err := NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 1, "", "", "", []string{endpoint.RecordTypeCNAME}, nil, nil)
+	require.NoError(t, err)
+	_, err = r.Records(context.Background())
+	require.NoError(t, err)
+
+	err = r.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeCNAME, "new.loadbalancer.com"),
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, conflicts)
+
+	records, err := p.Records(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestConfigMapRegistryCreatesConfigMaps(t *testing.T) {
+	p := newConfigMapTestProvider(t)
+	api := newConfigMapAPIStub()
+
+	r, err := NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 2, "", "", "", []string{endpoint.RecordTypeCNAME}, nil, nil)
+	require.NoError(t, err)
+	_, err = r.Records(context.Background())
+	require.NoError(t, err)
+
+	err = r.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			endpoint.NewEndpoint("a.test-zone.example.org", endpoint.RecordTypeCNAME, "a.loadbalancer.com"),
+			endpoint.NewEndpoint("b.test-zone.example.org", endpoint.RecordTypeCNAME, "b.loadbalancer.com"),
+			endpoint.NewEndpoint("c.test-zone.example.org", endpoint.RecordTypeCNAME, "c.loadbalancer.com"),
+		},
+	})
+	require.NoError(t, err)
+
+	list, err := api.List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	entries := 0
+	for _, cm := range list.Items {
+		assert.Contains(t, []string{"external-dns-0", "external-dns-1"}, cm.Name)
+		assert.Equal(t, "external-dns", cm.Labels[configMapRegistryLabel])
+		entries += len(cm.Data)
+	}
+	assert.Equal(t, 3, entries)
+
+	// the labels are read back by a new instance
+	r, err = NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 2, "", "", "", []string{endpoint.RecordTypeCNAME}, nil, nil)
+	require.NoError(t, err)
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	for _, record := range records {
+		assert.Equal(t, "test-owner", record.Labels[endpoint.OwnerLabelKey])
+	}
+}
+
+func TestConfigMapRegistryApplyChangesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	api := newConfigMapAPIStub()
+	r, err := NewConfigMapRegistry(newLockedProvider(), "test-owner", api, "default", "external-dns", 2, "", "", "", []string{}, []string{}, nil)
+	require.NoError(t, err)
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+
+	errs := applyChangesConcurrently(r, []string{"a", "b", "fail", "c"})
+	assert.Len(t, errs, 1)
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+}
+
+func TestConfigMapRegistryInvalidateCache(t *testing.T) {
+	p := newLockedProvider()
+	api := newConfigMapAPIStub()
+	replica, err := NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 1, "", "", "", []string{}, []string{}, nil)
+	require.NoError(t, err)
+	leader, err := NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 1, "", "", "", []string{}, []string{}, nil)
+	require.NoError(t, err)
+
+	assertCacheInvalidated(t, replica, leader)
+}
+
+func newConfigMapTestProvider(t *testing.T, records ...*endpoint.Endpoint) provider.Provider {
+	p := inmemory.NewInMemoryProvider()
+	require.NoError(t, p.CreateZone(testZone))
+	require.NoError(t, p.ApplyChanges(context.Background(), &plan.Changes{Create: records}))
+	return p
+}
+
+func newRegistryConfigMap(name string, entries map[endpoint.EndpointKey]configMapEntry) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{configMapRegistryLabel: "external-dns"},
+		},
+		Data: map[string]string{},
+	}
+	for key, entry := range entries {
+		cm.Data[toConfigMapKey(key)], _ = toConfigMapEntry(entry.Owner, entry.Labels)
+	}
+	return cm
+}
+
+func readRegistryConfigMap(t *testing.T, api *ConfigMapAPIStub, name string) map[endpoint.EndpointKey]configMapEntry {
+	cm, err := api.Get(context.Background(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	entries := map[endpoint.EndpointKey]configMapEntry{}
+	for dataKey, value := range cm.Data {
+		key, entry, err := fromConfigMapEntry(dataKey, value)
+		require.NoError(t, err)
+		if len(entry.Labels) == 0 {
+			entry.Labels = nil
+		}
+		entries[key] = entry
+	}
+	return entries
+}
+
+// ConfigMapAPIStub keeps ConfigMaps in memory, and refuses to update them with an outdated resourceVersion
+type ConfigMapAPIStub struct {
+	configMaps   map[string]*corev1.ConfigMap
+	version      int
+	beforeUpdate func()
+}
+
+func newConfigMapAPIStub(configMaps ...*corev1.ConfigMap) *ConfigMapAPIStub {
+	api := &ConfigMapAPIStub{configMaps: map[string]*corev1.ConfigMap{}}
+	for _, cm := range configMaps {
+		api.store(cm)
+	}
+	return api
+}
+
+func (api *ConfigMapAPIStub) store(cm *corev1.ConfigMap) *corev1.ConfigMap {
+	api.version++
+	stored := cm.DeepCopy()
+	stored.ResourceVersion = strconv.Itoa(api.version)
+	api.configMaps[cm.Name] = stored
+	return stored.DeepCopy()
+}
+
+func (api *ConfigMapAPIStub) List(_ context.Context, opts metav1.ListOptions) (*corev1.ConfigMapList, error) {
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	list := &corev1.ConfigMapList{}
+	for _, cm := range api.configMaps {
+		if selector.Matches(labels.Set(cm.Labels)) {
+			list.Items = append(list.Items, *cm.DeepCopy())
+		}
+	}
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+	return list, nil
+}
+
+func (api *ConfigMapAPIStub) Get(_ context.Context, name string, _ metav1.GetOptions) (*corev1.ConfigMap, error) {
+	cm, ok := api.configMaps[name]
+	if !ok {
+		return nil, apierrors.NewNotFound(corev1.Resource("configmaps"), name)
+	}
+	return cm.DeepCopy(), nil
+}
+
+func (api *ConfigMapAPIStub) Create(_ context.Context, cm *corev1.ConfigMap, _ metav1.CreateOptions) (*corev1.ConfigMap, error) {
+	if _, ok := api.configMaps[cm.Name]; ok {
+		return nil, apierrors.NewAlreadyExists(corev1.Resource("configmaps"), cm.Name)
+	}
+	return api.store(cm), nil
+}
+
+func (api *ConfigMapAPIStub) Update(_ context.Context, cm *corev1.ConfigMap, _ metav1.UpdateOptions) (*corev1.ConfigMap, error) {
+	if api.beforeUpdate != nil {
+		api.beforeUpdate()
+	}
+	stored, ok := api.configMaps[cm.Name]
+	if !ok {
+		return nil, apierrors.NewNotFound(corev1.Resource("configmaps"), cm.Name)
+	}
+	if stored.ResourceVersion != cm.ResourceVersion {
+		return nil, apierrors.NewConflict(corev1.Resource("configmaps"), cm.Name, errors.New("the object has been modified"))
+	}
+	return api.store(cm), nil
+}
@@ -20,7 +20,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -56,6 +58,9 @@ type DynamoDBRegistry struct {
 	excludeRecordTypes  []string
 	txtEncryptAESKey    []byte
 
+	// ApplyChanges may be called concurrently for distinct zones, the caches are only accessed with the mutex held.
+	cacheMux sync.Mutex
+
 	// cache the dynamodb records owned by us.
 	labels         map[endpoint.EndpointKey]endpoint.Labels
 	orphanedLabels sets.Set[endpoint.EndpointKey]
@@ -115,6 +120,9 @@ func (im *DynamoDBRegistry) OwnerID() string {
 
 // Records returns the current records from the registry.
 func (im *DynamoDBRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+
 	// If we have the zones cached AND we have refreshed the cache since the
 	// last given interval, then just use the cached results.
 	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
@@ -135,8 +143,7 @@ func (im *DynamoDBRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint,
 
 	orphanedLabels := sets.KeySet(im.labels)
 	endpoints := make([]*endpoint.Endpoint, 0, len(records))
-	labelMap := map[endpoint.EndpointKey]endpoint.Labels{}
-	txtRecordsMap := map[endpoint.EndpointKey]*endpoint.Endpoint{}
+	migration := newTXTMigration(im.mapper, im.wildcardReplacement, im.txtEncryptAESKey)
 	for _, record := range records {
 		key := record.Key()
 		if labels := im.labels[key]; labels != nil {
@@ -144,22 +151,8 @@ func (im *DynamoDBRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint,
 			orphanedLabels.Delete(key)
 		} else {
 			record.Labels = endpoint.NewLabels()
-
-			if record.RecordType == endpoint.RecordTypeTXT {
-				// We simply assume that TXT records for the TXT registry will always have only one target.
-				if labels, err := endpoint.NewLabelsFromString(record.Targets[0], im.txtEncryptAESKey); err == nil {
-					endpointName, recordType := im.mapper.toEndpointName(record.DNSName)
-					key := endpoint.EndpointKey{
-						DNSName:       endpointName,
-						SetIdentifier: record.SetIdentifier,
-					}
-					if recordType == endpoint.RecordTypeAAAA {
-						key.RecordType = recordType
-					}
-					labelMap[key] = labels
-					txtRecordsMap[key] = record
-					continue
-				}
+			if migration.collect(record) {
+				continue
 			}
 		}
 
@@ -169,43 +162,10 @@ func (im *DynamoDBRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint,
 	im.orphanedLabels = orphanedLabels
 
 	// Migrate label data from TXT registry.
-	if len(labelMap) > 0 {
-		for _, ep := range endpoints {
-			if _, ok := im.labels[ep.Key()]; ok {
-				continue
-			}
-
-			dnsNameSplit := strings.Split(ep.DNSName, ".")
-			// If specified, replace a leading asterisk in the generated txt record name with some other string
-			if im.wildcardReplacement != "" && dnsNameSplit[0] == "*" {
-				dnsNameSplit[0] = im.wildcardReplacement
-			}
-			dnsName := strings.Join(dnsNameSplit, ".")
-			key := endpoint.EndpointKey{
-				DNSName:       dnsName,
-				SetIdentifier: ep.SetIdentifier,
-			}
-			if ep.RecordType == endpoint.RecordTypeAAAA {
-				key.RecordType = ep.RecordType
-			}
-			if labels, ok := labelMap[key]; ok {
-				for k, v := range labels {
-					ep.Labels[k] = v
-				}
-				ep.SetProviderSpecificProperty(dynamodbAttributeMigrate, "true")
-				delete(txtRecordsMap, key)
-			}
-		}
-	}
+	migration.migrate(endpoints, im.labels, dynamodbAttributeMigrate)
 
 	// Remove any unused TXT ownership records owned by us
-	if len(txtRecordsMap) > 0 && !plan.IsManagedRecord(endpoint.RecordTypeTXT, im.managedRecordTypes, im.excludeRecordTypes) {
-		log.Infof("Old TXT ownership records will not be deleted because \"TXT\" is not in the set of managed record types.")
-	}
-	for _, record := range txtRecordsMap {
-		record.Labels[endpoint.OwnerLabelKey] = im.ownerID
-		endpoints = append(endpoints, record)
-	}
+	endpoints = append(endpoints, migration.obsoleteRecords(im.ownerID, im.managedRecordTypes, im.excludeRecordTypes)...)
 
 	// Update the cache.
 	if im.cacheInterval > 0 {
@@ -219,10 +179,20 @@ func (im *DynamoDBRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint,
 // ApplyChanges updates the DNS provider and DynamoDB table with the changes.
 func (im *DynamoDBRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
 	filteredChanges := &plan.Changes{
-		Create:    changes.Create,
-		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
-		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
-		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+		Create:       changes.Create,
+		UpdateNew:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:       endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+		TargetDeltas: changes.TargetDeltas,
+	}
+
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	// The labels are dropped when the changes of another zone failed.
+	if im.labels == nil {
+		if err := im.readLabels(ctx); err != nil {
+			return err
+		}
 	}
 
 	statements := make([]*dynamodb.BatchStatementRequest, 0, len(filteredChanges.Create)+len(filteredChanges.UpdateNew))
@@ -319,7 +289,10 @@ func (im *DynamoDBRegistry) ApplyChanges(ctx context.Context, changes *plan.Chan
 	if im.cacheInterval > 0 {
 		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
 	}
+	// The provider is called without the mutex, so that the changes of distinct zones are applied in parallel.
+	im.cacheMux.Unlock()
 	err = im.provider.ApplyChanges(ctx, filteredChanges)
+	im.cacheMux.Lock()
 	if err != nil {
 		im.recordsCache = nil
 		im.labels = nil
@@ -535,3 +508,55 @@ func (im *DynamoDBRegistry) removeFromCache(ep *endpoint.Endpoint) {
 		}
 	}
 }
+
+// InvalidateCache drops the cached labels, so that they are read again from the DynamoDB table with the next records.
+func (im *DynamoDBRegistry) InvalidateCache() {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	im.labels = nil
+	im.recordsCache = nil
+}
+
+// StoredLabels reads the labels of the records owned by the registry from the DynamoDB table.
+func (im *DynamoDBRegistry) StoredLabels(ctx context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error) {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	if err := im.readLabels(ctx); err != nil {
+		return nil, err
+	}
+	return maps.Clone(im.labels), nil
+}
+
+// StoreLabels writes the labels of records to the DynamoDB table, and returns the keys of the records registered
+// by another owner.
+func (im *DynamoDBRegistry) StoreLabels(ctx context.Context, labels map[endpoint.EndpointKey]endpoint.Labels) ([]endpoint.EndpointKey, error) {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	if err := im.readLabels(ctx); err != nil {
+		return nil, err
+	}
+	statements := make([]*dynamodb.BatchStatementRequest, 0, len(labels))
+	for key, l := range labels {
+		if oldLabels := im.labels[key]; oldLabels == nil {
+			statements = im.appendInsert(statements, key, l)
+		} else {
+			statements = im.appendUpdate(statements, key, oldLabels, l)
+		}
+	}
+	// The labels are read again with the records.
+	im.labels = nil
+	im.recordsCache = nil
+
+	var conflicts []endpoint.EndpointKey
+	err := im.executeStatements(ctx, statements, func(request *dynamodb.BatchStatementRequest, response *dynamodb.BatchStatementResponse) error {
+		if strings.HasPrefix(*request.Statement, "INSERT") {
+			if aws.StringValue(response.Error.Code) == "DuplicateItem" {
+				conflicts = append(conflicts, fromDynamoKey(request.Parameters[0]))
+				return nil
+			}
+			return fmt.Errorf("inserting dynamodb record %q: %s: %s", aws.StringValue(request.Parameters[0].S), aws.StringValue(response.Error.Code), aws.StringValue(response.Error.Message))
+		}
+		return fmt.Errorf("updating dynamodb record %q: %s: %s", aws.StringValue(request.Parameters[1].S), aws.StringValue(response.Error.Code), aws.StringValue(response.Error.Message))
+	})
+	return conflicts, err
+}
@@ -19,6 +19,7 @@ package registry
 import (
 	"context"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -214,6 +215,70 @@ func TestDynamoDBRegistryRecords(t *testing.T) {
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 }
 
+func TestDynamoDBRegistryStoreLabels(t *testing.T) {
+	ctx := context.Background()
+	api, p := newDynamoDBAPIStub(t, &DynamoDBStubConfig{
+		ExpectInsert: map[string]map[string]string{
+			"new.test-zone.example.org#CNAME#": {endpoint.ResourceLabelKey: "ingress/default/new-ingress"},
+		},
+		ExpectInsertError: map[string]string{
+			"taken.test-zone.example.org#A#": "DuplicateItem",
+		},
+		ExpectUpdate: map[string]map[string]string{
+			"bar.test-zone.example.org#CNAME#": {endpoint.ResourceLabelKey: "ingress/default/other-ingress"},
+		},
+	})
+
+	r, _ := NewDynamoDBRegistry(p, "test-owner", api, "test-table", "", "", "", []string{}, []string{}, nil, time.Hour)
+
+	conflicts, err := r.StoreLabels(ctx, map[endpoint.EndpointKey]endpoint.Labels{
+		{DNSName: "new.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {
+			endpoint.OwnerLabelKey:    "test-owner",
+			endpoint.ResourceLabelKey: "ingress/default/new-ingress",
+		},
+		{DNSName: "taken.test-zone.example.org", RecordType: endpoint.RecordTypeA}: {
+			endpoint.OwnerLabelKey:    "test-owner",
+			endpoint.ResourceLabelKey: "ingress/default/taken-ingress",
+		},
+		{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {
+			endpoint.OwnerLabelKey:    "test-owner",
+			endpoint.ResourceLabelKey: "ingress/default/other-ingress",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []endpoint.EndpointKey{{DNSName: "taken.test-zone.example.org", RecordType: endpoint.RecordTypeA}}, conflicts)
+	assert.Empty(t, api.stubConfig.ExpectInsert, "all expected inserts made")
+	assert.Empty(t, api.stubConfig.ExpectInsertError, "all expected insert errors made")
+	assert.Empty(t, api.stubConfig.ExpectUpdate, "all expected updates made")
+}
+
+func TestDynamoDBRegistryApplyChangesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	api := &concurrentDynamoDBStub{}
+	r, err := NewDynamoDBRegistry(newLockedProvider(), "test-owner", api, "test-table", "", "", "", []string{}, []string{}, nil, time.Hour)
+	require.NoError(t, err)
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+
+	errs := applyChangesConcurrently(r, []string{"a", "b", "fail", "c"})
+	assert.Len(t, errs, 1)
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+}
+
+func TestDynamoDBRegistryInvalidateCache(t *testing.T) {
+	p := newLockedProvider()
+	api := &concurrentDynamoDBStub{}
+	replica, err := NewDynamoDBRegistry(p, "test-owner", api, "test-table", "", "", "", []string{}, []string{}, nil, time.Hour)
+	require.NoError(t, err)
+	leader, err := NewDynamoDBRegistry(p, "test-owner", api, "test-table", "", "", "", []string{}, []string{}, nil, time.Hour)
+	require.NoError(t, err)
+
+	assertCacheInvalidated(t, replica, leader)
+}
+
 func TestDynamoDBRegistryApplyChanges(t *testing.T) {
 	for _, tc := range []struct {
 		name            string
@@ -1276,3 +1341,42 @@ func (r *DynamoDBStub) BatchExecuteStatementWithContext(context aws.Context, inp
 		Responses: responses,
 	}, nil
 }
+
+// concurrentDynamoDBStub accepts every statement, keeps the inserted items and can be called concurrently.
+type concurrentDynamoDBStub struct {
+	mux        sync.Mutex
+	statements int
+	items      []map[string]*dynamodb.AttributeValue
+}
+
+func (r *concurrentDynamoDBStub) DescribeTableWithContext(ctx aws.Context, input *dynamodb.DescribeTableInput, opts ...request.Option) (*dynamodb.DescribeTableOutput, error) {
+	return &dynamodb.DescribeTableOutput{
+		Table: &dynamodb.TableDescription{
+			AttributeDefinitions: []*dynamodb.AttributeDefinition{{AttributeName: aws.String("k"), AttributeType: aws.String("S")}},
+			KeySchema:            []*dynamodb.KeySchemaElement{{AttributeName: aws.String("k"), KeyType: aws.String("HASH")}},
+		},
+	}, nil
+}
+
+func (r *concurrentDynamoDBStub) ScanPagesWithContext(ctx aws.Context, input *dynamodb.ScanInput, fn func(*dynamodb.ScanOutput, bool) bool, opts ...request.Option) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	fn(&dynamodb.ScanOutput{Items: r.items}, true)
+	return nil
+}
+
+func (r *concurrentDynamoDBStub) BatchExecuteStatementWithContext(context aws.Context, input *dynamodb.BatchExecuteStatementInput, option ...request.Option) (*dynamodb.BatchExecuteStatementOutput, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.statements += len(input.Statements)
+	for _, statement := range input.Statements {
+		if strings.HasPrefix(aws.StringValue(statement.Statement), "INSERT") {
+			r.items = append(r.items, map[string]*dynamodb.AttributeValue{"k": statement.Parameters[0], "l": statement.Parameters[2]})
+		}
+	}
+	responses := make([]*dynamodb.BatchStatementResponse, len(input.Statements))
+	for i := range responses {
+		responses[i] = &dynamodb.BatchStatementResponse{}
+	}
+	return &dynamodb.BatchExecuteStatementOutput{Responses: responses}, nil
+}
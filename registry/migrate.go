@@ -0,0 +1,217 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// LabelStore is implemented by the registries which keep the labels of the records apart from the DNS provider,
+// so that the labels of another registry can be migrated to them.
+type LabelStore interface {
+	// StoredLabels reads the labels of the records owned by the registry from its store.
+	StoredLabels(ctx context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error)
+	// StoreLabels writes the labels of records owned by the registry to its store, without changing the records.
+	// It returns the keys of the records registered by another owner, whose labels are not written.
+	StoreLabels(ctx context.Context, labels map[endpoint.EndpointKey]endpoint.Labels) ([]endpoint.EndpointKey, error)
+}
+
+// OwnershipRecordDeleter is implemented by the registries which keep the labels of the records in DNS records,
+// which can be deleted once the labels are migrated.
+type OwnershipRecordDeleter interface {
+	// DeleteOwnershipRecords deletes the ownership records of the records and returns their number.
+	DeleteOwnershipRecords(ctx context.Context, endpoints []*endpoint.Endpoint) (int, error)
+}
+
+// MigrationAction is what a migration does with the labels of a record
+type MigrationAction string
+
+const (
+	MigrationCreate    MigrationAction = "create"
+	MigrationUpdate    MigrationAction = "update"
+	MigrationUnchanged MigrationAction = "unchanged"
+	// MigrationConflict is the action of the records registered by another owner in the target registry
+	MigrationConflict MigrationAction = "conflict"
+)
+
+// MigrationEntry is the migration of the labels of a record
+type MigrationEntry struct {
+	Key    endpoint.EndpointKey
+	Action MigrationAction
+	Labels endpoint.Labels
+}
+
+// MigrationReport lists the migration of the records owned by the owner of the source registry
+type MigrationReport struct {
+	Entries []MigrationEntry
+	// Unverified are the records whose labels were not read back from the target registry as they were written
+	Unverified []endpoint.EndpointKey
+	// DeletedOwnershipRecords is the number of ownership records deleted from the source registry
+	DeletedOwnershipRecords int
+}
+
+// Count returns the number of records migrated with the action
+func (r *MigrationReport) Count(action MigrationAction) int {
+	count := 0
+	for _, entry := range r.Entries {
+		if entry.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// MigrationOptions are the options of Migrate
+type MigrationOptions struct {
+	// DryRun only reports the migration
+	DryRun bool
+	// DeleteOwnershipRecords deletes the ownership records of the source registry once all labels are migrated
+	DeleteOwnershipRecords bool
+}
+
+// Migrate copies the labels of the records owned by the source registry to the target registry, and reads them back
+// to verify them. The ownership records of the source registry are only deleted if all the labels are verified.
+func Migrate(ctx context.Context, from Registry, to LabelStore, opts MigrationOptions) (*MigrationReport, error) {
+	records, err := from.Records(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading the records of the source registry: %w", err)
+	}
+	stored, err := to.StoredLabels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading the labels of the target registry: %w", err)
+	}
+
+	report := &MigrationReport{}
+	owned := []*endpoint.Endpoint{}
+	labels := map[endpoint.EndpointKey]endpoint.Labels{}
+	for _, record := range records {
+		if record.Labels[endpoint.OwnerLabelKey] != from.OwnerID() || isOwnershipRecord(record) {
+			continue
+		}
+		key := record.Key()
+		if _, ok := labels[key]; ok {
+			continue
+		}
+		owned = append(owned, record)
+		action := MigrationUnchanged
+		if current, ok := stored[key]; !ok {
+			action = MigrationCreate
+			labels[key] = record.Labels
+		} else if !labelsEqual(current, record.Labels) {
+			action = MigrationUpdate
+			labels[key] = record.Labels
+		}
+		report.Entries = append(report.Entries, MigrationEntry{Key: key, Action: action, Labels: record.Labels})
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i].Key, report.Entries[j].Key
+		if a.DNSName != b.DNSName {
+			return a.DNSName < b.DNSName
+		}
+		if a.RecordType != b.RecordType {
+			return a.RecordType < b.RecordType
+		}
+		return a.SetIdentifier < b.SetIdentifier
+	})
+
+	if opts.DryRun {
+		logMigrationReport(report, true)
+		return report, nil
+	}
+
+	conflicts, err := to.StoreLabels(ctx, labels)
+	if err != nil {
+		return report, fmt.Errorf("writing the labels to the target registry: %w", err)
+	}
+	conflicting := map[endpoint.EndpointKey]bool{}
+	for _, key := range conflicts {
+		conflicting[key] = true
+	}
+	for i := range report.Entries {
+		if conflicting[report.Entries[i].Key] {
+			report.Entries[i].Action = MigrationConflict
+		}
+	}
+
+	stored, err = to.StoredLabels(ctx)
+	if err != nil {
+		return report, fmt.Errorf("reading the labels of the target registry: %w", err)
+	}
+	for _, entry := range report.Entries {
+		if entry.Action != MigrationConflict && !labelsEqual(stored[entry.Key], entry.Labels) {
+			report.Unverified = append(report.Unverified, entry.Key)
+		}
+	}
+	logMigrationReport(report, false)
+
+	if n := report.Count(MigrationConflict); n > 0 {
+		return report, fmt.Errorf("%d records are registered by another owner in the target registry", n)
+	}
+	if len(report.Unverified) > 0 {
+		return report, fmt.Errorf("the labels of %d records could not be verified in the target registry", len(report.Unverified))
+	}
+
+	if opts.DeleteOwnershipRecords {
+		deleter, ok := from.(OwnershipRecordDeleter)
+		if !ok {
+			return report, errors.New("the source registry does not keep ownership records")
+		}
+		report.DeletedOwnershipRecords, err = deleter.DeleteOwnershipRecords(ctx, owned)
+		if err != nil {
+			return report, fmt.Errorf("deleting the ownership records of the source registry: %w", err)
+		}
+		log.Infof("Deleted %d ownership records", report.DeletedOwnershipRecords)
+	}
+	return report, nil
+}
+
+// isOwnershipRecord returns true for the TXT records of the TXT registry, which registries migrating from it return
+// to have them deleted
+func isOwnershipRecord(record *endpoint.Endpoint) bool {
+	if record.RecordType != endpoint.RecordTypeTXT || len(record.Targets) != 1 {
+		return false
+	}
+	_, err := endpoint.NewLabelsFromStringPlain(record.Targets[0])
+	return err == nil
+}
+
+func logMigrationReport(report *MigrationReport, dryRun bool) {
+	prefix := ""
+	if dryRun {
+		prefix = "[dry run] "
+	}
+	for _, entry := range report.Entries {
+		if entry.Action == MigrationUnchanged {
+			continue
+		}
+		log.WithFields(log.Fields{
+			"record":        entry.Key.DNSName,
+			"type":          entry.Key.RecordType,
+			"setIdentifier": entry.Key.SetIdentifier,
+		}).Infof("%s%s labels", prefix, entry.Action)
+	}
+	log.Infof("%sMigration of %d records: %d to create, %d to update, %d unchanged, %d conflicting, %d unverified", prefix,
+		len(report.Entries), report.Count(MigrationCreate), report.Count(MigrationUpdate), report.Count(MigrationUnchanged),
+		report.Count(MigrationConflict), len(report.Unverified))
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+func newMigrationTestRegistry(t *testing.T) (provider.Provider, *TXTRegistry) {
+	p := newConfigMapTestProvider(t)
+	txt, err := NewTXTRegistry(p, "txt.", "", "test-owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil, nil, false, false)
+	require.NoError(t, err)
+
+	foo := endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeCNAME, "foo.loadbalancer.com")
+	foo.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo"
+	bar := endpoint.NewEndpoint("bar.test-zone.example.org", endpoint.RecordTypeA, "1.2.3.4")
+	bar.Labels[endpoint.ResourceLabelKey] = "service/default/bar"
+	require.NoError(t, txt.ApplyChanges(context.Background(), &plan.Changes{Create: []*endpoint.Endpoint{foo, bar}}))
+
+	other, err := NewTXTRegistry(p, "txt.", "", "other-owner", 0, "", []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME}, nil, false, nil, nil, false, false)
+	require.NoError(t, err)
+	require.NoError(t, other.ApplyChanges(context.Background(), &plan.Changes{Create: []*endpoint.Endpoint{
+		endpoint.NewEndpoint("other.test-zone.example.org", endpoint.RecordTypeA, "5.6.7.8"),
+	}}))
+	return p, txt
+}
+
+func countTXTRecords(t *testing.T, p provider.Provider) int {
+	records, err := p.Records(context.Background())
+	require.NoError(t, err)
+	count := 0
+	for _, record := range records {
+		if record.RecordType == endpoint.RecordTypeTXT {
+			count++
+		}
+	}
+	return count
+}
+
+func TestMigrate(t *testing.T) {
+	p, txt := newMigrationTestRegistry(t)
+	api := newConfigMapAPIStub(newRegistryConfigMap("external-dns-0", map[endpoint.EndpointKey]configMapEntry{
+		{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeA}: {Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "service/default/old"}},
+	}))
+	to, err := NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 1, "", "", "", nil, nil, nil)
+	require.NoError(t, err)
+	txtRecords := countTXTRecords(t, p)
+
+	report, err := Migrate(context.Background(), txt, to, MigrationOptions{DryRun: true, DeleteOwnershipRecords: true})
+	require.NoError(t, err)
+	assert.Equal(t, []MigrationEntry{
+		{
+			Key:    endpoint.EndpointKey{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeA},
+			Action: MigrationUpdate,
+			Labels: endpoint.Labels{endpoint.OwnerLabelKey: "test-owner", endpoint.ResourceLabelKey: "service/default/bar"},
+		},
+		{
+			Key:    endpoint.EndpointKey{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME},
+			Action: MigrationCreate,
+			Labels: endpoint.Labels{endpoint.OwnerLabelKey: "test-owner", endpoint.ResourceLabelKey: "ingress/default/foo"},
+		},
+	}, report.Entries)
+	assert.Len(t, readRegistryConfigMap(t, api, "external-dns-0"), 1, "a dry run does not write the labels")
+	assert.Equal(t, txtRecords, countTXTRecords(t, p), "a dry run does not delete the TXT records")
+
+	report, err = Migrate(context.Background(), txt, to, MigrationOptions{DeleteOwnershipRecords: true})
+	require.NoError(t, err)
+	assert.Empty(t, report.Unverified)
+	assert.Equal(t, map[endpoint.EndpointKey]configMapEntry{
+		{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeA}:     {Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "service/default/bar"}},
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {Owner: "test-owner", Labels: map[string]string{endpoint.ResourceLabelKey: "ingress/default/foo"}},
+	}, readRegistryConfigMap(t, api, "external-dns-0"))
+
+	// the TXT records of the other owner are kept
+	assert.Equal(t, 4, report.DeletedOwnershipRecords)
+	assert.Equal(t, txtRecords-4, countTXTRecords(t, p))
+
+	// the migrated records are owned in the target registry
+	records, err := to.Records(context.Background())
+	require.NoError(t, err)
+	owners := map[string]string{}
+	for _, record := range records {
+		if record.RecordType != endpoint.RecordTypeTXT {
+			owners[record.DNSName] = record.Labels[endpoint.OwnerLabelKey]
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"foo.test-zone.example.org":   "test-owner",
+		"bar.test-zone.example.org":   "test-owner",
+		"other.test-zone.example.org": "",
+	}, owners)
+}
+
+func TestMigrateConflict(t *testing.T) {
+	p, txt := newMigrationTestRegistry(t)
+	api := newConfigMapAPIStub(newRegistryConfigMap("external-dns-0", map[endpoint.EndpointKey]configMapEntry{
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {Owner: "other-owner"},
+	}))
+	to, err := NewConfigMapRegistry(p, "test-owner", api, "default", "external-dns", 1, "", "", "", nil, nil, nil)
+	require.NoError(t, err)
+	txtRecords := countTXTRecords(t, p)
+
+	report, err := Migrate(context.Background(), txt, to, MigrationOptions{DeleteOwnershipRecords: true})
+	require.EqualError(t, err, "1 records are registered by another owner in the target registry")
+	assert.Equal(t, 1, report.Count(MigrationConflict))
+	assert.Equal(t, 1, report.Count(MigrationCreate))
+	assert.Equal(t, txtRecords, countTXTRecords(t, p), "the TXT records are not deleted unless all records are migrated")
+}
@@ -34,3 +34,16 @@ type Registry interface {
 	GetDomainFilter() endpoint.DomainFilter
 	OwnerID() string
 }
+
+// CacheInvalidator is implemented by registries which cache the labels of the records between synchronizations,
+// so that the cache can be dropped when the records may have been changed by another instance, e.g. when the
+// leader election lease is acquired again
+type CacheInvalidator interface {
+	InvalidateCache()
+}
+
+// OwnershipRecordNamer is implemented by registries which store the ownership of a record in DNS records
+// of their own, so that the planner can avoid names these records conflict with
+type OwnershipRecordNamer interface {
+	OwnershipRecordNames(ep *endpoint.Endpoint) []string
+}
@@ -0,0 +1,498 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+const sqlAttributeMigrate = "sql/needs-migration"
+
+// sqlDialects are the SQL dialects supported by SQLRegistry, with a function to return the placeholder of the
+// i-th parameter of a statement.
+var sqlDialects = map[string]func(i int) string{
+	"sqlite":   func(int) string { return "?" },
+	"postgres": func(i int) string { return "$" + strconv.Itoa(i) },
+}
+
+var sqlTableName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// sqlCreateTable is the statement creating the table of the SQL registry, formatted with the name of the table
+const sqlCreateTable = `CREATE TABLE IF NOT EXISTS %s (
+	dns_name VARCHAR(255) NOT NULL,
+	record_type VARCHAR(16) NOT NULL,
+	set_identifier VARCHAR(255) NOT NULL,
+	owner VARCHAR(255) NOT NULL,
+	labels TEXT NOT NULL,
+	PRIMARY KEY (dns_name, record_type, set_identifier)
+)`
+
+// SQLRegistry implements registry interface with ownership kept in a table of a SQL database, e.g. SQLite or
+// PostgreSQL. The table has a row per record, with its owner and labels, and is created if it does not exist.
+type SQLRegistry struct {
+	provider provider.Provider
+	ownerID  string // refers to the owner id of the current instance
+
+	db          *sql.DB
+	placeholder func(i int) string
+	table       string
+	tableReady  bool
+
+	// For migration from TXT registry
+	mapper              nameMapper
+	wildcardReplacement string
+	managedRecordTypes  []string
+	excludeRecordTypes  []string
+	txtEncryptAESKey    []byte
+
+	// ApplyChanges may be called concurrently for distinct zones, the caches are only accessed with the mutex held.
+	cacheMux sync.Mutex
+
+	// cache the labels of the records owned by us.
+	labels         map[endpoint.EndpointKey]endpoint.Labels
+	orphanedLabels sets.Set[endpoint.EndpointKey]
+}
+
+// NewSQLRegistry returns a new SQLRegistry object for a database of the dialect, "sqlite" or "postgres".
+func NewSQLRegistry(provider provider.Provider, ownerID string, db *sql.DB, dialect, table string, txtPrefix, txtSuffix, txtWildcardReplacement string, managedRecordTypes, excludeRecordTypes []string, txtEncryptAESKey []byte) (*SQLRegistry, error) {
+	if ownerID == "" {
+		return nil, errors.New("owner id cannot be empty")
+	}
+	placeholder, ok := sqlDialects[dialect]
+	if !ok {
+		return nil, fmt.Errorf("unsupported SQL dialect %q", dialect)
+	}
+	if !sqlTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+
+	if len(txtEncryptAESKey) == 0 {
+		txtEncryptAESKey = nil
+	} else if len(txtEncryptAESKey) != 32 {
+		return nil, errors.New("the AES Encryption key must have a length of 32 bytes")
+	}
+	if len(txtPrefix) > 0 && len(txtSuffix) > 0 {
+		return nil, errors.New("txt-prefix and txt-suffix are mutually exclusive")
+	}
+
+	return &SQLRegistry{
+		provider:            provider,
+		ownerID:             ownerID,
+		db:                  db,
+		placeholder:         placeholder,
+		table:               table,
+		mapper:              newaffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement),
+		wildcardReplacement: txtWildcardReplacement,
+		managedRecordTypes:  managedRecordTypes,
+		excludeRecordTypes:  excludeRecordTypes,
+		txtEncryptAESKey:    txtEncryptAESKey,
+	}, nil
+}
+
+func (im *SQLRegistry) GetDomainFilter() endpoint.DomainFilter {
+	return im.provider.GetDomainFilter()
+}
+
+func (im *SQLRegistry) OwnerID() string {
+	return im.ownerID
+}
+
+// Records returns the current records from the registry.
+func (im *SQLRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+
+	if im.labels == nil {
+		if err := im.readLabels(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	orphanedLabels := sets.KeySet(im.labels)
+	endpoints := make([]*endpoint.Endpoint, 0, len(records))
+	migration := newTXTMigration(im.mapper, im.wildcardReplacement, im.txtEncryptAESKey)
+	for _, record := range records {
+		key := record.Key()
+		if labels := im.labels[key]; labels != nil {
+			record.Labels = labels
+			orphanedLabels.Delete(key)
+		} else {
+			record.Labels = endpoint.NewLabels()
+			if migration.collect(record) {
+				continue
+			}
+		}
+
+		endpoints = append(endpoints, record)
+	}
+
+	im.orphanedLabels = orphanedLabels
+
+	// Migrate label data from TXT registry.
+	migration.migrate(endpoints, im.labels, sqlAttributeMigrate)
+
+	// Remove any unused TXT ownership records owned by us
+	endpoints = append(endpoints, migration.obsoleteRecords(im.ownerID, im.managedRecordTypes, im.excludeRecordTypes)...)
+
+	return endpoints, nil
+}
+
+// ApplyChanges updates the DNS provider and the table with the changes. The rows of the created and updated records
+// are written in a transaction before the changes are applied to the provider, the rows of the deleted records in a
+// transaction after.
+func (im *SQLRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	filteredChanges := &plan.Changes{
+		Create:       changes.Create,
+		UpdateNew:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
+		UpdateOld:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
+		Delete:       endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+		TargetDeltas: changes.TargetDeltas,
+	}
+
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	// The labels are dropped when the changes of another zone failed.
+	if im.labels == nil {
+		if err := im.readLabels(ctx); err != nil {
+			return err
+		}
+	}
+
+	var skipped []endpoint.EndpointKey
+	err := im.inTransaction(ctx, func(tx *sql.Tx) error {
+		skipped = nil
+		for _, r := range filteredChanges.Create {
+			if r.Labels == nil {
+				r.Labels = make(map[string]string)
+			}
+			r.Labels[endpoint.OwnerLabelKey] = im.ownerID
+
+			key := r.Key()
+			if oldLabels := im.labels[key]; oldLabels != nil {
+				im.orphanedLabels.Delete(key)
+				if !labelsEqual(oldLabels, r.Labels) {
+					if err := im.update(ctx, tx, key, r.Labels); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			inserted, err := im.insert(ctx, tx, key, r.Labels)
+			if err != nil {
+				return err
+			}
+			if !inserted {
+				skipped = append(skipped, key)
+			}
+		}
+
+		oldLabels := make(map[endpoint.EndpointKey]endpoint.Labels, len(filteredChanges.UpdateOld))
+		needMigration := map[endpoint.EndpointKey]bool{}
+		for _, r := range filteredChanges.UpdateOld {
+			oldLabels[r.Key()] = r.Labels
+
+			if _, ok := r.GetProviderSpecificProperty(sqlAttributeMigrate); ok {
+				needMigration[r.Key()] = true
+			}
+		}
+
+		for _, r := range filteredChanges.UpdateNew {
+			key := r.Key()
+			if needMigration[key] {
+				inserted, err := im.insert(ctx, tx, key, r.Labels)
+				if err != nil {
+					return err
+				}
+				if !inserted {
+					return fmt.Errorf("inserting row of %q: the record is registered by another owner", key.DNSName)
+				}
+			} else if !labelsEqual(oldLabels[key], r.Labels) {
+				if err := im.update(ctx, tx, key, r.Labels); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		im.labels = nil
+		return err
+	}
+
+	for _, r := range filteredChanges.Create {
+		im.labels[r.Key()] = r.Labels
+	}
+	for _, r := range filteredChanges.UpdateNew {
+		im.labels[r.Key()] = r.Labels
+	}
+	for _, key := range skipped {
+		for i, ep := range filteredChanges.Create {
+			if ep.Key() == key {
+				// We lost a race with a different owner or another owner has an orphaned ownership record.
+				log.Infof("Skipping endpoint %v because owner does not match", ep)
+				filteredChanges.Create = append(filteredChanges.Create[:i], filteredChanges.Create[i+1:]...)
+				delete(im.labels, key)
+				break
+			}
+		}
+	}
+
+	// The provider is called without the mutex, so that the changes of distinct zones are applied in parallel.
+	im.cacheMux.Unlock()
+	err = im.provider.ApplyChanges(ctx, filteredChanges)
+	im.cacheMux.Lock()
+	if err != nil {
+		im.labels = nil
+		return err
+	}
+
+	err = im.inTransaction(ctx, func(tx *sql.Tx) error {
+		for _, r := range filteredChanges.Delete {
+			if err := im.delete(ctx, tx, r.Key()); err != nil {
+				return err
+			}
+		}
+		for key := range im.orphanedLabels {
+			if err := im.delete(ctx, tx, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		im.labels = nil
+		return err
+	}
+	for _, r := range filteredChanges.Delete {
+		delete(im.labels, r.Key())
+	}
+	for key := range im.orphanedLabels {
+		delete(im.labels, key)
+	}
+	im.orphanedLabels = nil
+	return nil
+}
+
+// AdjustEndpoints modifies the endpoints as needed by the specific provider.
+func (im *SQLRegistry) AdjustEndpoints(endpoints []*endpoint.Endpoint) ([]*endpoint.Endpoint, error) {
+	return im.provider.AdjustEndpoints(endpoints)
+}
+
+func (im *SQLRegistry) readLabels(ctx context.Context) error {
+	if !im.tableReady {
+		_, err := im.db.ExecContext(ctx, fmt.Sprintf(sqlCreateTable, im.table))
+		if err != nil {
+			return fmt.Errorf("creating table %q: %w", im.table, err)
+		}
+		im.tableReady = true
+	}
+
+	rows, err := im.db.QueryContext(ctx, im.statement("SELECT dns_name, record_type, set_identifier, labels FROM %s WHERE owner = ?"), im.ownerID)
+	if err != nil {
+		return fmt.Errorf("querying table %q: %w", im.table, err)
+	}
+	defer rows.Close()
+
+	labels := map[endpoint.EndpointKey]endpoint.Labels{}
+	for rows.Next() {
+		var key endpoint.EndpointKey
+		var value string
+		if err := rows.Scan(&key.DNSName, &key.RecordType, &key.SetIdentifier, &value); err != nil {
+			return fmt.Errorf("querying table %q: %w", im.table, err)
+		}
+		stored := map[string]string{}
+		if err := json.Unmarshal([]byte(value), &stored); err != nil {
+			log.Warnf("Ignoring invalid labels of %q in table %q: %v", key.DNSName, im.table, err)
+			continue
+		}
+		labels[key] = endpoint.NewLabels()
+		for k, v := range stored {
+			labels[key][k] = v
+		}
+		labels[key][endpoint.OwnerLabelKey] = im.ownerID
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("querying table %q: %w", im.table, err)
+	}
+
+	im.labels = labels
+	return nil
+}
+
+func (im *SQLRegistry) inTransaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := im.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Errorf("Failed to roll back the changes of table %q: %v", im.table, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// insert inserts the row of a record, and returns false if the record is registered already.
+func (im *SQLRegistry) insert(ctx context.Context, tx *sql.Tx, key endpoint.EndpointKey, labels endpoint.Labels) (bool, error) {
+	value, err := toSQLLabels(labels)
+	if err != nil {
+		return false, err
+	}
+	result, err := tx.ExecContext(ctx, im.statement("INSERT INTO %s (dns_name, record_type, set_identifier, owner, labels) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING"),
+		key.DNSName, key.RecordType, key.SetIdentifier, im.ownerID, value)
+	if err != nil {
+		return false, fmt.Errorf("inserting row of %q: %w", key.DNSName, err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("inserting row of %q: %w", key.DNSName, err)
+	}
+	if inserted > 0 {
+		log.Infof("INSERT row of %q into table %q", key.DNSName, im.table)
+	}
+	return inserted > 0, nil
+}
+
+func (im *SQLRegistry) update(ctx context.Context, tx *sql.Tx, key endpoint.EndpointKey, labels endpoint.Labels) error {
+	value, err := toSQLLabels(labels)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, im.statement("UPDATE %s SET labels = ? WHERE dns_name = ? AND record_type = ? AND set_identifier = ? AND owner = ?"),
+		value, key.DNSName, key.RecordType, key.SetIdentifier, im.ownerID)
+	if err != nil {
+		return fmt.Errorf("updating row of %q: %w", key.DNSName, err)
+	}
+	log.Infof("UPDATE row of %q in table %q", key.DNSName, im.table)
+	return nil
+}
+
+func (im *SQLRegistry) delete(ctx context.Context, tx *sql.Tx, key endpoint.EndpointKey) error {
+	_, err := tx.ExecContext(ctx, im.statement("DELETE FROM %s WHERE dns_name = ? AND record_type = ? AND set_identifier = ? AND owner = ?"),
+		key.DNSName, key.RecordType, key.SetIdentifier, im.ownerID)
+	if err != nil {
+		return fmt.Errorf("deleting row of %q: %w", key.DNSName, err)
+	}
+	log.Infof("DELETE row of %q from table %q", key.DNSName, im.table)
+	return nil
+}
+
+// statement returns the statement for the table, with the "?" placeholders of its parameters replaced by the
+// placeholders of the dialect
+func (im *SQLRegistry) statement(format string) string {
+	query := fmt.Sprintf(format, im.table)
+	var b strings.Builder
+	i := 0
+	for _, c := range query {
+		if c == '?' {
+			i++
+			b.WriteString(im.placeholder(i))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func toSQLLabels(labels endpoint.Labels) (string, error) {
+	stored := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if k == endpoint.OwnerLabelKey {
+			continue
+		}
+		stored[k] = v
+	}
+	value, err := json.Marshal(stored)
+	return string(value), err
+}
+
+// InvalidateCache drops the cached labels, so that they are read again from the table with the next records.
+func (im *SQLRegistry) InvalidateCache() {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	im.labels = nil
+}
+
+// StoredLabels reads the labels of the records owned by the registry from the table.
+func (im *SQLRegistry) StoredLabels(ctx context.Context) (map[endpoint.EndpointKey]endpoint.Labels, error) {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	if err := im.readLabels(ctx); err != nil {
+		return nil, err
+	}
+	return maps.Clone(im.labels), nil
+}
+
+// StoreLabels writes the labels of records to the table in a transaction, and returns the keys of the records
+// registered by another owner.
+func (im *SQLRegistry) StoreLabels(ctx context.Context, labels map[endpoint.EndpointKey]endpoint.Labels) ([]endpoint.EndpointKey, error) {
+	im.cacheMux.Lock()
+	defer im.cacheMux.Unlock()
+	if err := im.readLabels(ctx); err != nil {
+		return nil, err
+	}
+	stored := im.labels
+	// The labels are read again with the records.
+	im.labels = nil
+
+	var conflicts []endpoint.EndpointKey
+	err := im.inTransaction(ctx, func(tx *sql.Tx) error {
+		conflicts = nil
+		for key, l := range labels {
+			if _, ok := stored[key]; ok {
+				if err := im.update(ctx, tx, key, l); err != nil {
+					return err
+				}
+				continue
+			}
+			inserted, err := im.insert(ctx, tx, key, l)
+			if err != nil {
+				return err
+			}
+			if !inserted {
+				conflicts = append(conflicts, key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestSQLRegistryNew(t *testing.T) {
+	p := newConfigMapTestProvider(t)
+	db := newSQLiteDB(t)
+
+	_, err := NewSQLRegistry(p, "test-owner", db, "sqlite", "external_dns", "", "", "", []string{}, []string{}, nil)
+	require.NoError(t, err)
+
+	_, err = NewSQLRegistry(p, "test-owner", db, "postgres", "external_dns", "", "", "", []string{}, []string{}, nil)
+	require.NoError(t, err)
+
+	_, err = NewSQLRegistry(p, "", db, "sqlite", "external_dns", "", "", "", []string{}, []string{}, nil)
+	require.EqualError(t, err, "owner id cannot be empty")
+
+	_, err = NewSQLRegistry(p, "test-owner", db, "mysql", "external_dns", "", "", "", []string{}, []string{}, nil)
+	require.EqualError(t, err, "unsupported SQL dialect \"mysql\"")
+
+	_, err = NewSQLRegistry(p, "test-owner", db, "sqlite", "external-dns; DROP TABLE x", "", "", "", []string{}, []string{}, nil)
+	require.EqualError(t, err, "invalid table name \"external-dns; DROP TABLE x\"")
+
+	_, err = NewSQLRegistry(p, "test-owner", db, "sqlite", "external_dns", "", "", "", []string{}, []string{}, []byte("too-short"))
+	require.EqualError(t, err, "the AES Encryption key must have a length of 32 bytes")
+
+	_, err = NewSQLRegistry(p, "test-owner", db, "sqlite", "external_dns", "testPrefix", "testSuffix", "", []string{}, []string{}, nil)
+	require.EqualError(t, err, "txt-prefix and txt-suffix are mutually exclusive")
+}
+
+func TestSQLRegistryStatement(t *testing.T) {
+	p := newConfigMapTestProvider(t)
+	db := newSQLiteDB(t)
+
+	r, err := NewSQLRegistry(p, "test-owner", db, "sqlite", "external_dns", "", "", "", nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE FROM external_dns WHERE a = ? AND b = ?", r.statement("DELETE FROM %s WHERE a = ? AND b = ?"))
+
+	r, err = NewSQLRegistry(p, "test-owner", db, "postgres", "external_dns", "", "", "", nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "DELETE FROM external_dns WHERE a = $1 AND b = $2", r.statement("DELETE FROM %s WHERE a = ? AND b = ?"))
+}
+
+func TestSQLRegistryRecords(t *testing.T) {
+	p := newConfigMapTestProvider(t,
+		endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeCNAME, "foo.loadbalancer.com"),
+		endpoint.NewEndpoint("bar.test-zone.example.org", endpoint.RecordTypeCNAME, "my-domain.com"),
+		endpoint.NewEndpoint("txt.bar.test-zone.example.org", endpoint.RecordTypeTXT, "\"heritage=external-dns,external-dns/owner=test-owner,external-dns/resource=ingress/default/bar\""),
+		endpoint.NewEndpoint("baz.test-zone.example.org", endpoint.RecordTypeA, "1.1.1.1"),
+	)
+	db := newSQLiteDB(t)
+	insertSQLRows(t, db, map[endpoint.EndpointKey]sqlRow{
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {owner: "test-owner", labels: `{"resource":"ingress/default/foo"}`},
+		{DNSName: "baz.test-zone.example.org", RecordType: endpoint.RecordTypeA}:     {owner: "other-owner", labels: `{}`},
+		{DNSName: "gone.test-zone.example.org", RecordType: endpoint.RecordTypeA}:    {owner: "test-owner", labels: `{}`},
+	})
+
+	r, err := NewSQLRegistry(p, "test-owner", db, "sqlite", "external_dns", "txt.", "", "", []string{endpoint.RecordTypeCNAME, endpoint.RecordTypeTXT}, nil, nil)
+	require.NoError(t, err)
+
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+	byName := map[string]*endpoint.Endpoint{}
+	for _, record := range records {
+		byName[record.DNSName] = record
+	}
+	require.Len(t, byName, 3)
+	assert.Equal(t, endpoint.Labels{
+		endpoint.OwnerLabelKey:    "test-owner",
+		endpoint.ResourceLabelKey: "ingress/default/foo",
+	}, byName["foo.test-zone.example.org"].Labels)
+	assert.Empty(t, byName["baz.test-zone.example.org"].Labels[endpoint.OwnerLabelKey])
+
+	// the labels of the TXT record are imported, the TXT record is deleted once they are stored
+	bar := byName["bar.test-zone.example.org"]
+	assert.Equal(t, "ingress/default/bar", bar.Labels[endpoint.ResourceLabelKey])
+	_, needsMigration := bar.GetProviderSpecificProperty(sqlAttributeMigrate)
+	require.True(t, needsMigration)
+
+	barNew := bar.DeepCopy()
+	barNew.DeleteProviderSpecificProperty(sqlAttributeMigrate)
+	require.NoError(t, r.ApplyChanges(context.Background(), &plan.Changes{
+		UpdateOld: []*endpoint.Endpoint{bar},
+		UpdateNew: []*endpoint.Endpoint{barNew},
+	}))
+	assert.Equal(t, map[endpoint.EndpointKey]sqlRow{
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {owner: "test-owner", labels: `{"resource":"ingress/default/foo"}`},
+		{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {owner: "test-owner", labels: `{"resource":"ingress/default/bar"}`},
+		{DNSName: "baz.test-zone.example.org", RecordType: endpoint.RecordTypeA}:     {owner: "other-owner", labels: `{}`},
+	}, readSQLRows(t, db))
+
+	records, err = r.Records(context.Background())
+	require.NoError(t, err)
+	byName = map[string]*endpoint.Endpoint{}
+	for _, record := range records {
+		byName[record.DNSName] = record
+	}
+	require.Contains(t, byName, "txt.bar.test-zone.example.org")
+	assert.Equal(t, "test-owner", byName["txt.bar.test-zone.example.org"].Labels[endpoint.OwnerLabelKey])
+}
+
+func TestSQLRegistryApplyChanges(t *testing.T) {
+	p := newConfigMapTestProvider(t,
+		endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeCNAME, "foo.loadbalancer.com"),
+		endpoint.NewEndpoint("bar.test-zone.example.org", endpoint.RecordTypeCNAME, "my-domain.com"),
+	)
+	db := newSQLiteDB(t)
+	insertSQLRows(t, db, map[endpoint.EndpointKey]sqlRow{
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}:   {owner: "test-owner", labels: `{"resource":"ingress/default/foo"}`},
+		{DNSName: "bar.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}:   {owner: "test-owner", labels: `{"resource":"ingress/default/bar"}`},
+		{DNSName: "taken.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {owner: "other-owner", labels: `{}`},
+	})
+
+	r, err := NewSQLRegistry(p, "test-owner", db, "sqlite", "external_dns", "", "", "", []string{endpoint.RecordTypeCNAME}, nil, nil)
+	require.NoError(t, err)
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+
+	var foo, bar *endpoint.Endpoint
+	for _, record := range records {
+		switch record.DNSName {
+		case "foo.test-zone.example.org":
+			foo = record
+		case "bar.test-zone.example.org":
+			bar = record
+		}
+	}
+	fooNew := foo.DeepCopy()
+	fooNew.Targets = endpoint.Targets{"foo2.loadbalancer.com"}
+	fooNew.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo2"
+	newRecord := endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeCNAME, "new.loadbalancer.com")
+	newRecord.Labels[endpoint.ResourceLabelKey] = "ingress/default/new"
+
+	err = r.ApplyChanges(context.Background(), &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newRecord,
+			endpoint.NewEndpoint("taken.test-zone.example.org", endpoint.RecordTypeCNAME, "taken.loadbalancer.com"),
+		},
+		UpdateOld: []*endpoint.Endpoint{foo},
+		UpdateNew: []*endpoint.Endpoint{fooNew},
+		Delete:    []*endpoint.Endpoint{bar},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[endpoint.EndpointKey]sqlRow{
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}:   {owner: "test-owner", labels: `{"resource":"ingress/default/foo2"}`},
+		{DNSName: "new.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}:   {owner: "test-owner", labels: `{"resource":"ingress/default/new"}`},
+		{DNSName: "taken.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {owner: "other-owner", labels: `{}`},
+	}, readSQLRows(t, db))
+
+	// the record registered by another owner is not created
+	records, err = p.Records(context.Background())
+	require.NoError(t, err)
+	names := []string{}
+	for _, record := range records {
+		names = append(names, record.DNSName)
+	}
+	assert.ElementsMatch(t, []string{"foo.test-zone.example.org", "new.test-zone.example.org"}, names)
+}
+
+func TestSQLRegistryApplyChangesRollback(t *testing.T) {
+	p := newConfigMapTestProvider(t,
+		endpoint.NewEndpoint("foo.test-zone.example.org", endpoint.RecordTypeCNAME, "foo.loadbalancer.com"),
+	)
+	db := newSQLiteDB(t)
+	insertSQLRows(t, db, map[endpoint.EndpointKey]sqlRow{
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {owner: "test-owner", labels: `{}`},
+	})
+
+	r, err := NewSQLRegistry(p, "test-owner", db, "sqlite", "external_dns", "", "", "", []string{endpoint.RecordTypeCNAME}, nil, nil)
+	require.NoError(t, err)
+	records, err := r.Records(context.Background())
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	fooNew := records[0].DeepCopy()
+	fooNew.Labels[endpoint.ResourceLabelKey] = "ingress/default/foo"
+	_, err = db.Exec("CREATE TRIGGER fail_update BEFORE UPDATE ON external_dns BEGIN SELECT RAISE(FAIL, 'failed'); END")
+	require.NoError(t, err)
+	err = r.ApplyChanges(context.Background(), &plan.Changes{
+		Create:    []*endpoint.Endpoint{endpoint.NewEndpoint("new.test-zone.example.org", endpoint.RecordTypeCNAME, "new.loadbalancer.com")},
+		UpdateOld: []*endpoint.Endpoint{records[0]},
+		UpdateNew: []*endpoint.Endpoint{fooNew},
+	})
+	require.Error(t, err)
+
+	// neither the rows nor the records are changed
+	assert.Equal(t, map[endpoint.EndpointKey]sqlRow{
+		{DNSName: "foo.test-zone.example.org", RecordType: endpoint.RecordTypeCNAME}: {owner: "test-owner", labels: `{}`},
+	}, readSQLRows(t, db))
+	records, err = p.Records(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Nil(t, r.labels)
+}
+
+func TestSQLRegistryApplyChangesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	r, err := NewSQLRegistry(newLockedProvider(), "test-owner", newSQLiteDB(t), "sqlite", "external_dns", "", "", "", []string{}, []string{}, nil)
+	require.NoError(t, err)
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+
+	errs := applyChangesConcurrently(r, []string{"a", "b", "fail", "c"})
+	assert.Len(t, errs, 1)
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 3)
+}
+
+func TestSQLRegistryInvalidateCache(t *testing.T) {
+	p := newLockedProvider()
+	db := newSQLiteDB(t)
+	replica, err := NewSQLRegistry(p, "test-owner", db, "sqlite", "external_dns", "", "", "", []string{}, []string{}, nil)
+	require.NoError(t, err)
+	leader, err := NewSQLRegistry(p, "test-owner", db, "sqlite", "external_dns", "", "", "", []string{}, []string{}, nil)
+	require.NoError(t, err)
+
+	assertCacheInvalidated(t, replica, leader)
+}
+
+// sqlRow is a row of the table of the SQL registry
+type sqlRow struct {
+	owner  string
+	labels string
+}
+
+// newSQLiteDB returns a new SQLite database in a temporary directory.
+func newSQLiteDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "registry.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// insertSQLRows creates the external_dns table with the rows.
+func insertSQLRows(t *testing.T, db *sql.DB, rows map[endpoint.EndpointKey]sqlRow) {
+	_, err := db.Exec(fmt.Sprintf(sqlCreateTable, "external_dns"))
+	require.NoError(t, err)
+	for key, row := range rows {
+		_, err := db.Exec("INSERT INTO external_dns (dns_name, record_type, set_identifier, owner, labels) VALUES (?, ?, ?, ?, ?)",
+			key.DNSName, key.RecordType, key.SetIdentifier, row.owner, row.labels)
+		require.NoError(t, err)
+	}
+}
+
+// readSQLRows returns the rows of the external_dns table.
+func readSQLRows(t *testing.T, db *sql.DB) map[endpoint.EndpointKey]sqlRow {
+	result, err := db.Query("SELECT dns_name, record_type, set_identifier, owner, labels FROM external_dns")
+	require.NoError(t, err)
+	defer result.Close()
+	rows := map[endpoint.EndpointKey]sqlRow{}
+	for result.Next() {
+		var key endpoint.EndpointKey
+		var row sqlRow
+		require.NoError(t, result.Scan(&key.DNSName, &key.RecordType, &key.SetIdentifier, &row.owner, &row.labels))
+		rows[key] = row
+	}
+	require.NoError(t, result.Err())
+	return rows
+}
@@ -20,8 +20,10 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	log "github.com/sirupsen/logrus"
 
 	"sigs.k8s.io/external-dns/endpoint"
@@ -32,6 +34,53 @@ import (
 const (
 	recordTemplate              = "%{record_type}"
 	providerSpecificForceUpdate = "txt/force-update"
+	// idnaPrefix starts the labels of internationalized names in their ASCII form
+	idnaPrefix = "xn--"
+)
+
+var txtRetiredKeyRecords = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "external_dns",
+		Subsystem: "registry",
+		Name:      "txt_retired_key_records",
+		Help:      "Number of TXT records encrypted with a retired AES key, or without key ID.",
+	},
+)
+
+var txtVerificationKeyRecordsTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "registry",
+		Name:      "txt_verification_key_records_total",
+		Help:      "Number of TXT records read which are signed with a verification key instead of the signing key.",
+	},
+)
+
+var txtUnsignedRecordsTotal = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "external_dns",
+		Subsystem: "registry",
+		Name:      "txt_unsigned_records_total",
+		Help:      "Number of unsigned TXT records read whose ownership is accepted until they are signed.",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(txtRetiredKeyRecords)
+	prometheus.MustRegister(txtVerificationKeyRecordsTotal)
+	prometheus.MustRegister(txtUnsignedRecordsTotal)
+}
+
+// signatureState tells how the labels of a TXT record are signed
+type signatureState int
+
+const (
+	// signedWithSigningKey labels are signed with the signing key, or signatures are disabled
+	signedWithSigningKey signatureState = iota
+	// signedWithVerificationKey labels are signed with a verification key, they are signed again with the signing key
+	signedWithVerificationKey
+	// acceptedUnsigned labels are not signed but owned by this instance, they are signed with the signing key
+	acceptedUnsigned
 )
 
 // TXTRegistry implements registry interface with ownership implemented via associated TXT records
@@ -44,6 +93,8 @@ type TXTRegistry struct {
 	recordsCache            []*endpoint.Endpoint
 	recordsCacheRefreshTime time.Time
 	cacheInterval           time.Duration
+	// ApplyChanges may be called concurrently for distinct zones, every access to the cache holds the mutex
+	recordsCacheMux sync.Mutex
 
 	// optional string to use to replace the asterisk in wildcard entries - without using this,
 	// registry TXT records corresponding to wildcard records will be invalid (and rejected by most providers), due to
@@ -53,24 +104,46 @@ type TXTRegistry struct {
 	managedRecordTypes []string
 	excludeRecordTypes []string
 
-	// encrypt text records
+	// encrypt text records with the active key of the keyring, decrypt them with any key
 	txtEncryptEnabled bool
-	txtEncryptAESKey  []byte
+	txtEncryptKeyring *endpoint.AESKeyring
+
+	// sign text records with the first key, verify them with any key
+	txtSigningKeys [][]byte
+	// accept the unsigned text records owned by this instance, so that they are signed instead of left unmanaged
+	txtSigningAcceptUnsigned bool
+
+	// apply the changes of the records shared with other owners, see plan.MultiOwner
+	multiOwner bool
 }
 
 // NewTXTRegistry returns new TXTRegistry object
-func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID string, cacheInterval time.Duration, txtWildcardReplacement string, managedRecordTypes, excludeRecordTypes []string, txtEncryptEnabled bool, txtEncryptAESKey []byte) (*TXTRegistry, error) {
+func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID string, cacheInterval time.Duration, txtWildcardReplacement string, managedRecordTypes, excludeRecordTypes []string, txtEncryptEnabled bool, txtEncryptAESKeys [][]byte, txtSigningKeys [][]byte, txtSigningAcceptUnsigned bool, multiOwner bool) (*TXTRegistry, error) {
 	if ownerID == "" {
 		return nil, errors.New("owner id cannot be empty")
 	}
-	if len(txtEncryptAESKey) == 0 {
-		txtEncryptAESKey = nil
-	} else if len(txtEncryptAESKey) != 32 {
-		return nil, errors.New("the AES Encryption key must have a length of 32 bytes")
+	var txtEncryptKeyring *endpoint.AESKeyring
+	if len(txtEncryptAESKeys) > 0 {
+		var err error
+		txtEncryptKeyring, err = endpoint.NewAESKeyring(txtEncryptAESKeys[0], txtEncryptAESKeys[1:]...)
+		if err != nil {
+			return nil, err
+		}
 	}
-	if txtEncryptEnabled && txtEncryptAESKey == nil {
+	if txtEncryptEnabled && txtEncryptKeyring == nil {
 		return nil, errors.New("the AES Encryption key must be set when TXT record encryption is enabled")
 	}
+	for _, key := range txtSigningKeys {
+		if len(key) < 32 {
+			return nil, errors.New("the TXT signing keys must have a length of at least 32 bytes")
+		}
+	}
+	if len(txtSigningKeys) == 0 {
+		txtSigningKeys = nil
+	}
+	if txtSigningAcceptUnsigned && txtSigningKeys == nil {
+		return nil, errors.New("the TXT signing key must be set to accept unsigned TXT records")
+	}
 
 	if len(txtPrefix) > 0 && len(txtSuffix) > 0 {
 		return nil, errors.New("txt-prefix and txt-suffix are mutual exclusive")
@@ -79,15 +152,18 @@ func NewTXTRegistry(provider provider.Provider, txtPrefix, txtSuffix, ownerID st
 	mapper := newaffixNameMapper(txtPrefix, txtSuffix, txtWildcardReplacement)
 
 	return &TXTRegistry{
-		provider:            provider,
-		ownerID:             ownerID,
-		mapper:              mapper,
-		cacheInterval:       cacheInterval,
-		wildcardReplacement: txtWildcardReplacement,
-		managedRecordTypes:  managedRecordTypes,
-		excludeRecordTypes:  excludeRecordTypes,
-		txtEncryptEnabled:   txtEncryptEnabled,
-		txtEncryptAESKey:    txtEncryptAESKey,
+		provider:                 provider,
+		ownerID:                  ownerID,
+		mapper:                   mapper,
+		cacheInterval:            cacheInterval,
+		wildcardReplacement:      txtWildcardReplacement,
+		managedRecordTypes:       managedRecordTypes,
+		excludeRecordTypes:       excludeRecordTypes,
+		txtEncryptEnabled:        txtEncryptEnabled,
+		txtEncryptKeyring:        txtEncryptKeyring,
+		txtSigningKeys:           txtSigningKeys,
+		txtSigningAcceptUnsigned: txtSigningAcceptUnsigned,
+		multiOwner:               multiOwner,
 	}, nil
 }
 
@@ -109,9 +185,9 @@ func (im *TXTRegistry) OwnerID() string {
 func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 	// If we have the zones cached AND we have refreshed the cache since the
 	// last given interval, then just use the cached results.
-	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
+	if cached := im.cachedRecords(); cached != nil {
 		log.Debug("Using cached records.")
-		return im.recordsCache, nil
+		return cached, nil
 	}
 
 	records, err := im.provider.Records(ctx)
@@ -123,6 +199,9 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 
 	labelMap := map[endpoint.EndpointKey]endpoint.Labels{}
 	txtRecordsMap := map[string]struct{}{}
+	// how the TXT records which are not signed with the signing key are signed, by the key of their record
+	signatureMap := map[endpoint.EndpointKey]signatureState{}
+	retiredKeyRecords := 0
 
 	for _, record := range records {
 		if record.RecordType != endpoint.RecordTypeTXT {
@@ -130,8 +209,11 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			continue
 		}
 		// We simply assume that TXT records for the registry will always have only one target.
-		labels, err := endpoint.NewLabelsFromString(record.Targets[0], im.txtEncryptAESKey)
-		if err == endpoint.ErrInvalidHeritage {
+		labels, signature, err := im.labelsFromString(record.Targets[0])
+		if err == endpoint.ErrInvalidSignature || err == endpoint.ErrMissingSignature {
+			log.Warnf("Ignoring TXT record %s because its ownership is not signed with one of the TXT signing keys", record.DNSName)
+		}
+		if err == endpoint.ErrInvalidHeritage || err == endpoint.ErrInvalidSignature || err == endpoint.ErrMissingSignature {
 			// if no heritage is found or it is invalid
 			// case when value of txt record cannot be identified
 			// record will not be removed as it will have empty owner
@@ -149,8 +231,15 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			SetIdentifier: record.SetIdentifier,
 		}
 		labelMap[key] = labels
+		if signature != signedWithSigningKey {
+			signatureMap[key] = signature
+		}
 		txtRecordsMap[record.DNSName] = struct{}{}
+		if im.encryptedWithRetiredKey(labels) {
+			retiredKeyRecords++
+		}
 	}
+	txtRetiredKeyRecords.Set(float64(retiredKeyRecords))
 
 	for _, ep := range endpoints {
 		if ep.Labels == nil {
@@ -179,8 +268,17 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 			key.RecordType = ""
 			labels, labelsExist = labelMap[key]
 		}
+		// Signed TXT records hold the name of their record, so that they cannot be copied to another record
+		if labelsExist && im.txtSigningKeys != nil && signatureMap[key] != acceptedUnsigned && !sameDNSName(labels[endpoint.OwnedRecordLabelKey], ep.DNSName) {
+			log.Warnf("Ignoring the ownership of %s because its signed TXT record belongs to %q", ep.DNSName, labels[endpoint.OwnedRecordLabelKey])
+			labelsExist = false
+		}
+		resign := labelsExist && signatureMap[key] != signedWithSigningKey
 		if labelsExist {
 			for k, v := range labels {
+				if k == endpoint.OwnedRecordLabelKey {
+					continue
+				}
 				ep.Labels[k] = v
 			}
 		}
@@ -196,28 +294,81 @@ func (im *TXTRegistry) Records(ctx context.Context) ([]*endpoint.Endpoint, error
 						ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
 					}
 				}
+				// Re-encrypt the TXT records encrypted with a retired key, or without key ID, with the active key.
+				if im.encryptedWithRetiredKey(ep.Labels) {
+					ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
+				}
+				// Sign the TXT records signed with a verification key, or not signed at all, with the signing key.
+				if resign {
+					ep.WithProviderSpecific(providerSpecificForceUpdate, "true")
+				}
 			}
 		}
 	}
 
 	// Update the cache.
 	if im.cacheInterval > 0 {
+		im.recordsCacheMux.Lock()
 		im.recordsCache = endpoints
 		im.recordsCacheRefreshTime = time.Now()
+		im.recordsCacheMux.Unlock()
 	}
 
 	return endpoints, nil
 }
 
+// labelsFromString returns the labels of the text of a TXT record and how they are signed. Labels signed with a
+// verification key are accepted, as are the unsigned labels owned by this instance if txtSigningAcceptUnsigned is set,
+// so that they can be signed with the signing key.
+func (im *TXTRegistry) labelsFromString(text string) (endpoint.Labels, signatureState, error) {
+	if im.txtSigningKeys == nil {
+		labels, err := endpoint.NewLabelsFromString(text, im.txtEncryptKeyring, nil)
+		return labels, signedWithSigningKey, err
+	}
+	labels, err := endpoint.NewLabelsFromString(text, im.txtEncryptKeyring, im.txtSigningKeys[:1])
+	switch {
+	case err == endpoint.ErrInvalidSignature && len(im.txtSigningKeys) > 1:
+		labels, err = endpoint.NewLabelsFromString(text, im.txtEncryptKeyring, im.txtSigningKeys[1:])
+		if err != nil {
+			return nil, signedWithVerificationKey, err
+		}
+		txtVerificationKeyRecordsTotal.Inc()
+		return labels, signedWithVerificationKey, nil
+	case err == endpoint.ErrMissingSignature && im.txtSigningAcceptUnsigned:
+		labels, err = endpoint.NewLabelsFromString(text, im.txtEncryptKeyring, nil)
+		if err != nil {
+			return nil, acceptedUnsigned, err
+		}
+		if labels[endpoint.OwnerLabelKey] != im.ownerID {
+			return nil, acceptedUnsigned, endpoint.ErrMissingSignature
+		}
+		txtUnsignedRecordsTotal.Inc()
+		return labels, acceptedUnsigned, nil
+	}
+	return labels, signedWithSigningKey, err
+}
+
+// encryptedWithRetiredKey returns true if the labels were decrypted with another key than the active key of the keyring,
+// or were encrypted without key ID
+func (im *TXTRegistry) encryptedWithRetiredKey(labels endpoint.Labels) bool {
+	keyRef := labels.EncryptionKey()
+	return im.txtEncryptEnabled && keyRef != "" && im.txtEncryptKeyring.Retired(keyRef)
+}
+
 // generateTXTRecord generates both "old" and "new" TXT records.
 // Once we decide to drop old format we need to drop toTXTName() and rename toNewTXTName
 func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpoint {
 	endpoints := make([]*endpoint.Endpoint, 0)
-
-	if !im.txtEncryptEnabled && !im.mapper.recordTypeInAffix() && r.RecordType != endpoint.RecordTypeAAAA {
-		// old TXT record format
-		txt := endpoint.NewEndpoint(im.mapper.toTXTName(r.DNSName), endpoint.RecordTypeTXT, r.Labels.Serialize(true, im.txtEncryptEnabled, im.txtEncryptAESKey))
-		if txt != nil {
+	text := im.serializeLabels(r)
+
+	// old TXT record format, not for CNAME records which cannot coexist with a TXT record of the same name
+	oldFormatName := im.mapper.toTXTName(r.DNSName)
+	sameNameCNAME := r.RecordType == endpoint.RecordTypeCNAME && oldFormatName == r.DNSName
+	if !im.txtEncryptEnabled && !im.mapper.recordTypeInAffix() && r.RecordType != endpoint.RecordTypeAAAA && !sameNameCNAME {
+		txt := endpoint.NewEndpoint(oldFormatName, endpoint.RecordTypeTXT, text)
+		if err := endpoint.Validate(txt); err != nil {
+			log.Errorf("Cannot create TXT record %s: %v", txt.DNSName, err)
+		} else {
 			txt.WithSetIdentifier(r.SetIdentifier)
 			txt.Labels[endpoint.OwnedRecordLabelKey] = r.DNSName
 			txt.ProviderSpecific = r.ProviderSpecific
@@ -230,8 +381,10 @@ func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpo
 	if isAlias, found := r.GetProviderSpecificProperty("alias"); found && isAlias == "true" && recordType == endpoint.RecordTypeA {
 		recordType = endpoint.RecordTypeCNAME
 	}
-	txtNew := endpoint.NewEndpoint(im.mapper.toNewTXTName(r.DNSName, recordType), endpoint.RecordTypeTXT, r.Labels.Serialize(true, im.txtEncryptEnabled, im.txtEncryptAESKey))
-	if txtNew != nil {
+	txtNew := endpoint.NewEndpoint(im.mapper.toNewTXTName(r.DNSName, recordType), endpoint.RecordTypeTXT, text)
+	if err := endpoint.Validate(txtNew); err != nil {
+		log.Errorf("Cannot create TXT record %s: %v", txtNew.DNSName, err)
+	} else {
 		txtNew.WithSetIdentifier(r.SetIdentifier)
 		txtNew.Labels[endpoint.OwnedRecordLabelKey] = r.DNSName
 		txtNew.ProviderSpecific = r.ProviderSpecific
@@ -241,14 +394,94 @@ func (im *TXTRegistry) generateTXTRecord(r *endpoint.Endpoint) []*endpoint.Endpo
 	return endpoints
 }
 
+// serializeLabels returns the text of the TXT records of the record. Signed TXT records include the name of the record.
+func (im *TXTRegistry) serializeLabels(r *endpoint.Endpoint) string {
+	if im.txtSigningKeys == nil || r.Labels == nil {
+		return r.Labels.Serialize(true, im.txtEncryptEnabled, im.txtEncryptKeyring, nil)
+	}
+	signed := make(endpoint.Labels, len(r.Labels)+1)
+	for k, v := range r.Labels {
+		signed[k] = v
+	}
+	signed[endpoint.OwnedRecordLabelKey] = r.DNSName
+	text := signed.Serialize(true, im.txtEncryptEnabled, im.txtEncryptKeyring, im.txtSigningKeys[0])
+	// the record keeps the encryption nonce and key of its labels, as without signature
+	for k, v := range signed {
+		if k != endpoint.OwnedRecordLabelKey {
+			r.Labels[k] = v
+		}
+	}
+	return text
+}
+
+// sameDNSName returns true if the DNS names are equal regardless of their case and trailing dot
+func sameDNSName(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// OwnershipRecordNames returns the names of the TXT records the ownership of the given record is stored in
+func (im *TXTRegistry) OwnershipRecordNames(ep *endpoint.Endpoint) []string {
+	names := []string{}
+	for _, txt := range im.generateTXTRecord(ep) {
+		names = append(names, txt.DNSName)
+	}
+	return names
+}
+
+// DeleteOwnershipRecords deletes the TXT records owned by this instance which hold the ownership of the records,
+// e.g. once their labels are migrated to another registry, and returns their number.
+func (im *TXTRegistry) DeleteOwnershipRecords(ctx context.Context, endpoints []*endpoint.Endpoint) (int, error) {
+	type ownershipRecordKey struct {
+		dnsName       string
+		setIdentifier string
+	}
+	keys := map[ownershipRecordKey]bool{}
+	for _, ep := range endpoints {
+		for _, name := range im.OwnershipRecordNames(ep) {
+			keys[ownershipRecordKey{dnsName: name, setIdentifier: ep.SetIdentifier}] = true
+		}
+	}
+
+	records, err := im.provider.Records(ctx)
+	if err != nil {
+		return 0, err
+	}
+	deletes := []*endpoint.Endpoint{}
+	for _, record := range records {
+		if record.RecordType != endpoint.RecordTypeTXT || !keys[ownershipRecordKey{dnsName: record.DNSName, setIdentifier: record.SetIdentifier}] {
+			continue
+		}
+		labels, err := endpoint.NewLabelsFromString(record.Targets[0], im.txtEncryptKeyring, im.txtSigningKeys)
+		if err != nil || labels[endpoint.OwnerLabelKey] != im.ownerID {
+			continue
+		}
+		deletes = append(deletes, record)
+	}
+	if len(deletes) == 0 {
+		return 0, nil
+	}
+
+	im.invalidateCache()
+	ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
+	if err := im.provider.ApplyChanges(ctx, &plan.Changes{Delete: deletes}); err != nil {
+		return 0, err
+	}
+	return len(deletes), nil
+}
+
 // ApplyChanges updates dns provider with the changes
 // for each created/deleted record it will also take into account TXT records for creation/deletion
 func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
 	filteredChanges := &plan.Changes{
-		Create:    changes.Create,
-		UpdateNew: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateNew),
-		UpdateOld: endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.UpdateOld),
-		Delete:    endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete),
+		Create:       changes.Create,
+		TargetDeltas: changes.TargetDeltas,
+	}
+	if im.multiOwner {
+		filteredChanges.Delete = endpoint.FilterSharedEndpointsByOwnerID(im.ownerID, changes.Delete)
+		filteredChanges.UpdateOld, filteredChanges.UpdateNew = endpoint.FilterSharedUpdatesByOwnerID(im.ownerID, changes.UpdateOld, changes.UpdateNew)
+	} else {
+		filteredChanges.Delete = endpoint.FilterEndpointsByOwnerID(im.ownerID, changes.Delete)
+		filteredChanges.UpdateOld, filteredChanges.UpdateNew = endpoint.FilterUpdatesByOwnerID(im.ownerID, changes.UpdateOld, changes.UpdateNew)
 	}
 	for _, r := range filteredChanges.Create {
 		if r.Labels == nil {
@@ -298,7 +531,12 @@ func (im *TXTRegistry) ApplyChanges(ctx context.Context, changes *plan.Changes)
 	if im.cacheInterval > 0 {
 		ctx = context.WithValue(ctx, provider.RecordsContextKey, nil)
 	}
-	return im.provider.ApplyChanges(ctx, filteredChanges)
+	if err := im.provider.ApplyChanges(ctx, filteredChanges); err != nil {
+		// the cache holds the records which failed to be applied
+		im.invalidateCache()
+		return err
+	}
+	return nil
 }
 
 // AdjustEndpoints modifies the endpoints as needed by the specific provider
@@ -406,11 +644,48 @@ func (pr affixNameMapper) toEndpointName(txtDNSName string) (endpointName string
 		domainWithSuffix := strings.Join(DNSName[:1+dc], ".")
 
 		r, rType := pr.dropAffixExtractType(domainWithSuffix)
+		if r == "" && strings.HasPrefix(domainWithSuffix, idnaPrefix) {
+			r, rType = pr.dropUnicodeAffixExtractType(domainWithSuffix)
+		}
 		return r + "." + DNSName[1+dc], rType
 	}
 	return "", ""
 }
 
+// dropUnicodeAffixExtractType strips the suffix which appendSuffix added to the Unicode form of an IDN label
+func (pr affixNameMapper) dropUnicodeAffixExtractType(name string) (baseName, recordType string) {
+	unicodeName, err := endpoint.ToUnicodeName(name)
+	if err != nil {
+		return "", ""
+	}
+	baseName, recordType = pr.dropAffixExtractType(unicodeName)
+	if baseName == "" {
+		return "", ""
+	}
+	if baseName, err = endpoint.ToASCIIName(baseName); err != nil {
+		return "", ""
+	}
+	return baseName, recordType
+}
+
+// appendSuffix appends the suffix to the first label of a DNS name. Appending it to an IDN label in its ASCII form
+// would not encode a name anymore, e.g. "xn--bcher-kva-txt", so it is appended to the Unicode form of the label
+// and the result converted to punycode again.
+func appendSuffix(label, suffix string) string {
+	if suffix == "" || !strings.HasPrefix(label, idnaPrefix) {
+		return label + suffix
+	}
+	unicodeLabel, err := endpoint.ToUnicodeName(label)
+	if err != nil {
+		return label + suffix
+	}
+	name, err := endpoint.ToASCIIName(unicodeLabel + suffix)
+	if err != nil {
+		return label + suffix
+	}
+	return name
+}
+
 func (pr affixNameMapper) toTXTName(endpointDNSName string) string {
 	DNSName := strings.SplitN(endpointDNSName, ".", 2)
 
@@ -422,9 +697,9 @@ func (pr affixNameMapper) toTXTName(endpointDNSName string) string {
 	}
 
 	if len(DNSName) < 2 {
-		return prefix + DNSName[0] + suffix
+		return prefix + appendSuffix(DNSName[0], suffix)
 	}
-	return prefix + DNSName[0] + suffix + "." + DNSName[1]
+	return prefix + appendSuffix(DNSName[0], suffix) + "." + DNSName[1]
 }
 
 func (pr affixNameMapper) recordTypeInAffix() bool {
@@ -462,19 +737,39 @@ func (pr affixNameMapper) toNewTXTName(endpointDNSName, recordType string) strin
 	}
 
 	if len(DNSName) < 2 {
-		return prefix + DNSName[0] + suffix
+		return prefix + appendSuffix(DNSName[0], suffix)
 	}
 
-	return prefix + DNSName[0] + suffix + "." + DNSName[1]
+	return prefix + appendSuffix(DNSName[0], suffix) + "." + DNSName[1]
+}
+
+// cachedRecords returns the cached records, or nil if they are missing or expired
+func (im *TXTRegistry) cachedRecords() []*endpoint.Endpoint {
+	im.recordsCacheMux.Lock()
+	defer im.recordsCacheMux.Unlock()
+	if im.recordsCache != nil && time.Since(im.recordsCacheRefreshTime) < im.cacheInterval {
+		return im.recordsCache
+	}
+	return nil
+}
+
+func (im *TXTRegistry) invalidateCache() {
+	im.recordsCacheMux.Lock()
+	defer im.recordsCacheMux.Unlock()
+	im.recordsCache = nil
 }
 
 func (im *TXTRegistry) addToCache(ep *endpoint.Endpoint) {
+	im.recordsCacheMux.Lock()
+	defer im.recordsCacheMux.Unlock()
 	if im.recordsCache != nil {
 		im.recordsCache = append(im.recordsCache, ep)
 	}
 }
 
 func (im *TXTRegistry) removeFromCache(ep *endpoint.Endpoint) {
+	im.recordsCacheMux.Lock()
+	defer im.recordsCacheMux.Unlock()
 	if im.recordsCache == nil || ep == nil {
 		return
 	}
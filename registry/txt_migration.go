@@ -0,0 +1,122 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package registry
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// txtMigration collects the ownership TXT records of the TXT registry, so that a registry keeping the labels
+// elsewhere can take over their labels and delete them.
+type txtMigration struct {
+	mapper              nameMapper
+	wildcardReplacement string
+	txtEncryptKeyring   *endpoint.AESKeyring
+
+	labels  map[endpoint.EndpointKey]endpoint.Labels
+	records map[endpoint.EndpointKey]*endpoint.Endpoint
+}
+
+func newTXTMigration(mapper nameMapper, wildcardReplacement string, txtEncryptAESKey []byte) *txtMigration {
+	// the registries check the length of the key, which is the only error of the keyring
+	txtEncryptKeyring, _ := endpoint.NewAESKeyring(txtEncryptAESKey)
+	return &txtMigration{
+		mapper:              mapper,
+		wildcardReplacement: wildcardReplacement,
+		txtEncryptKeyring:   txtEncryptKeyring,
+		labels:              map[endpoint.EndpointKey]endpoint.Labels{},
+		records:             map[endpoint.EndpointKey]*endpoint.Endpoint{},
+	}
+}
+
+// collect returns true if the record is an ownership TXT record, in which case its labels are kept for migrate.
+func (m *txtMigration) collect(record *endpoint.Endpoint) bool {
+	if record.RecordType != endpoint.RecordTypeTXT {
+		return false
+	}
+	// We simply assume that TXT records for the TXT registry will always have only one target.
+	labels, err := endpoint.NewLabelsFromString(record.Targets[0], m.txtEncryptKeyring, nil)
+	if err != nil {
+		return false
+	}
+	// signed TXT records hold the name of their record, which is not a label of the record
+	delete(labels, endpoint.OwnedRecordLabelKey)
+	endpointName, recordType := m.mapper.toEndpointName(record.DNSName)
+	key := endpoint.EndpointKey{
+		DNSName:       endpointName,
+		SetIdentifier: record.SetIdentifier,
+	}
+	if recordType == endpoint.RecordTypeAAAA {
+		key.RecordType = recordType
+	}
+	m.labels[key] = labels
+	m.records[key] = record
+	return true
+}
+
+// migrate copies the labels of the collected TXT records to the endpoints without stored labels. These endpoints get
+// the provider specific attribute, so that the plan updates them and the registry stores their labels.
+func (m *txtMigration) migrate(endpoints []*endpoint.Endpoint, stored map[endpoint.EndpointKey]endpoint.Labels, attribute string) {
+	if len(m.labels) == 0 {
+		return
+	}
+	for _, ep := range endpoints {
+		if _, ok := stored[ep.Key()]; ok {
+			continue
+		}
+
+		dnsNameSplit := strings.Split(ep.DNSName, ".")
+		// If specified, replace a leading asterisk in the generated txt record name with some other string
+		if m.wildcardReplacement != "" && dnsNameSplit[0] == "*" {
+			dnsNameSplit[0] = m.wildcardReplacement
+		}
+		dnsName := strings.Join(dnsNameSplit, ".")
+		key := endpoint.EndpointKey{
+			DNSName:       dnsName,
+			SetIdentifier: ep.SetIdentifier,
+		}
+		if ep.RecordType == endpoint.RecordTypeAAAA {
+			key.RecordType = ep.RecordType
+		}
+		if labels, ok := m.labels[key]; ok {
+			for k, v := range labels {
+				ep.Labels[k] = v
+			}
+			ep.SetProviderSpecificProperty(attribute, "true")
+			delete(m.records, key)
+		}
+	}
+}
+
+// obsoleteRecords returns the collected TXT records which were not migrated, owned by ownerID so that the plan
+// deletes them if TXT records are managed.
+func (m *txtMigration) obsoleteRecords(ownerID string, managedRecordTypes, excludeRecordTypes []string) []*endpoint.Endpoint {
+	if len(m.records) > 0 && !plan.IsManagedRecord(endpoint.RecordTypeTXT, managedRecordTypes, excludeRecordTypes) {
+		log.Infof("Old TXT ownership records will not be deleted because \"TXT\" is not in the set of managed record types.")
+	}
+	records := make([]*endpoint.Endpoint, 0, len(m.records))
+	for _, record := range m.records {
+		record.Labels[endpoint.OwnerLabelKey] = ownerID
+		records = append(records, record)
+	}
+	return records
+}
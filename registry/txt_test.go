@@ -18,11 +18,17 @@ package registry
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -46,20 +52,20 @@ func TestTXTRegistry(t *testing.T) {
 
 func testTXTRegistryNew(t *testing.T) {
 	p := inmemory.NewInMemoryProvider()
-	_, err := NewTXTRegistry(p, "txt", "", "", time.Hour, "", []string{}, []string{}, false, nil)
+	_, err := NewTXTRegistry(p, "txt", "", "", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	require.Error(t, err)
 
-	_, err = NewTXTRegistry(p, "", "txt", "", time.Hour, "", []string{}, []string{}, false, nil)
+	_, err = NewTXTRegistry(p, "", "txt", "", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	require.Error(t, err)
 
-	r, err := NewTXTRegistry(p, "txt", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, err := NewTXTRegistry(p, "txt", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	require.NoError(t, err)
 	assert.Equal(t, p, r.provider)
 
-	r, err = NewTXTRegistry(p, "", "txt", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, err = NewTXTRegistry(p, "", "txt", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	require.NoError(t, err)
 
-	_, err = NewTXTRegistry(p, "txt", "txt", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	_, err = NewTXTRegistry(p, "txt", "txt", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	require.Error(t, err)
 
 	_, ok := r.mapper.(affixNameMapper)
@@ -68,16 +74,16 @@ func testTXTRegistryNew(t *testing.T) {
 	assert.Equal(t, p, r.provider)
 
 	aesKey := []byte(";k&l)nUC/33:{?d{3)54+,AD?]SX%yh^")
-	_, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	_, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	require.NoError(t, err)
 
-	_, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, aesKey)
+	_, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, [][]byte{aesKey}, nil, false, false)
 	require.NoError(t, err)
 
-	_, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, true, nil)
+	_, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, true, nil, nil, false, false)
 	require.Error(t, err)
 
-	r, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, true, aesKey)
+	r, err = NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, true, [][]byte{aesKey}, nil, false, false)
 	require.NoError(t, err)
 
 	_, ok = r.mapper.(affixNameMapper)
@@ -215,13 +221,13 @@ func testTXTRegistryRecordsPrefixed(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "wc", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "wc", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 
 	// Ensure prefix is case-insensitive
-	r, _ = NewTXTRegistry(p, "TxT.", "", "owner", time.Hour, "wc", []string{}, []string{}, false, nil)
+	r, _ = NewTXTRegistry(p, "TxT.", "", "owner", time.Hour, "wc", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ = r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
@@ -340,13 +346,13 @@ func testTXTRegistryRecordsSuffixed(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 
 	// Ensure prefix is case-insensitive
-	r, _ = NewTXTRegistry(p, "", "-TxT", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ = NewTXTRegistry(p, "", "-TxT", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ = r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpointLabels(records, expectedRecords))
@@ -457,7 +463,7 @@ func testTXTRegistryRecordsNoPrefix(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
@@ -484,12 +490,12 @@ func testTXTRegistryRecordsPrefixedTemplated(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "txt-%{record_type}.", "", "owner", time.Hour, "wc", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "txt-%{record_type}.", "", "owner", time.Hour, "wc", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 
-	r, _ = NewTXTRegistry(p, "TxT-%{record_type}.", "", "owner", time.Hour, "wc", []string{}, []string{}, false, nil)
+	r, _ = NewTXTRegistry(p, "TxT-%{record_type}.", "", "owner", time.Hour, "wc", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ = r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
@@ -516,12 +522,12 @@ func testTXTRegistryRecordsSuffixedTemplated(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "", "txt%{record_type}", "owner", time.Hour, "wc", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "txt%{record_type}", "owner", time.Hour, "wc", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
 
-	r, _ = NewTXTRegistry(p, "", "TxT%{record_type}", "owner", time.Hour, "wc", []string{}, []string{}, false, nil)
+	r, _ = NewTXTRegistry(p, "", "TxT%{record_type}", "owner", time.Hour, "wc", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ = r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
@@ -564,7 +570,7 @@ func testTXTRegistryApplyChangesWithPrefix(t *testing.T) {
 			newEndpointWithOwner("txt.cname-multiple.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "").WithSetIdentifier("test-set-2"),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -653,7 +659,7 @@ func testTXTRegistryApplyChangesWithTemplatedPrefix(t *testing.T) {
 	p.ApplyChanges(ctx, &plan.Changes{
 		Create: []*endpoint.Endpoint{},
 	})
-	r, _ := NewTXTRegistry(p, "prefix%{record_type}.", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "prefix%{record_type}.", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
 			newEndpointWithOwnerResource("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "", "ingress/default/my-ingress"),
@@ -696,7 +702,7 @@ func testTXTRegistryApplyChangesWithTemplatedSuffix(t *testing.T) {
 	p.OnApplyChanges = func(ctx context.Context, got *plan.Changes) {
 		assert.Equal(t, ctxEndpoints, ctx.Value(provider.RecordsContextKey))
 	}
-	r, _ := NewTXTRegistry(p, "", "-%{record_type}suffix", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "-%{record_type}suffix", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
 			newEndpointWithOwnerResource("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "", "ingress/default/my-ingress"),
@@ -761,7 +767,7 @@ func testTXTRegistryApplyChangesWithSuffix(t *testing.T) {
 			newEndpointWithOwner("cname-multiple-txt.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "").WithSetIdentifier("test-set-2"),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "wildcard", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "-txt", "owner", time.Hour, "wildcard", []string{}, []string{}, false, nil, nil, false, false)
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -865,7 +871,7 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 			newEndpointWithOwner("cname-foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -886,10 +892,8 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 	expected := &plan.Changes{
 		Create: []*endpoint.Endpoint{
 			newEndpointWithOwner("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwnerAndOwnedRecord("new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "new-record-1.test-zone.example.org"),
 			newEndpointWithOwnerAndOwnedRecord("cname-new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "new-record-1.test-zone.example.org"),
 			newEndpointWithOwner("example", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwnerAndOwnedRecord("example", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "example"),
 			newEndpointWithOwnerAndOwnedRecord("cname-example", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "example"),
 			newEndpointWithOwner("new-alias.test-zone.example.org", "my-domain.com", endpoint.RecordTypeA, "owner").WithProviderSpecific("alias", "true"),
 			// TODO: It's not clear why the TXT registry copies ProviderSpecificProperties to ownership records; that doesn't seem correct.
@@ -898,7 +902,6 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 		},
 		Delete: []*endpoint.Endpoint{
 			newEndpointWithOwner("foobar.test-zone.example.org", "foobar.loadbalancer.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwnerAndOwnedRecord("foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "foobar.test-zone.example.org"),
 			newEndpointWithOwnerAndOwnedRecord("cname-foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "foobar.test-zone.example.org"),
 		},
 		UpdateNew: []*endpoint.Endpoint{},
@@ -924,6 +927,40 @@ func testTXTRegistryApplyChangesNoPrefix(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTXTRegistryApplyChangesSharedRecord(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		multiOwner bool
+		deleted    bool
+	}{
+		{name: "owner targets are ignored", multiOwner: false, deleted: false},
+		{name: "owner targets are honoured in multi-owner mode", multiOwner: true, deleted: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			p := inmemory.NewInMemoryProvider()
+			p.CreateZone(testZone)
+			other, _ := NewTXTRegistry(p, "txt.", "", "other", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
+			shared := newEndpointWithOwner("shared.test-zone.example.org", "1.1.1.1", endpoint.RecordTypeA, "")
+			shared.Labels[endpoint.OwnerTargetsLabelPrefix+"owner"] = "1.1.1.1"
+			require.NoError(t, other.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{shared}}))
+
+			r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, tt.multiOwner)
+			records, err := r.Records(ctx)
+			require.NoError(t, err)
+			require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Delete: records}))
+
+			records, err = p.Records(ctx)
+			require.NoError(t, err)
+			if tt.deleted {
+				assert.Empty(t, records)
+			} else {
+				assert.Len(t, records, 3)
+			}
+		})
+	}
+}
+
 func testTXTRegistryMissingRecords(t *testing.T) {
 	t.Run("No prefix", testTXTRegistryMissingRecordsNoPrefix)
 	t.Run("With Prefix", testTXTRegistryMissingRecordsWithPrefix)
@@ -1028,7 +1065,7 @@ func testTXTRegistryMissingRecordsNoPrefix(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "wc", []string{endpoint.RecordTypeCNAME, endpoint.RecordTypeA, endpoint.RecordTypeNS}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "wc", []string{endpoint.RecordTypeCNAME, endpoint.RecordTypeA, endpoint.RecordTypeNS}, []string{}, false, nil, nil, false, false)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
@@ -1138,7 +1175,7 @@ func testTXTRegistryMissingRecordsWithPrefix(t *testing.T) {
 		},
 	}
 
-	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "wc", []string{endpoint.RecordTypeCNAME, endpoint.RecordTypeA, endpoint.RecordTypeNS, endpoint.RecordTypeTXT}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "wc", []string{endpoint.RecordTypeCNAME, endpoint.RecordTypeA, endpoint.RecordTypeNS, endpoint.RecordTypeTXT}, []string{}, false, nil, nil, false, false)
 	records, _ := r.Records(ctx)
 
 	assert.True(t, testutils.SameEndpoints(records, expectedRecords))
@@ -1426,7 +1463,7 @@ func TestNewTXTScheme(t *testing.T) {
 			newEndpointWithOwner("cname-foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
 		},
 	})
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 
 	changes := &plan.Changes{
 		Create: []*endpoint.Endpoint{
@@ -1446,15 +1483,12 @@ func TestNewTXTScheme(t *testing.T) {
 	expected := &plan.Changes{
 		Create: []*endpoint.Endpoint{
 			newEndpointWithOwner("new-record-1.test-zone.example.org", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwnerAndOwnedRecord("new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "new-record-1.test-zone.example.org"),
 			newEndpointWithOwnerAndOwnedRecord("cname-new-record-1.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "new-record-1.test-zone.example.org"),
 			newEndpointWithOwner("example", "new-loadbalancer-1.lb.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwnerAndOwnedRecord("example", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "example"),
 			newEndpointWithOwnerAndOwnedRecord("cname-example", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "example"),
 		},
 		Delete: []*endpoint.Endpoint{
 			newEndpointWithOwner("foobar.test-zone.example.org", "foobar.loadbalancer.com", endpoint.RecordTypeCNAME, "owner"),
-			newEndpointWithOwnerAndOwnedRecord("foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "foobar.test-zone.example.org"),
 			newEndpointWithOwnerAndOwnedRecord("cname-foobar.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, "", "foobar.test-zone.example.org"),
 		},
 		UpdateNew: []*endpoint.Endpoint{},
@@ -1482,15 +1516,8 @@ func TestNewTXTScheme(t *testing.T) {
 
 func TestGenerateTXT(t *testing.T) {
 	record := newEndpointWithOwner("foo.test-zone.example.org", "new-foo.loadbalancer.com", endpoint.RecordTypeCNAME, "owner")
+	// a CNAME record cannot coexist with the TXT record of the old format of the same name
 	expectedTXT := []*endpoint.Endpoint{
-		{
-			DNSName:    "foo.test-zone.example.org",
-			Targets:    endpoint.Targets{"\"heritage=external-dns,external-dns/owner=owner\""},
-			RecordType: endpoint.RecordTypeTXT,
-			Labels: map[string]string{
-				endpoint.OwnedRecordLabelKey: "foo.test-zone.example.org",
-			},
-		},
 		{
 			DNSName:    "cname-foo.test-zone.example.org",
 			Targets:    endpoint.Targets{"\"heritage=external-dns,external-dns/owner=owner\""},
@@ -1502,7 +1529,7 @@ func TestGenerateTXT(t *testing.T) {
 	}
 	p := inmemory.NewInMemoryProvider()
 	p.CreateZone(testZone)
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	gotTXT := r.generateTXTRecord(record)
 	assert.Equal(t, expectedTXT, gotTXT)
 }
@@ -1521,11 +1548,74 @@ func TestGenerateTXTForAAAA(t *testing.T) {
 	}
 	p := inmemory.NewInMemoryProvider()
 	p.CreateZone(testZone)
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	gotTXT := r.generateTXTRecord(record)
 	assert.Equal(t, expectedTXT, gotTXT)
 }
 
+func TestOwnershipRecordNames(t *testing.T) {
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	record := newEndpointWithOwner("foo.test-zone.example.org", "new-foo.loadbalancer.com", endpoint.RecordTypeCNAME, "owner")
+
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
+	assert.Equal(t, []string{"cname-foo.test-zone.example.org"}, r.OwnershipRecordNames(record))
+
+	r, _ = NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
+	assert.Equal(t, []string{"txt.foo.test-zone.example.org", "txt.cname-foo.test-zone.example.org"}, r.OwnershipRecordNames(record))
+}
+
+func TestOwnershipRecordNamesAllowCNAME(t *testing.T) {
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	r, _ := NewTXTRegistry(p, "", "", "owner", 0, "", []string{}, []string{}, false, nil, nil, false, false)
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	calculated := (&plan.Plan{
+		Policies:             []plan.Policy{&plan.SyncPolicy{}},
+		Current:              records,
+		Desired:              []*endpoint.Endpoint{newEndpointWithOwner("foo.test-zone.example.org", "lb.example.com", endpoint.RecordTypeCNAME, "")},
+		ManagedRecords:       []string{endpoint.RecordTypeA, endpoint.RecordTypeCNAME},
+		OwnerID:              r.OwnerID(),
+		OwnershipRecordNames: r.OwnershipRecordNames,
+	}).Calculate()
+	require.Len(t, calculated.Changes.Create, 1)
+	require.NoError(t, r.ApplyChanges(ctx, calculated.Changes))
+
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "owner", records[0].Labels[endpoint.OwnerLabelKey])
+}
+
+func TestInternationalizedTXTNames(t *testing.T) {
+	for _, tc := range []struct {
+		mapper     affixNameMapper
+		oldTXTName string
+		newTXTName string
+	}{
+		{newaffixNameMapper("", "", ""), "xn--bcher-kva.example.org", "a-xn--bcher-kva.example.org"},
+		{newaffixNameMapper("txt-", "", ""), "txt-xn--bcher-kva.example.org", "txt-a-xn--bcher-kva.example.org"},
+		{newaffixNameMapper("", "-txt", ""), "xn--bcher-txt-q9a.example.org", "a-xn--bcher-kva-txt.example.org"},
+		// the old format is not generated when the record type is in the affix
+		{newaffixNameMapper("", "-%{record_type}", ""), "", "xn--bcher-a-n2a.example.org"},
+	} {
+		t.Run(tc.newTXTName, func(t *testing.T) {
+			if tc.oldTXTName != "" {
+				assert.Equal(t, tc.oldTXTName, tc.mapper.toTXTName("xn--bcher-kva.example.org"))
+			}
+			txtName := tc.mapper.toNewTXTName("xn--bcher-kva.example.org", endpoint.RecordTypeA)
+			assert.Equal(t, tc.newTXTName, txtName)
+
+			name, recordType := tc.mapper.toEndpointName(txtName)
+			assert.Equal(t, "xn--bcher-kva.example.org", name)
+			assert.Equal(t, endpoint.RecordTypeA, recordType)
+		})
+	}
+}
+
 func TestFailGenerateTXT(t *testing.T) {
 
 	cnameRecord := &endpoint.Endpoint{
@@ -1538,7 +1628,7 @@ func TestFailGenerateTXT(t *testing.T) {
 	expectedTXT := []*endpoint.Endpoint{}
 	p := inmemory.NewInMemoryProvider()
 	p.CreateZone(testZone)
-	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	gotTXT := r.generateTXTRecord(cnameRecord)
 	assert.Equal(t, expectedTXT, gotTXT)
 }
@@ -1556,7 +1646,7 @@ func TestTXTRegistryApplyChangesEncrypt(t *testing.T) {
 		},
 	})
 
-	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", []string{}, []string{}, true, []byte("12345678901234567890123456789012"))
+	r, _ := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", []string{}, []string{}, true, [][]byte{[]byte("12345678901234567890123456789012")}, nil, false, false)
 	records, _ := r.Records(ctx)
 	changes := &plan.Changes{
 		Delete: records,
@@ -1584,6 +1674,236 @@ func TestTXTRegistryApplyChangesEncrypt(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTXTRegistryApplyChangesConcurrently(t *testing.T) {
+	ctx := context.Background()
+	p := newLockedProvider()
+	require.NoError(t, p.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{
+		newEndpointWithOwner("existing.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+	}}))
+	r, err := NewTXTRegistry(p, "txt.", "", "owner", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
+	require.NoError(t, err)
+	_, err = r.Records(ctx)
+	require.NoError(t, err)
+
+	errs := applyChangesConcurrently(r, []string{"a", "b", "fail", "c"})
+	assert.Len(t, errs, 1)
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Len(t, records, 4, "the records which failed to be created are not cached")
+}
+
+func TestTXTRegistryEncryptionKeyRotation(t *testing.T) {
+	activeKey := []byte("s%zF`.*'5`9.AhI2!B,.~hmbs^.*TL?;")
+	retiredKey := []byte("12345678901234567890123456789012")
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+
+	retired, err := endpoint.NewAESKeyring(retiredKey)
+	require.NoError(t, err)
+	nonce, err := endpoint.GenerateNonce()
+	require.NoError(t, err)
+	legacy, err := endpoint.EncryptText("heritage=external-dns,external-dns/owner=owner", activeKey, nonce)
+	require.NoError(t, err)
+	texts := map[string]string{
+		"txt.a-retired.test-zone.example.org": endpoint.Labels{endpoint.OwnerLabelKey: "owner"}.Serialize(true, true, retired, nil),
+		"txt.a-legacy.test-zone.example.org":  "\"" + legacy + "\"",
+	}
+	p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("retired.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.a-retired.test-zone.example.org", texts["txt.a-retired.test-zone.example.org"], endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("legacy.test-zone.example.org", "1.2.3.5", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.a-legacy.test-zone.example.org", texts["txt.a-legacy.test-zone.example.org"], endpoint.RecordTypeTXT, ""),
+		},
+	})
+
+	r, err := NewTXTRegistry(p, "txt.", "", "owner", 0, "", []string{endpoint.RecordTypeA}, []string{}, true, [][]byte{activeKey, retiredKey}, nil, false, false)
+	require.NoError(t, err)
+	fresh := newEndpointWithOwner("fresh.test-zone.example.org", "1.2.3.6", endpoint.RecordTypeA, "")
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{fresh}}))
+
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, math.Float64bits(2), valueFromMetric(txtRetiredKeyRecords))
+	for _, record := range records {
+		_, forceUpdate := record.GetProviderSpecificProperty(providerSpecificForceUpdate)
+		assert.Equal(t, record.DNSName != "fresh.test-zone.example.org", forceUpdate, record.DNSName)
+		assert.Equal(t, "owner", record.Labels[endpoint.OwnerLabelKey], record.DNSName)
+
+		// the current TXT records are reproduced with the key they were encrypted with
+		for _, txt := range r.generateTXTRecord(record) {
+			if text, ok := texts[txt.DNSName]; ok {
+				assert.Equal(t, text, txt.Targets[0])
+			}
+		}
+
+		// the updated TXT records are encrypted with the active key
+		desired := newEndpointWithOwner(record.DNSName, record.Targets[0], record.RecordType, "owner")
+		for _, txt := range r.generateTXTRecord(desired) {
+			assert.True(t, strings.HasPrefix(txt.Targets[0], "\""+r.txtEncryptKeyring.ActiveKeyID()+":"), txt.Targets[0])
+		}
+	}
+}
+
+func TestTXTRegistrySigned(t *testing.T) {
+	signingKey := []byte("a8Qd3v7Wm2Zr5Tk9Xp1Lc6Hf4Jn0Bs8G")
+	oldKey := []byte("Yt2Rw6Ue9Io3Pa7Sd1Fg5Hj8Kl4Zx0Cv")
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+
+	_, err := NewTXTRegistry(p, "txt.", "", "owner", 0, "", []string{}, []string{}, false, nil, [][]byte{[]byte("too-short")}, false, false)
+	require.EqualError(t, err, "the TXT signing keys must have a length of at least 32 bytes")
+
+	r, err := NewTXTRegistry(p, "txt.", "", "owner", 0, "", []string{}, []string{}, false, nil, [][]byte{signingKey, oldKey}, false, false)
+	require.NoError(t, err)
+	foo := newEndpointWithOwnerResource("foo.test-zone.example.org", "foo.loadbalancer.com", endpoint.RecordTypeCNAME, "", "ingress/default/foo")
+	require.NoError(t, r.ApplyChanges(ctx, &plan.Changes{Create: []*endpoint.Endpoint{foo}}))
+	assert.NotContains(t, foo.Labels, endpoint.OwnedRecordLabelKey)
+
+	records, err := p.Records(ctx)
+	require.NoError(t, err)
+	var signed string
+	for _, record := range records {
+		if record.DNSName == "txt.cname-foo.test-zone.example.org" {
+			signed = record.Targets[0]
+		}
+	}
+	assert.Equal(t, "\"heritage=external-dns,external-dns/ownedRecord=foo.test-zone.example.org,external-dns/owner=owner,external-dns/resource=ingress/default/foo,external-dns/txt-signature=", signed[:len(signed)-33])
+
+	oldSigned := endpoint.Labels{
+		endpoint.OwnerLabelKey:       "owner",
+		endpoint.OwnedRecordLabelKey: "old.test-zone.example.org",
+	}.Serialize(true, false, nil, oldKey)
+	mixedCaseSigned := endpoint.Labels{
+		endpoint.OwnerLabelKey:       "owner",
+		endpoint.OwnedRecordLabelKey: "Mixed.Test-Zone.example.org",
+	}.Serialize(true, false, nil, signingKey)
+	trailingDotSigned := endpoint.Labels{
+		endpoint.OwnerLabelKey:       "owner",
+		endpoint.OwnedRecordLabelKey: "dot.test-zone.example.org.",
+	}.Serialize(true, false, nil, signingKey)
+	p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			// ownership signed with a verification key
+			newEndpointWithOwner("old.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.a-old.test-zone.example.org", oldSigned, endpoint.RecordTypeTXT, ""),
+			// ownership signed with the name of the record in another case, or with a trailing dot
+			newEndpointWithOwner("mixed.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.a-mixed.test-zone.example.org", mixedCaseSigned, endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("dot.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.a-dot.test-zone.example.org", trailingDotSigned, endpoint.RecordTypeTXT, ""),
+			// unsigned ownership
+			newEndpointWithOwner("unsigned.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.a-unsigned.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			// ownership of another record
+			newEndpointWithOwner("copy.test-zone.example.org", "copy.loadbalancer.com", endpoint.RecordTypeCNAME, ""),
+			newEndpointWithOwner("txt.cname-copy.test-zone.example.org", signed, endpoint.RecordTypeTXT, ""),
+		},
+	})
+
+	records, err = r.Records(ctx)
+	require.NoError(t, err)
+	owners := map[string]string{}
+	for _, record := range records {
+		owners[record.DNSName] = record.Labels[endpoint.OwnerLabelKey]
+		assert.NotContains(t, record.Labels, endpoint.OwnedRecordLabelKey)
+	}
+	assert.Equal(t, map[string]string{
+		"foo.test-zone.example.org":            "owner",
+		"old.test-zone.example.org":            "owner",
+		"mixed.test-zone.example.org":          "owner",
+		"dot.test-zone.example.org":            "owner",
+		"unsigned.test-zone.example.org":       "",
+		"txt.a-unsigned.test-zone.example.org": "",
+		"copy.test-zone.example.org":           "",
+	}, owners)
+}
+
+func TestTXTRegistrySigningKeyRotation(t *testing.T) {
+	signingKey := []byte("a8Qd3v7Wm2Zr5Tk9Xp1Lc6Hf4Jn0Bs8G")
+	oldKey := []byte("Yt2Rw6Ue9Io3Pa7Sd1Fg5Hj8Kl4Zx0Cv")
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+
+	signedWith := func(name string, key []byte) string {
+		return endpoint.Labels{
+			endpoint.OwnerLabelKey:       "owner",
+			endpoint.OwnedRecordLabelKey: name,
+		}.Serialize(true, false, nil, key)
+	}
+	p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("active.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.active.test-zone.example.org", signedWith("active.test-zone.example.org", signingKey), endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.a-active.test-zone.example.org", signedWith("active.test-zone.example.org", signingKey), endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("old.test-zone.example.org", "1.2.3.5", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.old.test-zone.example.org", signedWith("old.test-zone.example.org", oldKey), endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.a-old.test-zone.example.org", signedWith("old.test-zone.example.org", oldKey), endpoint.RecordTypeTXT, ""),
+		},
+	})
+
+	r, err := NewTXTRegistry(p, "txt.", "", "owner", 0, "", []string{endpoint.RecordTypeA}, []string{}, false, nil, [][]byte{signingKey, oldKey}, false, false)
+	require.NoError(t, err)
+	verified := testutil.ToFloat64(txtVerificationKeyRecordsTotal)
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, verified+2, testutil.ToFloat64(txtVerificationKeyRecordsTotal))
+	require.Len(t, records, 2)
+	for _, record := range records {
+		_, forceUpdate := record.GetProviderSpecificProperty(providerSpecificForceUpdate)
+		assert.Equal(t, record.DNSName == "old.test-zone.example.org", forceUpdate, record.DNSName)
+		assert.Equal(t, "owner", record.Labels[endpoint.OwnerLabelKey], record.DNSName)
+
+		// the updated TXT records are signed with the signing key
+		for _, txt := range r.generateTXTRecord(record) {
+			assert.Equal(t, signedWith(record.DNSName, signingKey), txt.Targets[0])
+		}
+	}
+}
+
+func TestTXTRegistrySigningAcceptUnsigned(t *testing.T) {
+	signingKey := []byte("a8Qd3v7Wm2Zr5Tk9Xp1Lc6Hf4Jn0Bs8G")
+	ctx := context.Background()
+	p := inmemory.NewInMemoryProvider()
+	p.CreateZone(testZone)
+	p.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{
+			newEndpointWithOwner("unsigned.test-zone.example.org", "1.2.3.4", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.unsigned.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("txt.a-unsigned.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=owner\"", endpoint.RecordTypeTXT, ""),
+			newEndpointWithOwner("other.test-zone.example.org", "1.2.3.5", endpoint.RecordTypeA, ""),
+			newEndpointWithOwner("txt.a-other.test-zone.example.org", "\"heritage=external-dns,external-dns/owner=other\"", endpoint.RecordTypeTXT, ""),
+		},
+	})
+
+	_, err := NewTXTRegistry(p, "txt.", "", "owner", 0, "", []string{endpoint.RecordTypeA}, []string{}, false, nil, nil, true, false)
+	require.EqualError(t, err, "the TXT signing key must be set to accept unsigned TXT records")
+
+	r, err := NewTXTRegistry(p, "txt.", "", "owner", 0, "", []string{endpoint.RecordTypeA}, []string{}, false, nil, [][]byte{signingKey}, true, false)
+	require.NoError(t, err)
+	unsigned := testutil.ToFloat64(txtUnsignedRecordsTotal)
+	records, err := r.Records(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, unsigned+2, testutil.ToFloat64(txtUnsignedRecordsTotal))
+
+	owners := map[string]string{}
+	for _, record := range records {
+		owners[record.DNSName] = record.Labels[endpoint.OwnerLabelKey]
+		_, forceUpdate := record.GetProviderSpecificProperty(providerSpecificForceUpdate)
+		assert.Equal(t, record.DNSName == "unsigned.test-zone.example.org", forceUpdate, record.DNSName)
+	}
+	// the unsigned ownership of another instance is still ignored
+	assert.Equal(t, map[string]string{
+		"unsigned.test-zone.example.org":    "owner",
+		"other.test-zone.example.org":       "",
+		"txt.a-other.test-zone.example.org": "",
+	}, owners)
+}
+
 // TestMultiClusterDifferentRecordTypeOwnership validates the registry handles environments where the same zone is managed by
 // external-dns in different clusters and the ingress record type is different. For example one uses A records and the other
 // uses CNAME. In this environment the first cluster that establishes the owner record should maintain ownership even
@@ -1602,7 +1922,7 @@ func TestMultiClusterDifferentRecordTypeOwnership(t *testing.T) {
 		},
 	})
 
-	r, _ := NewTXTRegistry(p, "_owner.", "", "bar", time.Hour, "", []string{}, []string{}, false, nil)
+	r, _ := NewTXTRegistry(p, "_owner.", "", "bar", time.Hour, "", []string{}, []string{}, false, nil, nil, false, false)
 	records, _ := r.Records(ctx)
 
 	// new cluster has same ingress host as other cluster and uses CNAME ingress address
@@ -1652,6 +1972,58 @@ helper methods
 
 */
 
+// lockedProvider is an inmemory provider which can be called concurrently, and which fails to create the records
+// starting with "fail."
+type lockedProvider struct {
+	*inmemory.InMemoryProvider
+	mux sync.Mutex
+}
+
+func newLockedProvider() *lockedProvider {
+	p := inmemory.NewInMemoryProvider()
+	_ = p.CreateZone(testZone)
+	return &lockedProvider{InMemoryProvider: p}
+}
+
+func (p *lockedProvider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.InMemoryProvider.Records(ctx)
+}
+
+func (p *lockedProvider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	for _, ep := range changes.Create {
+		if strings.HasPrefix(ep.DNSName, "fail.") {
+			return errors.New("failed to create " + ep.DNSName)
+		}
+	}
+	return p.InMemoryProvider.ApplyChanges(ctx, changes)
+}
+
+// applyChangesConcurrently creates an A record for every name in a separate call to ApplyChanges, like the controller
+// does for distinct zones, and returns the errors.
+func applyChangesConcurrently(r Registry, names []string) []error {
+	var wg sync.WaitGroup
+	var errsMux sync.Mutex
+	var errs []error
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			ep := endpoint.NewEndpoint(name+"."+testZone, endpoint.RecordTypeA, fmt.Sprintf("1.2.3.%d", i))
+			if err := r.ApplyChanges(context.Background(), &plan.Changes{Create: []*endpoint.Endpoint{ep}}); err != nil {
+				errsMux.Lock()
+				errs = append(errs, err)
+				errsMux.Unlock()
+			}
+		}(i, name)
+	}
+	wg.Wait()
+	return errs
+}
+
 func newEndpointWithOwner(dnsName, target, recordType, ownerID string) *endpoint.Endpoint {
 	return newEndpointWithOwnerAndLabels(dnsName, target, recordType, ownerID, nil)
 }
@@ -1675,3 +2047,33 @@ func newEndpointWithOwnerResource(dnsName, target, recordType, ownerID, resource
 	e.Labels[endpoint.ResourceLabelKey] = resource
 	return e
 }
+
+func valueFromMetric(metric prometheus.Gauge) uint64 {
+	ref := reflect.ValueOf(metric)
+	return reflect.Indirect(ref).FieldByName("valBits").Uint()
+}
+
+// assertCacheInvalidated checks that a replica sees the ownership of a record created by another replica, while it
+// was standby, once it invalidates its cache when it acquires the leader election lease again.
+func assertCacheInvalidated(t *testing.T, replica, leader Registry) {
+	ctx := context.Background()
+	owner := func() string {
+		records, err := replica.Records(ctx)
+		require.NoError(t, err)
+		for _, record := range records {
+			if record.DNSName == "new."+testZone {
+				return record.Labels[endpoint.OwnerLabelKey]
+			}
+		}
+		return ""
+	}
+
+	assert.Empty(t, owner())
+	require.NoError(t, leader.ApplyChanges(ctx, &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("new."+testZone, endpoint.RecordTypeA, "1.2.3.4")},
+	}))
+	assert.Empty(t, owner(), "the labels are cached")
+
+	replica.(CacheInvalidator).InvalidateCache()
+	assert.Equal(t, "test-owner", owner())
+}
@@ -18,10 +18,15 @@ package source
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
+	"sync"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
@@ -49,6 +54,15 @@ type crdSource struct {
 	annotationFilter string
 	labelSelector    labels.Selector
 	informer         *cache.SharedInformer
+	// conflictResolutionLabels sets the labels the oldest and priority conflict resolvers rely on
+	conflictResolutionLabels bool
+
+	// observedMux guards the state observed by the last call to Endpoints
+	observedMux sync.Mutex
+	// generations is the generation of every DNSEndpoint listed by Endpoints, by resource label
+	generations map[string]int64
+	// desired are the records of every DNSEndpoint which were returned by Endpoints, by resource label
+	desired map[string][]endpoint.EndpointKey
 }
 
 func addKnownTypes(scheme *runtime.Scheme, groupVersion schema.GroupVersion) error {
@@ -108,7 +122,7 @@ func NewCRDClientForAPIVersionKind(client kubernetes.Interface, kubeConfig, apiS
 }
 
 // NewCRDSource creates a new crdSource with the given config.
-func NewCRDSource(crdClient rest.Interface, namespace, kind string, annotationFilter string, labelSelector labels.Selector, scheme *runtime.Scheme, startInformer bool) (Source, error) {
+func NewCRDSource(crdClient rest.Interface, namespace, kind string, annotationFilter string, labelSelector labels.Selector, scheme *runtime.Scheme, startInformer bool, conflictResolutionLabels bool) (Source, error) {
 	sourceCrd := crdSource{
 		crdResource:      strings.ToLower(kind) + "s",
 		namespace:        namespace,
@@ -116,6 +130,8 @@ func NewCRDSource(crdClient rest.Interface, namespace, kind string, annotationFi
 		labelSelector:    labelSelector,
 		crdClient:        crdClient,
 		codec:            runtime.NewParameterCodec(scheme),
+
+		conflictResolutionLabels: conflictResolutionLabels,
 	}
 	if startInformer {
 		// external-dns already runs its sync-handler periodically (controlled by `--interval` flag) to ensure any
@@ -179,39 +195,28 @@ func (cs *crdSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error
 		return nil, err
 	}
 
+	generations := map[string]int64{}
+	desired := map[string][]endpoint.EndpointKey{}
+
 	for _, dnsEndpoint := range result.Items {
-		// Make sure that all endpoints have targets for A or CNAME type
+		resource := crdResourceLabel(&dnsEndpoint)
+		generations[resource] = dnsEndpoint.Generation
+
+		// Invalid endpoints are refused by the controller, which reports them in the status of the resource
 		crdEndpoints := []*endpoint.Endpoint{}
 		for _, ep := range dnsEndpoint.Spec.Endpoints {
-			if (ep.RecordType == "CNAME" || ep.RecordType == "A" || ep.RecordType == "AAAA") && len(ep.Targets) < 1 {
-				log.Warnf("Endpoint %s with DNSName %s has an empty list of targets", dnsEndpoint.ObjectMeta.Name, ep.DNSName)
-				continue
-			}
-
-			illegalTarget := false
-			for _, target := range ep.Targets {
-				if ep.RecordType != "NAPTR" && strings.HasSuffix(target, ".") {
-					illegalTarget = true
-					break
-				}
-				if ep.RecordType == "NAPTR" && !strings.HasSuffix(target, ".") {
-					illegalTarget = true
-					break
-				}
-			}
-			if illegalTarget {
-				log.Warnf("Endpoint %s with DNSName %s has an illegal target. The subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com')", dnsEndpoint.ObjectMeta.Name, ep.DNSName)
-				continue
-			}
-
 			if ep.Labels == nil {
 				ep.Labels = endpoint.NewLabels()
 			}
 
 			crdEndpoints = append(crdEndpoints, ep)
+			desired[resource] = append(desired[resource], desiredKey(ep))
 		}
 
 		cs.setResourceLabel(&dnsEndpoint, crdEndpoints)
+		if cs.conflictResolutionLabels {
+			setConflictResolutionLabels(&dnsEndpoint.ObjectMeta, crdEndpoints)
+		}
 		endpoints = append(endpoints, crdEndpoints...)
 
 		if dnsEndpoint.Status.ObservedGeneration == dnsEndpoint.Generation {
@@ -226,12 +231,146 @@ func (cs *crdSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error
 		}
 	}
 
+	cs.observedMux.Lock()
+	cs.generations, cs.desired = generations, desired
+	cs.observedMux.Unlock()
+
 	return endpoints, nil
 }
 
+// RecordStatus writes the state of the records of every DNSEndpoint, as observed by the last call to Endpoints
+// and reported by the controller, to its status. statuses are keyed by the resource label of the records.
+func (cs *crdSource) RecordStatus(ctx context.Context, statuses map[string][]endpoint.EndpointStatus) error {
+	result, err := cs.List(ctx, &metav1.ListOptions{LabelSelector: cs.labelSelector.String()})
+	if err != nil {
+		return err
+	}
+
+	cs.observedMux.Lock()
+	defer cs.observedMux.Unlock()
+
+	var errs []error
+	for _, dnsEndpoint := range result.Items {
+		resource := crdResourceLabel(&dnsEndpoint)
+		generation, ok := cs.generations[resource]
+		if !ok {
+			continue
+		}
+
+		status := *dnsEndpoint.Status.DeepCopy()
+		status.Endpoints = mergeEndpointStatuses(dnsEndpoint.Status.Endpoints, statuses[resource], cs.desired[resource])
+		setProgrammedCondition(&status, generation)
+		if reflect.DeepEqual(status, dnsEndpoint.Status) {
+			continue
+		}
+
+		dnsEndpoint.Status = status
+		if _, err := cs.UpdateStatus(ctx, &dnsEndpoint); err != nil {
+			errs = append(errs, fmt.Errorf("updating status of %s: %w", resource, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// desiredKey returns the key of the record as reported by the controller, which plans internationalized names in
+// their punycode form
+func desiredKey(ep *endpoint.Endpoint) endpoint.EndpointKey {
+	key := ep.Key()
+	if name, err := endpoint.ToASCIIName(ep.DNSName); err == nil && name != strings.ToLower(strings.TrimSuffix(ep.DNSName, ".")) {
+		key.DNSName = name
+	}
+	return key
+}
+
+// crdResourceLabel returns the resource label of the records of the DNSEndpoint
+func crdResourceLabel(crd *endpoint.DNSEndpoint) string {
+	return fmt.Sprintf("crd/%s/%s", crd.ObjectMeta.Namespace, crd.ObjectMeta.Name)
+}
+
+// mergeEndpointStatuses returns the status of every desired record, sorted by DNS name, record type
+// and set identifier. Desired records the controller did not report on are pending. The last applied time and
+// zone of a record are kept from its previous status if they are not reported.
+func mergeEndpointStatuses(previous, reported []endpoint.EndpointStatus, desired []endpoint.EndpointKey) []endpoint.EndpointStatus {
+	byKey := map[endpoint.EndpointKey]endpoint.EndpointStatus{}
+	for _, key := range desired {
+		byKey[key] = endpoint.EndpointStatus{
+			DNSName:       key.DNSName,
+			RecordType:    key.RecordType,
+			SetIdentifier: key.SetIdentifier,
+			Condition:     endpoint.EndpointPending,
+			Reason:        "NotPlanned",
+			Message:       "the record has not been planned yet",
+		}
+	}
+	for _, s := range reported {
+		if _, ok := byKey[s.Key()]; ok {
+			byKey[s.Key()] = s
+		}
+	}
+	for _, s := range previous {
+		current, ok := byKey[s.Key()]
+		if !ok {
+			continue
+		}
+		if current.LastAppliedTime == nil {
+			current.LastAppliedTime = s.LastAppliedTime
+		}
+		if current.Zone == "" {
+			current.Zone = s.Zone
+		}
+		byKey[s.Key()] = current
+	}
+
+	merged := make([]endpoint.EndpointStatus, 0, len(byKey))
+	for _, s := range byKey {
+		merged = append(merged, s)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		a, b := merged[i], merged[j]
+		if a.DNSName != b.DNSName {
+			return a.DNSName < b.DNSName
+		}
+		if a.RecordType != b.RecordType {
+			return a.RecordType < b.RecordType
+		}
+		return a.SetIdentifier < b.SetIdentifier
+	})
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// setProgrammedCondition sets the Programmed condition of the DNSEndpoint, which is true when all its records
+// are programmed
+func setProgrammedCondition(status *endpoint.DNSEndpointStatus, generation int64) {
+	condition := metav1.Condition{
+		Type:               endpoint.DNSEndpointProgrammed,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             string(endpoint.EndpointProgrammed),
+		Message:            "All records are programmed",
+	}
+	notProgrammed := 0
+	for _, s := range status.Endpoints {
+		if s.Condition == endpoint.EndpointProgrammed {
+			continue
+		}
+		if notProgrammed == 0 {
+			condition.Status = metav1.ConditionFalse
+			condition.Reason = string(s.Condition)
+		}
+		notProgrammed++
+	}
+	if notProgrammed > 0 {
+		condition.Message = fmt.Sprintf("%d of %d records are not programmed", notProgrammed, len(status.Endpoints))
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+}
+
 func (cs *crdSource) setResourceLabel(crd *endpoint.DNSEndpoint, endpoints []*endpoint.Endpoint) {
 	for _, ep := range endpoints {
-		ep.Labels[endpoint.ResourceLabelKey] = fmt.Sprintf("crd/%s/%s", crd.ObjectMeta.Namespace, crd.ObjectMeta.Name)
+		ep.Labels[endpoint.ResourceLabelKey] = crdResourceLabel(crd)
 	}
 }
 
@@ -26,6 +26,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -103,7 +104,7 @@ func fakeRESTClient(endpoints []*endpoint.Endpoint, apiVersion, kind, namespace,
 
 				var body endpoint.DNSEndpoint
 				decoder.Decode(&body)
-				dnsEndpoint.Status.ObservedGeneration = body.Status.ObservedGeneration
+				dnsEndpoint.Status = body.Status
 				return &http.Response{StatusCode: http.StatusOK, Header: defaultHeader(), Body: objBody(codec, dnsEndpoint)}, nil
 			default:
 				return nil, fmt.Errorf("unexpected request: %#v\n%#v", req.URL, req)
@@ -118,6 +119,7 @@ func TestCRDSource(t *testing.T) {
 	suite.Run(t, new(CRDSuite))
 	t.Run("Interface", testCRDSourceImplementsSource)
 	t.Run("Endpoints", testCRDSourceEndpoints)
+	t.Run("RecordStatus", testCRDSourceRecordStatus)
 }
 
 // testCRDSourceImplementsSource tests that crdSource is a valid Source.
@@ -487,7 +489,7 @@ func testCRDSourceEndpoints(t *testing.T) {
 			// So don't start the informer during testing.
 			startInformer := false
 
-			cs, err := NewCRDSource(restClient, ti.namespace, ti.kind, ti.annotationFilter, labelSelector, scheme, startInformer)
+			cs, err := NewCRDSource(restClient, ti.namespace, ti.kind, ti.annotationFilter, labelSelector, scheme, startInformer, false)
 			require.NoError(t, err)
 
 			receivedEndpoints, err := cs.Endpoints(context.Background())
@@ -511,6 +513,67 @@ func testCRDSourceEndpoints(t *testing.T) {
 	}
 }
 
+// testCRDSourceRecordStatus tests that the state of the records is written to the status of the DNSEndpoint.
+func testCRDSourceRecordStatus(t *testing.T) {
+	apiVersion, kind := "test.k8s.io/v1alpha1", "DNSEndpoint"
+	restClient := fakeRESTClient([]*endpoint.Endpoint{
+		{DNSName: "abc.example.org", Targets: endpoint.Targets{"1.2.3.4"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "def.example.org", Targets: endpoint.Targets{"5.6.7.8"}, RecordType: endpoint.RecordTypeA},
+		{DNSName: "empty.example.org", RecordType: endpoint.RecordTypeA},
+		{DNSName: "bücher.example.org", Targets: endpoint.Targets{"9.9.9.9"}, RecordType: endpoint.RecordTypeA},
+	}, apiVersion, kind, "foo", "test", nil, nil, t)
+
+	groupVersion, err := schema.ParseGroupVersion(apiVersion)
+	require.NoError(t, err)
+	scheme := runtime.NewScheme()
+	require.NoError(t, addKnownTypes(scheme, groupVersion))
+
+	src, err := NewCRDSource(restClient, "foo", kind, "", labels.Everything(), scheme, false, false)
+	require.NoError(t, err)
+	cs := src.(*crdSource)
+
+	_, err = cs.Endpoints(context.Background())
+	require.NoError(t, err)
+
+	applied := metav1.Now()
+	err = cs.RecordStatus(context.Background(), map[string][]endpoint.EndpointStatus{
+		"crd/foo/test": {
+			{DNSName: "abc.example.org", RecordType: endpoint.RecordTypeA, Condition: endpoint.EndpointProgrammed, Zone: "example.org", Owner: "default", LastAppliedTime: &applied},
+			{DNSName: "def.example.org", RecordType: endpoint.RecordTypeA, Condition: endpoint.EndpointPending, Reason: "ProviderError", LastError: "zone is broken"},
+			{DNSName: "empty.example.org", RecordType: endpoint.RecordTypeA, Condition: endpoint.EndpointRejected, Reason: "InvalidTarget", Message: "the list of targets is empty"},
+			// the controller reports internationalized names in their punycode form
+			{DNSName: "xn--bcher-kva.example.org", RecordType: endpoint.RecordTypeA, Condition: endpoint.EndpointProgrammed, Zone: "example.org"},
+		},
+		"crd/foo/other": {
+			{DNSName: "xyz.example.org", RecordType: endpoint.RecordTypeA, Condition: endpoint.EndpointProgrammed},
+		},
+	})
+	require.NoError(t, err)
+
+	result, err := cs.List(context.Background(), &metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, result.Items, 1)
+	status := result.Items[0].Status
+
+	require.Len(t, status.Endpoints, 4)
+	assert.Equal(t, "abc.example.org", status.Endpoints[0].DNSName)
+	assert.Equal(t, endpoint.EndpointProgrammed, status.Endpoints[0].Condition)
+	assert.Equal(t, "example.org", status.Endpoints[0].Zone)
+	assert.NotNil(t, status.Endpoints[0].LastAppliedTime)
+	assert.Equal(t, endpoint.EndpointPending, status.Endpoints[1].Condition)
+	assert.Equal(t, "zone is broken", status.Endpoints[1].LastError)
+	assert.Equal(t, "empty.example.org", status.Endpoints[2].DNSName)
+	assert.Equal(t, endpoint.EndpointRejected, status.Endpoints[2].Condition)
+	assert.Equal(t, "xn--bcher-kva.example.org", status.Endpoints[3].DNSName)
+	assert.Equal(t, endpoint.EndpointProgrammed, status.Endpoints[3].Condition)
+
+	require.Len(t, status.Conditions, 1)
+	assert.Equal(t, endpoint.DNSEndpointProgrammed, status.Conditions[0].Type)
+	assert.Equal(t, metav1.ConditionFalse, status.Conditions[0].Status)
+	assert.Equal(t, string(endpoint.EndpointPending), status.Conditions[0].Reason)
+	assert.Equal(t, int64(1), status.Conditions[0].ObservedGeneration)
+}
+
 func validateCRDResource(t *testing.T, src Source, expectError bool) {
 	cs := src.(*crdSource)
 	result, err := cs.List(context.Background(), &metav1.ListOptions{})
@@ -96,6 +96,7 @@ type gatewayRouteSource struct {
 	fqdnTemplate             *template.Template
 	combineFQDNAnnotation    bool
 	ignoreHostnameAnnotation bool
+	conflictResolutionLabels bool
 }
 
 func newGatewayRouteSource(clients ClientGenerator, config *Config, kind string, newInformerFn newGatewayRouteInformerFunc) (Source, error) {
@@ -175,6 +176,7 @@ func newGatewayRouteSource(clients ClientGenerator, config *Config, kind string,
 		fqdnTemplate:             tmpl,
 		combineFQDNAnnotation:    config.CombineFQDNAndAnnotation,
 		ignoreHostnameAnnotation: config.IgnoreHostnameAnnotation,
+		conflictResolutionLabels: config.ConflictResolutionLabels,
 	}
 	return src, nil
 }
@@ -232,9 +234,14 @@ func (src *gatewayRouteSource) Endpoints(ctx context.Context) ([]*endpoint.Endpo
 		resource := fmt.Sprintf("%s/%s/%s", kind, meta.Namespace, meta.Name)
 		providerSpecific, setIdentifier := getProviderSpecificAnnotations(annots)
 		ttl := getTTLFromAnnotations(annots, resource)
+		var rtEndpoints []*endpoint.Endpoint
 		for host, targets := range hostTargets {
-			endpoints = append(endpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, resource)...)
+			rtEndpoints = append(rtEndpoints, endpointsForHostname(host, targets, ttl, providerSpecific, setIdentifier, resource)...)
 		}
+		if src.conflictResolutionLabels {
+			setConflictResolutionLabels(meta, rtEndpoints)
+		}
+		endpoints = append(endpoints, rtEndpoints...)
 		log.Debugf("Endpoints generated from %s %s/%s: %v", src.rtKind, meta.Namespace, meta.Name, endpoints)
 	}
 	return endpoints, nil
@@ -19,6 +19,7 @@ package source
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -66,6 +67,7 @@ func (suite *IngressSuite) SetupTest() {
 		false,
 		labels.Everything(),
 		[]string{},
+		false,
 	)
 	suite.NoError(err, "should initialize ingress source")
 }
@@ -162,6 +164,7 @@ func TestNewIngressSource(t *testing.T) {
 				false,
 				labels.Everything(),
 				ti.ingressClassNames,
+				false,
 			)
 			if ti.expectError {
 				assert.Error(t, err)
@@ -1416,6 +1419,7 @@ func testIngressEndpoints(t *testing.T) {
 				ti.ignoreIngressRulesSpec,
 				ti.ingressLabelSelector,
 				ti.ingressClassNames,
+				false,
 			)
 			// Informer cache has all of the ingresses. Retrieve and validate their endpoints.
 			res, err := source.Endpoints(context.Background())
@@ -1429,6 +1433,35 @@ func testIngressEndpoints(t *testing.T) {
 	}
 }
 
+func TestIngressSourceConflictResolutionLabels(t *testing.T) {
+	ingress := fakeIngress{
+		name:        "foo",
+		namespace:   "default",
+		dnsnames:    []string{"foo.example.org"},
+		ips:         []string{"8.8.8.8"},
+		annotations: map[string]string{priorityAnnotationKey: "10"},
+	}.Ingress()
+	ingress.CreationTimestamp = metav1.NewTime(time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC))
+	fakeClient := fake.NewSimpleClientset()
+	_, err := fakeClient.NetworkingV1().Ingresses(ingress.Namespace).Create(context.Background(), ingress, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	for _, enabled := range []bool{false, true} {
+		source, err := NewIngressSource(context.TODO(), fakeClient, "", "", "", false, false, false, false, labels.Everything(), nil, enabled)
+		require.NoError(t, err)
+		endpoints, err := source.Endpoints(context.Background())
+		require.NoError(t, err)
+		require.Len(t, endpoints, 1)
+		if enabled {
+			assert.Equal(t, "2024-02-01T09:00:00Z", endpoints[0].Labels[endpoint.CreationTimestampLabelKey])
+			assert.Equal(t, "10", endpoints[0].Labels[endpoint.PriorityLabelKey])
+		} else {
+			assert.NotContains(t, endpoints[0].Labels, endpoint.CreationTimestampLabelKey)
+			assert.NotContains(t, endpoints[0].Labels, endpoint.PriorityLabelKey)
+		}
+	}
+}
+
 // ingress specific helper functions
 type fakeIngress struct {
 	dnsnames         []string
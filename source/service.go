@@ -19,6 +19,7 @@ package source
 import (
 	"context"
 	"fmt"
+	"math"
 	"net"
 	"sort"
 	"strings"
@@ -61,10 +62,11 @@ type serviceSource struct {
 	nodeInformer                   coreinformers.NodeInformer
 	serviceTypeFilter              map[string]struct{}
 	labelSelector                  labels.Selector
+	conflictResolutionLabels       bool
 }
 
 // NewServiceSource creates a new serviceSource with the given config.
-func NewServiceSource(ctx context.Context, kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, compatibility string, publishInternal bool, publishHostIP bool, alwaysPublishNotReadyAddresses bool, serviceTypeFilter []string, ignoreHostnameAnnotation bool, labelSelector labels.Selector, resolveLoadBalancerHostname bool) (Source, error) {
+func NewServiceSource(ctx context.Context, kubeClient kubernetes.Interface, namespace, annotationFilter string, fqdnTemplate string, combineFqdnAnnotation bool, compatibility string, publishInternal bool, publishHostIP bool, alwaysPublishNotReadyAddresses bool, serviceTypeFilter []string, ignoreHostnameAnnotation bool, labelSelector labels.Selector, resolveLoadBalancerHostname bool, conflictResolutionLabels bool) (Source, error) {
 	tmpl, err := parseTemplate(fqdnTemplate)
 	if err != nil {
 		return nil, err
@@ -136,6 +138,7 @@ func NewServiceSource(ctx context.Context, kubeClient kubernetes.Interface, name
 		serviceTypeFilter:              serviceTypes,
 		labelSelector:                  labelSelector,
 		resolveLoadBalancerHostname:    resolveLoadBalancerHostname,
+		conflictResolutionLabels:       conflictResolutionLabels,
 	}, nil
 }
 
@@ -197,6 +200,9 @@ func (sc *serviceSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, e
 
 		log.Debugf("Endpoints generated from service: %s/%s: %v", svc.Namespace, svc.Name, svcEndpoints)
 		sc.setResourceLabel(svc, svcEndpoints)
+		if sc.conflictResolutionLabels {
+			setConflictResolutionLabels(svc, svcEndpoints)
+		}
 		endpoints = append(endpoints, svcEndpoints...)
 	}
 
@@ -503,6 +509,15 @@ func (sc *serviceSource) generateEndpoints(svc *v1.Service, hostname string, pro
 
 	endpoints = append(endpoints, endpointsForHostname(hostname, targets, ttl, providerSpecific, setIdentifier, resource)...)
 
+	// SRV records only make sense if the hostname they point to resolves
+	if len(endpoints) > 0 {
+		for _, ep := range sc.extractSRVEndpoints(svc, hostname, ttl) {
+			ep.ProviderSpecific = providerSpecific
+			ep.SetIdentifier = setIdentifier
+			endpoints = append(endpoints, ep)
+		}
+	}
+
 	return endpoints
 }
 
@@ -711,6 +726,67 @@ func (sc *serviceSource) extractNodePortEndpoints(svc *v1.Service, hostname stri
 	return endpoints
 }
 
+// extractSRVEndpoints generates an SRV record for every port of the service selected by the srv-ports annotation.
+// Following the DNS specification of Kubernetes, the records are named _<port name>._<protocol>.<hostname> and point
+// to the port on the hostname. Unnamed ports use the name of the service, like the SRV records of NodePort services.
+func (sc *serviceSource) extractSRVEndpoints(svc *v1.Service, hostname string, ttl endpoint.TTL) []*endpoint.Endpoint {
+	selected := getSRVPortsFromAnnotations(svc.Annotations)
+	if len(selected) == 0 {
+		return nil
+	}
+	resource := fmt.Sprintf("service/%s/%s", svc.Namespace, svc.Name)
+	priority, weight := getSRVPriorityAndWeightFromAnnotations(svc.Annotations, resource)
+
+	var endpoints []*endpoint.Endpoint
+	for _, port := range svc.Spec.Ports {
+		if !srvPortSelected(selected, port.Name, port.Port) {
+			continue
+		}
+		number := srvPort(svc, port)
+		if number <= 0 || number > math.MaxUint16 {
+			log.Debugf("Skipping SRV record for port %d of service %s/%s without a published port", port.Port, svc.Namespace, svc.Name)
+			continue
+		}
+
+		name := port.Name
+		if name == "" {
+			if svc.Spec.Type == v1.ServiceTypeNodePort {
+				// already published by extractNodePortEndpoints
+				continue
+			}
+			name = svc.Name
+		}
+		protocol := strings.ToLower(string(port.Protocol))
+		if protocol == "" {
+			protocol = "tcp"
+		}
+
+		target := endpoint.SRVTarget{Priority: priority, Weight: weight, Port: uint16(number), Target: hostname}
+		recordName := fmt.Sprintf("_%s._%s.%s", name, protocol, hostname)
+		ep := endpoint.NewEndpointWithTTL(recordName, endpoint.RecordTypeSRV, ttl, target.String())
+		ep.Labels[endpoint.ResourceLabelKey] = resource
+		endpoints = append(endpoints, ep)
+	}
+
+	return endpoints
+}
+
+// srvPort returns the port clients reach the given service port on through the hostname of the service
+func srvPort(svc *v1.Service, port v1.ServicePort) int32 {
+	switch {
+	case svc.Spec.Type == v1.ServiceTypeNodePort:
+		return port.NodePort
+	case svc.Spec.Type == v1.ServiceTypeClusterIP && svc.Spec.ClusterIP == v1.ClusterIPNone:
+		// the hostname of a headless service resolves to the pods
+		if port.TargetPort.IntValue() > 0 {
+			return int32(port.TargetPort.IntValue())
+		}
+		return port.Port
+	default:
+		return port.Port
+	}
+}
+
 func (sc *serviceSource) AddEventHandler(ctx context.Context, handler func()) {
 	log.Debug("Adding event handler for service")
 
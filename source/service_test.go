@@ -29,6 +29,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
 
 	"sigs.k8s.io/external-dns/endpoint"
@@ -79,6 +80,7 @@ func (suite *ServiceSuite) SetupTest() {
 		false,
 		labels.Everything(),
 		false,
+		false,
 	)
 	suite.NoError(err, "should initialize service source")
 }
@@ -160,6 +162,7 @@ func testServiceSourceNewServiceSource(t *testing.T) {
 				false,
 				labels.Everything(),
 				false,
+				false,
 			)
 
 			if ti.expectError {
@@ -1126,6 +1129,7 @@ func testServiceSourceEndpoints(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				sourceLabel,
 				tc.resolveLoadBalancerHostname,
+				false,
 			)
 
 			require.NoError(t, err)
@@ -1316,6 +1320,7 @@ func testMultipleServicesEndpoints(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -1619,6 +1624,7 @@ func TestClusterIpServices(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labelSelector,
 				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -2337,6 +2343,7 @@ func TestServiceSourceNodePortServices(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -3065,6 +3072,7 @@ func TestHeadlessServices(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -3524,6 +3532,7 @@ func TestHeadlessServicesHostIP(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -3702,6 +3711,7 @@ func TestExternalServices(t *testing.T) {
 				tc.ignoreHostnameAnnotation,
 				labels.Everything(),
 				false,
+				false,
 			)
 			require.NoError(t, err)
 
@@ -3718,6 +3728,69 @@ func TestExternalServices(t *testing.T) {
 	}
 }
 
+func TestServiceSourceSRVEndpoints(t *testing.T) {
+	ports := []v1.ServicePort{
+		{Name: "http", Protocol: v1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt32(8080), NodePort: 30080},
+		{Name: "dns", Protocol: v1.ProtocolUDP, Port: 53, TargetPort: intstr.FromString("dns"), NodePort: 30053},
+		{Name: "metrics", Protocol: v1.ProtocolTCP, Port: 9090, TargetPort: intstr.FromInt32(9090), NodePort: 30090},
+	}
+
+	for _, tc := range []struct {
+		title       string
+		svcType     v1.ServiceType
+		clusterIP   string
+		annotations map[string]string
+		expected    []*endpoint.Endpoint
+	}{
+		{
+			title:       "no srv-ports annotation",
+			svcType:     v1.ServiceTypeLoadBalancer,
+			annotations: map[string]string{},
+		},
+		{
+			title:       "load balancer service publishes the service ports",
+			svcType:     v1.ServiceTypeLoadBalancer,
+			annotations: map[string]string{srvPortsAnnotationKey: "http,53"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("_http._tcp.foo.example.org", endpoint.RecordTypeSRV, "0 50 80 foo.example.org"),
+				endpoint.NewEndpoint("_dns._udp.foo.example.org", endpoint.RecordTypeSRV, "0 50 53 foo.example.org"),
+			},
+		},
+		{
+			title:       "node port service publishes the node ports",
+			svcType:     v1.ServiceTypeNodePort,
+			annotations: map[string]string{srvPortsAnnotationKey: "metrics", srvPriorityAnnotationKey: "10", srvWeightAnnotationKey: "5"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("_metrics._tcp.foo.example.org", endpoint.RecordTypeSRV, "10 5 30090 foo.example.org"),
+			},
+		},
+		{
+			title:       "headless service publishes the numeric target ports",
+			svcType:     v1.ServiceTypeClusterIP,
+			clusterIP:   v1.ClusterIPNone,
+			annotations: map[string]string{srvPortsAnnotationKey: "*"},
+			expected: []*endpoint.Endpoint{
+				endpoint.NewEndpoint("_http._tcp.foo.example.org", endpoint.RecordTypeSRV, "0 50 8080 foo.example.org"),
+				endpoint.NewEndpoint("_dns._udp.foo.example.org", endpoint.RecordTypeSRV, "0 50 53 foo.example.org"),
+				endpoint.NewEndpoint("_metrics._tcp.foo.example.org", endpoint.RecordTypeSRV, "0 50 9090 foo.example.org"),
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			svc := &v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "foo", Annotations: tc.annotations},
+				Spec:       v1.ServiceSpec{Type: tc.svcType, ClusterIP: tc.clusterIP, Ports: ports},
+			}
+			// the SRV records are owned by the service, like its other records
+			for _, ep := range tc.expected {
+				ep.Labels[endpoint.ResourceLabelKey] = "service/default/foo"
+			}
+			sc := &serviceSource{}
+			validateEndpoints(t, sc.extractSRVEndpoints(svc, "foo.example.org", 0), tc.expected)
+		})
+	}
+}
+
 func BenchmarkServiceEndpoints(b *testing.B) {
 	kubernetes := fake.NewSimpleClientset()
 
@@ -3757,6 +3830,7 @@ func BenchmarkServiceEndpoints(b *testing.B) {
 		false,
 		labels.Everything(),
 		false,
+		false,
 	)
 	require.NoError(b, err)
 
@@ -60,6 +60,14 @@ const (
 	controllerAnnotationValue = "dns-controller"
 	// The annotation used for defining the desired hostname
 	internalHostnameAnnotationKey = "external-dns.alpha.kubernetes.io/internal-hostname"
+	// The annotation used for defining the priority of a resource when several resources want the same DNS name
+	priorityAnnotationKey = "external-dns.alpha.kubernetes.io/priority"
+	// The annotation used for selecting the service ports SRV records are published for
+	srvPortsAnnotationKey = "external-dns.alpha.kubernetes.io/srv-ports"
+	// The annotation used for defining the priority of the published SRV records
+	srvPriorityAnnotationKey = "external-dns.alpha.kubernetes.io/srv-priority"
+	// The annotation used for defining the weight of the published SRV records
+	srvWeightAnnotationKey = "external-dns.alpha.kubernetes.io/srv-weight"
 )
 
 const (
@@ -105,6 +113,73 @@ func getTTLFromAnnotations(annotations map[string]string, resource string) endpo
 	return endpoint.TTL(ttlValue)
 }
 
+// getSRVPortsFromAnnotations returns the names or numbers of the ports SRV records are published for, "*" selecting
+// all ports. It returns nil if the annotation is not set.
+func getSRVPortsFromAnnotations(annotations map[string]string) []string {
+	srvPortsAnnotation, exists := annotations[srvPortsAnnotationKey]
+	if !exists || strings.TrimSpace(srvPortsAnnotation) == "" {
+		return nil
+	}
+	return splitHostnameAnnotation(srvPortsAnnotation)
+}
+
+// srvPortSelected returns true if the port with the given name and number is selected by the srv-ports annotation
+func srvPortSelected(selected []string, name string, number int32) bool {
+	for _, s := range selected {
+		if s == "*" || (name != "" && s == name) || s == strconv.Itoa(int(number)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getSRVPriorityAndWeightFromAnnotations returns the priority and weight of the published SRV records, defaulting
+// to a priority of 0 and a weight of 50
+func getSRVPriorityAndWeightFromAnnotations(annotations map[string]string, resource string) (uint16, uint16) {
+	priority, weight := uint16(0), uint16(50)
+	if value, exists := annotations[srvPriorityAnnotationKey]; exists {
+		if v, err := strconv.ParseUint(value, 10, 16); err != nil {
+			log.Warnf("%s: %q is not a valid SRV priority: %v", resource, value, err)
+		} else {
+			priority = uint16(v)
+		}
+	}
+	if value, exists := annotations[srvWeightAnnotationKey]; exists {
+		if v, err := strconv.ParseUint(value, 10, 16); err != nil {
+			log.Warnf("%s: %q is not a valid SRV weight: %v", resource, value, err)
+		} else {
+			weight = uint16(v)
+		}
+	}
+	return priority, weight
+}
+
+// setConflictResolutionLabels copies the creation timestamp and the priority annotation of obj to the
+// labels of its endpoints, so that the plan can arbitrate between resources claiming the same DNS name.
+func setConflictResolutionLabels(obj metav1.Object, endpoints []*endpoint.Endpoint) {
+	var priority string
+	if value, ok := obj.GetAnnotations()[priorityAnnotationKey]; ok {
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			log.Warnf("%s/%s: %q is not a valid priority value: %v", obj.GetNamespace(), obj.GetName(), value, err)
+		} else {
+			priority = value
+		}
+	}
+	created := obj.GetCreationTimestamp()
+
+	for _, ep := range endpoints {
+		if ep.Labels == nil {
+			ep.Labels = endpoint.NewLabels()
+		}
+		if !created.IsZero() {
+			ep.Labels[endpoint.CreationTimestampLabelKey] = created.UTC().Format(time.RFC3339)
+		}
+		if priority != "" {
+			ep.Labels[endpoint.PriorityLabelKey] = priority
+		}
+	}
+}
+
 // parseTTL parses TTL from string, returning duration in seconds.
 // parseTTL supports both integers like "600" and durations based
 // on Go Duration like "10m", hence "600" and "10m" represent the same value.
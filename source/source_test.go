@@ -19,8 +19,10 @@ package source
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"sigs.k8s.io/external-dns/endpoint"
 )
@@ -96,3 +98,65 @@ func TestSuitableType(t *testing.T) {
 		}
 	}
 }
+
+func TestSetConflictResolutionLabels(t *testing.T) {
+	for _, tc := range []struct {
+		title          string
+		meta           metav1.ObjectMeta
+		expectedLabels endpoint.Labels
+	}{
+		{
+			title:          "no creation timestamp nor priority",
+			meta:           metav1.ObjectMeta{},
+			expectedLabels: endpoint.Labels{},
+		},
+		{
+			title: "creation timestamp and priority",
+			meta: metav1.ObjectMeta{
+				CreationTimestamp: metav1.NewTime(time.Date(2024, 2, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600))),
+				Annotations:       map[string]string{priorityAnnotationKey: "-5"},
+			},
+			expectedLabels: endpoint.Labels{
+				endpoint.CreationTimestampLabelKey: "2024-02-01T09:00:00Z",
+				endpoint.PriorityLabelKey:          "-5",
+			},
+		},
+		{
+			title: "invalid priority",
+			meta: metav1.ObjectMeta{
+				Annotations: map[string]string{priorityAnnotationKey: "high"},
+			},
+			expectedLabels: endpoint.Labels{},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			ep := &endpoint.Endpoint{DNSName: "example.org"}
+			setConflictResolutionLabels(&tc.meta, []*endpoint.Endpoint{ep})
+			assert.Equal(t, tc.expectedLabels, ep.Labels)
+		})
+	}
+}
+
+func TestSRVAnnotations(t *testing.T) {
+	assert.Nil(t, getSRVPortsFromAnnotations(map[string]string{}))
+	assert.Nil(t, getSRVPortsFromAnnotations(map[string]string{srvPortsAnnotationKey: " "}))
+
+	selected := getSRVPortsFromAnnotations(map[string]string{srvPortsAnnotationKey: "http, 9090"})
+	assert.Equal(t, []string{"http", "9090"}, selected)
+	assert.True(t, srvPortSelected(selected, "http", 80))
+	assert.True(t, srvPortSelected(selected, "metrics", 9090))
+	assert.False(t, srvPortSelected(selected, "grpc", 8080))
+	assert.False(t, srvPortSelected(selected, "", 8080))
+	assert.True(t, srvPortSelected([]string{"*"}, "", 8080))
+
+	priority, weight := getSRVPriorityAndWeightFromAnnotations(map[string]string{}, "service/default/foo")
+	assert.Equal(t, uint16(0), priority)
+	assert.Equal(t, uint16(50), weight)
+
+	priority, weight = getSRVPriorityAndWeightFromAnnotations(map[string]string{
+		srvPriorityAnnotationKey: "10",
+		srvWeightAnnotationKey:   "heavy",
+	}, "service/default/foo")
+	assert.Equal(t, uint16(10), priority)
+	assert.Equal(t, uint16(50), weight)
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// ttlSource is a Source that sets the TTL of the endpoints of its wrapped source according to a TTL policy.
+type ttlSource struct {
+	source Source
+	policy *endpoint.TTLPolicy
+}
+
+// NewTTLSource creates a new ttlSource wrapping the provided Source.
+func NewTTLSource(source Source, policy *endpoint.TTLPolicy) Source {
+	return &ttlSource{source: source, policy: policy}
+}
+
+// Endpoints collects endpoints from its wrapped source and returns them with the TTL given by the policy.
+func (ts *ttlSource) Endpoints(ctx context.Context) ([]*endpoint.Endpoint, error) {
+	endpoints, err := ts.source.Endpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts.policy.Apply(endpoints)
+	return endpoints, nil
+}
+
+func (ts *ttlSource) AddEventHandler(ctx context.Context, handler func()) {
+	ts.source.AddEventHandler(ctx, handler)
+}
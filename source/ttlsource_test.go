@@ -0,0 +1,40 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestTTLSource(t *testing.T) {
+	src := NewTTLSource(NewEchoSource([]*endpoint.Endpoint{
+		endpoint.NewEndpoint("app.example.org", endpoint.RecordTypeA, "1.2.3.4"),
+		endpoint.NewEndpointWithTTL("db.example.org", endpoint.RecordTypeA, 10, "1.2.3.5"),
+	}), &endpoint.TTLPolicy{Default: 300, Min: 60})
+
+	endpoints, err := src.Endpoints(context.Background())
+	require.NoError(t, err)
+	require.Len(t, endpoints, 2)
+	assert.Equal(t, endpoint.TTL(300), endpoints[0].RecordTTL)
+	assert.Equal(t, endpoint.TTL(60), endpoints[1].RecordTTL)
+}